@@ -2,7 +2,9 @@
 // Lightwatch — MongoDB Initialization Script
 // ============================================================================
 //
-// Collections: logs, metrics, security_events, services, alerts
+// Collections: logs, metrics, security_events, services, alerts,
+//              alert_events, alert_states, notifications, silences,
+//              oncall_schedules, escalation_policies, leases
 //
 // Design principles:
 //   1.  Every high-volume collection uses a TTL index on `received_at` so
@@ -277,6 +279,117 @@ safe(() =>
   ),
 );
 
+// ┌─────────────────────────────────────────────────────────────────────────┐
+// │  5b. ALERT ENGINE STATE  (events, lifecycle, notification outbox)       │
+// │                                                                         │
+// │  alert_events    firing / resolved instances, listed newest first       │
+// │  alert_states    one lifecycle document per rule (_id = rule id), plus  │
+// │                  one per active key of per-key rules (alert_id)         │
+// │  notifications   delivery outbox drained by api-go workers; rows hold   │
+// │                  full event snapshots and expire after 30 days          │
+// │  silences        one-off silences and recurring maintenance windows     │
+// │  oncall_schedules     weekly on-call rotations with overrides           │
+// │  escalation_policies  stepped notification policies for alert rules     │
+// │  leases          leader leases (_id = lease name), e.g. alert-engine;   │
+// │                  looked up by _id only, so no secondary index           │
+// │                                                                         │
+// │  Query patterns:                                                        │
+// │    • events by rule / service, sorted by triggered_at desc              │
+// │    • workers claim the oldest due notification by status                │
+// │    • email digests claim due notifications per channel + target         │
+// │    • per-event ordering: earlier undelivered notification per target    │
+// │    • alert groups: last delivery and held notifications per group       │
+// │    • outbox listing by status, sorted by created_at desc                │
+// │    • silences current at a point in time (starts_at / ends_at)          │
+// └─────────────────────────────────────────────────────────────────────────┘
+
+ensureCollection("alert_events");
+ensureCollection("alert_states");
+ensureCollection("notifications");
+ensureCollection("silences");
+ensureCollection("oncall_schedules");
+ensureCollection("escalation_policies");
+ensureCollection("leases");
+
+safe(() =>
+  db.alert_events.createIndex(
+    { alert_id: 1, triggered_at: -1 },
+    { name: "idx_alert_events_alert_triggered", background: true },
+  ),
+);
+
+safe(() =>
+  db.alert_events.createIndex(
+    { service: 1, status: 1, triggered_at: -1 },
+    { name: "idx_alert_events_service_status_triggered", background: true },
+  ),
+);
+
+// Per-key states of a rule, e.g. one per source IP for security rules.
+safe(() =>
+  db.alert_states.createIndex(
+    { alert_id: 1 },
+    { name: "idx_alert_states_alert", sparse: true, background: true },
+  ),
+);
+
+// Worker claim path — "oldest pending notification that is due".
+safe(() =>
+  db.notifications.createIndex(
+    { status: 1, next_attempt_at: 1 },
+    { name: "idx_notifications_status_next_attempt", background: true },
+  ),
+);
+
+safe(() =>
+  db.notifications.createIndex(
+    { status: 1, created_at: -1 },
+    { name: "idx_notifications_status_created", background: true },
+  ),
+);
+
+safe(() =>
+  db.notifications.createIndex(
+    { channel: 1, target: 1, status: 1, next_attempt_at: 1 },
+    { name: "idx_notifications_batch", background: true },
+  ),
+);
+
+safe(() =>
+  db.notifications.createIndex(
+    { event_id: 1, channel: 1, target: 1, created_at: 1 },
+    { name: "idx_notifications_event_order", background: true },
+  ),
+);
+
+safe(() =>
+  db.notifications.createIndex(
+    { channel: 1, target: 1, "group.key": 1, delivered_at: -1 },
+    { name: "idx_notifications_group", background: true },
+  ),
+);
+
+// Outbox retention. Rows are written by api-go, so created_at is our own
+// clock; delivery (max NOTIFY_MAX_ATTEMPTS with backoff) ends within hours,
+// so only delivered and dead-lettered rows are old enough to expire.
+safe(() =>
+  db.notifications.createIndex(
+    { created_at: 1 },
+    {
+      name: "idx_notifications_ttl",
+      expireAfterSeconds: TTL_30_DAYS,
+      background: true,
+    },
+  ),
+);
+
+safe(() =>
+  db.silences.createIndex(
+    { starts_at: 1, ends_at: 1 },
+    { name: "idx_silences_window", background: true },
+  ),
+);
+
 // ┌─────────────────────────────────────────────────────────────────────────┐
 // │  6.  SCHEMA VALIDATION  (server-side)                                  │
 // │                                                                        │
@@ -360,25 +473,41 @@ db.runCommand({
   validationAction: "warn",
 });
 
+// Alert conditions depend on the rule type: metric rules compare a metric
+// against operator + threshold, log and security rules count events with
+// an operator, heartbeat rules check services against grace periods and
+// composite rules combine nested conditions. Rules stored before types
+// existed have no `type` and are threshold rules.
+const ALERT_OPERATORS = ["gt", "gte", "lt", "lte", "eq"];
+
 db.runCommand({
   collMod: "alerts",
   validator: {
     $jsonSchema: {
       bsonType: "object",
-      required: ["name", "service", "condition", "enabled"],
+      required: ["name", "condition", "enabled"],
       properties: {
         name: { bsonType: "string" },
+        type: {
+          bsonType: "string",
+          enum: [
+            "threshold",
+            "rate_change",
+            "anomaly",
+            "log",
+            "security",
+            "heartbeat",
+            "composite",
+          ],
+        },
         service: { bsonType: "string" },
+        selector: { bsonType: "array" },
         enabled: { bsonType: "bool" },
         condition: {
           bsonType: "object",
-          required: ["metric", "operator", "threshold"],
           properties: {
             metric: { bsonType: "string" },
-            operator: {
-              bsonType: "string",
-              enum: ["gt", "lt", "eq", "gte", "lte"],
-            },
+            operator: { bsonType: "string" },
             threshold: { bsonType: "number" },
             duration: { bsonType: "string" },
           },
@@ -386,6 +515,83 @@ db.runCommand({
         created_at: { bsonType: "date" },
         updated_at: { bsonType: "date" },
       },
+      anyOf: [
+        {
+          properties: {
+            type: { enum: ["threshold", "rate_change"] },
+            condition: {
+              required: ["metric", "operator", "threshold"],
+              properties: {
+                metric: { minLength: 1 },
+                operator: { enum: ALERT_OPERATORS },
+              },
+            },
+          },
+        },
+        {
+          required: ["type"],
+          properties: {
+            type: { enum: ["anomaly"] },
+            condition: {
+              required: ["metric"],
+              properties: { metric: { minLength: 1 } },
+            },
+          },
+        },
+        {
+          required: ["type"],
+          properties: {
+            type: { enum: ["log"] },
+            condition: {
+              required: ["logs", "operator", "threshold"],
+              properties: {
+                logs: { bsonType: "object" },
+                operator: { enum: ALERT_OPERATORS },
+              },
+            },
+          },
+        },
+        {
+          required: ["type"],
+          properties: {
+            type: { enum: ["security"] },
+            condition: {
+              required: ["security", "operator", "threshold"],
+              properties: {
+                security: { bsonType: "object" },
+                operator: { enum: ALERT_OPERATORS },
+              },
+            },
+          },
+        },
+        {
+          required: ["type"],
+          properties: {
+            type: { enum: ["heartbeat"] },
+            condition: {
+              properties: { heartbeat: { bsonType: "object" } },
+            },
+          },
+        },
+        {
+          required: ["type"],
+          properties: {
+            type: { enum: ["composite"] },
+            condition: {
+              anyOf: [
+                {
+                  required: ["all"],
+                  properties: { all: { bsonType: "array", minItems: 1 } },
+                },
+                {
+                  required: ["any"],
+                  properties: { any: { bsonType: "array", minItems: 1 } },
+                },
+              ],
+            },
+          },
+        },
+      ],
     },
   },
   validationLevel: "moderate",
@@ -411,5 +617,5 @@ db.runCommand({
 // sh.shardCollection("monitoring.security_events", { service: "hashed" });
 
 print(
-  "✓ Lightwatch MongoDB initialized — 5 collections, indexes, validators, TTLs",
+  "✓ Lightwatch MongoDB initialized — 12 collections, indexes, validators, TTLs",
 );
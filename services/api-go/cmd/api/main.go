@@ -10,15 +10,18 @@ import (
 	"syscall"
 	"time"
 
+	"github.com/redis/go-redis/v9"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 
 	"github.com/lightwatch/monitoring-platform/services/api-go/internal/config"
+	"github.com/lightwatch/monitoring-platform/services/api-go/internal/domain"
 	handler "github.com/lightwatch/monitoring-platform/services/api-go/internal/http"
 	"github.com/lightwatch/monitoring-platform/services/api-go/internal/http/handlers"
 	mw "github.com/lightwatch/monitoring-platform/services/api-go/internal/middleware"
 	"github.com/lightwatch/monitoring-platform/services/api-go/internal/observability"
 	"github.com/lightwatch/monitoring-platform/services/api-go/internal/repository"
+	"github.com/lightwatch/monitoring-platform/services/api-go/internal/stream"
 	"github.com/lightwatch/monitoring-platform/services/api-go/internal/usecase"
 )
 
@@ -43,30 +46,103 @@ func main() {
 
 	db := mongoClient.Database("monitoring")
 
+	// ── Redis ──
+	// Used for realtime fan-out, and for leader election when
+	// LEADER_ELECTION=redis; the API keeps serving if it is down.
+	redisOpts, err := redis.ParseURL(cfg.RedisURL)
+	if err != nil {
+		log.Fatalf("redis url: %v", err)
+	}
+	redisClient := redis.NewClient(redisOpts)
+	defer redisClient.Close()
+
+	if err := redisClient.Ping(ctx).Err(); err != nil {
+		logger.Warn("redis unreachable, alert streaming unavailable until it recovers", map[string]interface{}{
+			"error": err.Error(),
+		})
+	} else {
+		logger.Info("connected to Redis")
+	}
+
 	// ── Repositories ──
 	logsRepo := repository.NewLogsRepository(db)
 	metricsRepo := repository.NewMetricsRepository(db)
 	securityRepo := repository.NewSecurityRepository(db)
 	alertsRepo := repository.NewAlertsRepository(db)
 	alertEventsRepo := repository.NewAlertEventsRepository(db)
+	alertStatesRepo := repository.NewAlertStatesRepository(db)
+	notificationsRepo := repository.NewNotificationsRepository(db)
+	silencesRepo := repository.NewSilencesRepository(db)
+	schedulesRepo := repository.NewSchedulesRepository(db)
+	policiesRepo := repository.NewEscalationPoliciesRepository(db)
 	servicesRepo := repository.NewServicesRepository(db)
 
+	var leasesRepo domain.LeasesRepository
+	switch cfg.LeaderElection {
+	case domain.LeaderElectionMongo:
+		leasesRepo = repository.NewLeasesRepository(db)
+	case domain.LeaderElectionRedis:
+		leasesRepo = repository.NewRedisLeasesRepository(redisClient)
+	case domain.LeaderElectionNone:
+		logger.Warn("leader election disabled, every replica runs the alert engine")
+	default:
+		log.Fatalf("LEADER_ELECTION: unknown backend %q", cfg.LeaderElection)
+	}
+
 	// ── Use Cases ──
 	queryLogsUC := usecase.NewQueryLogs(logsRepo)
 	queryMetricsUC := usecase.NewQueryMetrics(metricsRepo)
 	querySecurityUC := usecase.NewQuerySecurity(securityRepo)
-	manageAlertsUC := usecase.NewManageAlerts(alertsRepo)
+	manageAlertsUC := usecase.NewManageAlerts(alertsRepo, policiesRepo)
+	queryAlertEventsUC := usecase.NewQueryAlertEvents(alertEventsRepo, alertsRepo)
+	manageNotificationsUC := usecase.NewManageNotifications(notificationsRepo)
+	manageSilencesUC := usecase.NewManageSilences(silencesRepo)
+	manageSchedulesUC := usecase.NewManageSchedules(schedulesRepo, policiesRepo)
+	managePoliciesUC := usecase.NewManageEscalationPolicies(policiesRepo, schedulesRepo, alertsRepo)
 	queryServicesUC := usecase.NewQueryServices(servicesRepo)
 
 	// ── Alert Engine ──
-	detectAnomalyUC := usecase.NewDetectAnomaly(alertsRepo, alertEventsRepo, metricsRepo, logger)
+	alertPublisher := stream.NewRedisPublisher(redisClient, cfg.AlertStreamMaxLen)
+	manageAlertEventsUC := usecase.NewManageAlertEvents(alertEventsRepo, alertsRepo, alertStatesRepo, notificationsRepo, alertPublisher, logger)
+	monitorHeartbeatsUC := usecase.NewMonitorHeartbeats(servicesRepo, time.Duration(cfg.HeartbeatDegradedSeconds)*time.Second, time.Duration(cfg.HeartbeatUnhealthySeconds)*time.Second, logger)
+	instanceID := cfg.InstanceID
+	if instanceID == "" {
+		hostname, _ := os.Hostname()
+		instanceID = fmt.Sprintf("%s-%d", hostname, os.Getpid())
+	}
+	electLeaderUC := usecase.NewElectLeader(leasesRepo, cfg.LeaderElection, usecase.EngineLease, instanceID, time.Duration(cfg.LeaderLeaseSeconds)*time.Second, logger)
+	engineInterval := 30 * time.Second
+	detectAnomalyUC := usecase.NewDetectAnomaly(alertsRepo, alertEventsRepo, alertStatesRepo, metricsRepo, logsRepo, securityRepo, notificationsRepo, silencesRepo, policiesRepo, schedulesRepo, monitorHeartbeatsUC, electLeaderUC, alertPublisher, logger)
+	detectAnomalyUC.SetConcurrency(cfg.EngineWorkers, time.Duration(cfg.EngineRuleTimeoutSeconds)*time.Second)
+	backtestAlertUC := usecase.NewBacktestAlert(detectAnomalyUC, engineInterval)
+	deliverNotificationsUC := usecase.NewDeliverNotifications(notificationsRepo, cfg.NotifyWorkers, cfg.NotifyMaxAttempts, cfg.PublicURL, logger)
+	if cfg.SMTPHost != "" {
+		deliverNotificationsUC.Register(domain.ChannelEmail, usecase.NewEmailNotifier(usecase.SMTPSettings{
+			Host:     cfg.SMTPHost,
+			Port:     cfg.SMTPPort,
+			Username: cfg.SMTPUsername,
+			Password: cfg.SMTPPassword,
+			From:     cfg.SMTPFrom,
+			StartTLS: cfg.SMTPStartTLS,
+			Digest:   time.Duration(cfg.EmailDigestMinutes) * time.Minute,
+		}))
+	} else {
+		logger.Info("SMTP_HOST not set, email notifications disabled")
+	}
 
 	// ── Handlers ──
 	logsH := handlers.NewLogsHandler(queryLogsUC)
 	metricsH := handlers.NewMetricsHandler(queryMetricsUC)
 	securityH := handlers.NewSecurityHandler(querySecurityUC)
-	alertsH := handlers.NewAlertsHandler(manageAlertsUC)
-	servicesH := handlers.NewServicesHandler(queryServicesUC)
+	alertsH := handlers.NewAlertsHandler(manageAlertsUC, backtestAlertUC)
+	alertEventsH := handlers.NewAlertEventsHandler(queryAlertEventsUC, manageAlertEventsUC)
+	notificationsH := handlers.NewNotificationsHandler(manageNotificationsUC)
+	silencesH := handlers.NewSilencesHandler(manageSilencesUC)
+	schedulesH := handlers.NewSchedulesHandler(manageSchedulesUC)
+	policiesH := handlers.NewEscalationPoliciesHandler(managePoliciesUC)
+	servicesH := handlers.NewServicesHandler(queryServicesUC, monitorHeartbeatsUC)
+	leaderH := handlers.NewLeaderHandler(electLeaderUC)
+	engineH := handlers.NewEngineHandler(detectAnomalyUC)
 	healthH := handlers.NewHealthHandler()
 
 	// ── Router ──
@@ -75,7 +151,14 @@ func main() {
 		metricsH,
 		securityH,
 		alertsH,
+		alertEventsH,
+		notificationsH,
+		silencesH,
+		schedulesH,
+		policiesH,
 		servicesH,
+		leaderH,
+		engineH,
 		healthH,
 	)
 
@@ -100,7 +183,24 @@ func main() {
 	// ── Start alert engine ──
 	engineCtx, engineCancel := context.WithCancel(context.Background())
 	defer engineCancel()
-	detectAnomalyUC.Start(engineCtx, 30*time.Second)
+	// Only the elected leader evaluates alert rules and heartbeats
+	electLeaderUC.Run(engineCtx, func(ctx context.Context) {
+		if cfg.HeartbeatRules {
+			// In the background, as lead must not hold up lease renewal
+			go func() {
+				created, err := manageAlertsUC.EnsureHeartbeatRules(ctx)
+				if err != nil {
+					logger.Error("heartbeat rules provisioning failed", map[string]interface{}{"error": err.Error()})
+				}
+				if len(created) > 0 {
+					logger.Info("heartbeat rules provisioned", map[string]interface{}{"rules": created})
+				}
+			}()
+		}
+		monitorHeartbeatsUC.Start(ctx, 30*time.Second)
+		detectAnomalyUC.Start(ctx, engineInterval)
+	})
+	deliverNotificationsUC.Start(engineCtx)
 
 	go func() {
 		logger.Info("Lightwatch API listening on :" + cfg.Port)
@@ -118,5 +218,6 @@ func main() {
 	engineCancel()
 	shutCtx, shutCancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer shutCancel()
+	electLeaderUC.Wait(shutCtx) // step down, so another replica takes over at once
 	srv.Shutdown(shutCtx)
 }
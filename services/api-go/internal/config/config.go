@@ -3,6 +3,7 @@ package config
 import (
 	"bufio"
 	"os"
+	"strconv"
 	"strings"
 )
 
@@ -13,6 +14,30 @@ type Config struct {
 	RedisURL string
 	APIKey   string
 	LogLevel string
+
+	PublicURL         string // base URL used for links in notifications
+	AlertStreamMaxLen int64  // approximate MAXLEN for stream:alerts
+	NotifyWorkers     int    // concurrent notification delivery workers
+	NotifyMaxAttempts int    // delivery attempts before dead-lettering
+
+	SMTPHost           string // email notifications are disabled when empty
+	SMTPPort           int
+	SMTPUsername       string
+	SMTPPassword       string
+	SMTPFrom           string
+	SMTPStartTLS       bool // require STARTTLS before authenticating
+	EmailDigestMinutes int  // batch emails per recipient list; 0 sends immediately
+
+	HeartbeatDegradedSeconds  int  // heartbeat age at which a service becomes degraded
+	HeartbeatUnhealthySeconds int  // heartbeat age at which a service becomes unhealthy
+	HeartbeatRules            bool // provision heartbeat rules covering every service
+
+	EngineWorkers            int // rules the alert engine evaluates concurrently
+	EngineRuleTimeoutSeconds int // limit on one rule's evaluation
+
+	LeaderElection     string // lease backend for the alert engine leader: mongo, redis or none
+	LeaderLeaseSeconds int    // lease TTL; the leader renews every third of it
+	InstanceID         string // identifies this replica as lease holder; defaults to hostname-pid
 }
 
 // Load reads .env file (if present), then reads environment with defaults.
@@ -24,6 +49,30 @@ func Load() Config {
 		RedisURL: getEnv("REDIS_URL", "redis://localhost:6379"),
 		APIKey:   getEnv("API_KEY", ""),
 		LogLevel: getEnv("LOG_LEVEL", "info"),
+
+		PublicURL:         strings.TrimRight(getEnv("PUBLIC_URL", "http://localhost:3003"), "/"),
+		AlertStreamMaxLen: getEnvInt64("ALERT_STREAM_MAXLEN", 10000),
+		NotifyWorkers:     int(getEnvInt64("NOTIFY_WORKERS", 4)),
+		NotifyMaxAttempts: int(getEnvInt64("NOTIFY_MAX_ATTEMPTS", 8)),
+
+		SMTPHost:           getEnv("SMTP_HOST", ""),
+		SMTPPort:           int(getEnvInt64("SMTP_PORT", 587)),
+		SMTPUsername:       getEnv("SMTP_USERNAME", ""),
+		SMTPPassword:       getEnv("SMTP_PASSWORD", ""),
+		SMTPFrom:           getEnv("SMTP_FROM", "lightwatch@localhost"),
+		SMTPStartTLS:       getEnvBool("SMTP_STARTTLS", true),
+		EmailDigestMinutes: int(getEnvInt64("EMAIL_DIGEST_MINUTES", 0)),
+
+		HeartbeatDegradedSeconds:  int(getEnvInt64("HEARTBEAT_DEGRADED_SECONDS", 90)),
+		HeartbeatUnhealthySeconds: int(getEnvInt64("HEARTBEAT_UNHEALTHY_SECONDS", 300)),
+		HeartbeatRules:            getEnvBool("HEARTBEAT_RULES", true),
+
+		EngineWorkers:            int(getEnvInt64("ENGINE_WORKERS", 8)),
+		EngineRuleTimeoutSeconds: int(getEnvInt64("ENGINE_RULE_TIMEOUT_SECONDS", 20)),
+
+		LeaderElection:     strings.ToLower(getEnv("LEADER_ELECTION", "mongo")),
+		LeaderLeaseSeconds: int(getEnvInt64("LEADER_LEASE_SECONDS", 30)),
+		InstanceID:         getEnv("INSTANCE_ID", ""),
 	}
 }
 
@@ -34,6 +83,24 @@ func getEnv(key, fallback string) string {
 	return fallback
 }
 
+func getEnvInt64(key string, fallback int64) int64 {
+	if v := os.Getenv(key); v != "" {
+		if n, err := strconv.ParseInt(v, 10, 64); err == nil {
+			return n
+		}
+	}
+	return fallback
+}
+
+func getEnvBool(key string, fallback bool) bool {
+	if v := os.Getenv(key); v != "" {
+		if b, err := strconv.ParseBool(v); err == nil {
+			return b
+		}
+	}
+	return fallback
+}
+
 // loadEnvFile reads a .env file and sets env vars that are not already set.
 func loadEnvFile(path string) {
 	f, err := os.Open(path)
@@ -1,34 +1,169 @@
 package domain
 
-import "time"
+import (
+	"encoding/json"
+	"time"
+)
 
 // AlertCondition defines the threshold rule for triggering an alert.
 type AlertCondition struct {
 	Metric    string  `json:"metric" bson:"metric"`
-	Operator  string  `json:"operator" bson:"operator"`                    // gt, lt, eq, gte, lte
+	Operator  string  `json:"operator" bson:"operator"` // gt, lt, eq, gte, lte
 	Threshold float64 `json:"threshold" bson:"threshold"`
-	Duration  string  `json:"duration,omitempty" bson:"duration,omitempty"` // e.g. "5m"
+	Duration  string  `json:"duration,omitempty" bson:"duration,omitempty"`   // e.g. "5m"
+	Aggregate string  `json:"aggregate,omitempty" bson:"aggregate,omitempty"` // threshold: last, all, avg, min, max, p95
+	Change    string  `json:"change,omitempty" bson:"change,omitempty"`       // rate_change: percent (default), absolute
+	Direction string  `json:"direction,omitempty" bson:"direction,omitempty"` // rate_change, anomaly: up, down, either (default)
+
+	Anomaly   *AnomalyConfig  `json:"anomaly,omitempty" bson:"anomaly,omitempty"`
+	Logs      *LogQuery       `json:"logs,omitempty" bson:"logs,omitempty"`
+	Security  *SecurityQuery  `json:"security,omitempty" bson:"security,omitempty"`
+	Heartbeat *HeartbeatCheck `json:"heartbeat,omitempty" bson:"heartbeat,omitempty"`
+
+	// Composite rules: exactly one of All (every node must hold) or Any
+	All []ConditionNode `json:"all,omitempty" bson:"all,omitempty"`
+	Any []ConditionNode `json:"any,omitempty" bson:"any,omitempty"`
+}
+
+// ConditionNode is one node of a composite rule's condition tree: either a
+// group combining its children with All or Any, or a leaf whose Condition
+// is evaluated the way a rule of Type would evaluate it, on the rule's
+// service.
+type ConditionNode struct {
+	Name      string          `json:"name,omitempty" bson:"name,omitempty"` // leaf name in event meta, default its path, e.g. "all[1]"
+	All       []ConditionNode `json:"all,omitempty" bson:"all,omitempty"`
+	Any       []ConditionNode `json:"any,omitempty" bson:"any,omitempty"`
+	Type      string          `json:"type,omitempty" bson:"type,omitempty"` // threshold (default), rate_change, anomaly, log, security
+	Condition *AlertCondition `json:"condition,omitempty" bson:"condition,omitempty"`
+}
+
+// HeartbeatCheck configures heartbeat rules, which fire for each service
+// whose heartbeats have been missing for longer than its grace period.
+type HeartbeatCheck struct {
+	Status string `json:"status,omitempty" bson:"status,omitempty"` // degraded (default) or unhealthy: fire from this status on
 }
 
+// LogQuery selects the logs that log rules count over the rule's Duration.
+// The service is the rule's; empty fields match every log.
+type LogQuery struct {
+	Level   string            `json:"level,omitempty" bson:"level,omitempty"`     // debug, info, warn, error, fatal
+	Pattern string            `json:"pattern,omitempty" bson:"pattern,omitempty"` // regular expression on the message, case-insensitive
+	Tags    map[string]string `json:"tags,omitempty" bson:"tags,omitempty"`       // exact tag values
+}
+
+// AnomalyConfig tunes the statistical baseline used by anomaly rules.
+// The baseline is built per (service, metric, tag set) from the History
+// window preceding the evaluation window.
+type AnomalyConfig struct {
+	Method      string  `json:"method,omitempty" bson:"method,omitempty"`           // zscore (default), ewma, mad
+	Sensitivity float64 `json:"sensitivity,omitempty" bson:"sensitivity,omitempty"` // deviations from baseline to fire, default 3
+	MinSamples  int     `json:"min_samples,omitempty" bson:"min_samples,omitempty"` // default 30
+	History     string  `json:"history,omitempty" bson:"history,omitempty"`         // baseline window, default "1h"
+	Alpha       float64 `json:"alpha,omitempty" bson:"alpha,omitempty"`             // ewma smoothing factor, default 0.3
+}
+
+// SecurityQuery selects the security events that security rules count over
+// the rule's Duration. With GroupBy, events are counted per distinct value
+// of those fields and the rule fires separately for each offending key,
+// e.g. per source IP. The service is the rule's, if set.
+type SecurityQuery struct {
+	Type     string   `json:"type,omitempty" bson:"type,omitempty"`           // e.g. auth_failure
+	Severity string   `json:"severity,omitempty" bson:"severity,omitempty"`   // low, medium, high, critical
+	SourceIP string   `json:"source_ip,omitempty" bson:"source_ip,omitempty"` // only events from this address
+	GroupBy  []string `json:"group_by,omitempty" bson:"group_by,omitempty"`   // source_ip, service, type
+}
+
+// Fields security rules can group by.
+const (
+	SecurityKeySourceIP = "source_ip"
+	SecurityKeyService  = "service"
+	SecurityKeyType     = "type"
+)
+
+// Baseline methods for anomaly rules.
+const (
+	BaselineZScore = "zscore" // mean / standard deviation
+	BaselineEWMA   = "ewma"   // exponentially weighted mean / deviation
+	BaselineMAD    = "mad"    // median / median absolute deviation
+)
+
+// Aggregations for threshold rules. With a Duration, the default is
+// AggregateAll: every sample covering the window must breach ("value > 90
+// for 5m"). Without a Duration only the latest sample is checked.
+const (
+	AggregateLast = "last"
+	AggregateAll  = "all"
+	AggregateAvg  = "avg"
+	AggregateMin  = "min"
+	AggregateMax  = "max"
+	AggregateP95  = "p95"
+)
+
+// Rate change modes and directions for rate_change rules.
+const (
+	ChangePercent  = "percent"
+	ChangeAbsolute = "absolute"
+
+	DirectionUp     = "up"
+	DirectionDown   = "down"
+	DirectionEither = "either"
+)
+
 // AlertType enumerates supported alert detection strategies.
 const (
 	AlertTypeThreshold  = "threshold"
 	AlertTypeRateChange = "rate_change"
 	AlertTypeAnomaly    = "anomaly"
+	AlertTypeLog        = "log"
+	AlertTypeSecurity   = "security"
+	AlertTypeHeartbeat  = "heartbeat"
+	AlertTypeComposite  = "composite"
 )
 
 // Alert represents an alert rule definition.
 type Alert struct {
-	ID        string         `json:"id" bson:"_id,omitempty"`
-	Name      string         `json:"name" bson:"name"`
-	Type      string         `json:"type" bson:"type"` // threshold, rate_change, anomaly
-	Condition AlertCondition `json:"condition" bson:"condition"`
-	Service   string         `json:"service" bson:"service"`
-	Enabled   bool           `json:"enabled" bson:"enabled"`
-	Channels  []string       `json:"channels,omitempty" bson:"channels,omitempty"` // websocket, webhook
-	Webhook   string         `json:"webhook,omitempty" bson:"webhook,omitempty"`   // webhook URL
-	CreatedAt time.Time      `json:"created_at" bson:"created_at"`
-	UpdatedAt time.Time      `json:"updated_at" bson:"updated_at"`
+	ID                 string               `json:"id" bson:"_id,omitempty"`
+	Name               string               `json:"name" bson:"name"`
+	Type               string               `json:"type" bson:"type"` // threshold, rate_change, anomaly, log, security, heartbeat, composite
+	Condition          AlertCondition       `json:"condition" bson:"condition"`
+	Service            string               `json:"service" bson:"service"`
+	Selector           []Matcher            `json:"selector,omitempty" bson:"selector,omitempty"` // series to watch, e.g. host=~srv-.*; the rule fires per series
+	Enabled            bool                 `json:"enabled" bson:"enabled"`
+	Severity           string               `json:"severity,omitempty" bson:"severity,omitempty"`                       // critical (default), error, warning, info
+	Channels           []string             `json:"channels,omitempty" bson:"channels,omitempty"`                       // websocket, webhook
+	Webhook            string               `json:"webhook,omitempty" bson:"webhook,omitempty"`                         // webhook URL
+	WebhookSecrets     []string             `json:"webhook_secrets,omitempty" bson:"webhook_secrets,omitempty"`         // HMAC signing secrets, at most two during rotation; write-only
+	Targets            []NotificationTarget `json:"targets,omitempty" bson:"targets,omitempty"`                         // chat and webhook destinations
+	Grouping           *AlertGrouping       `json:"grouping,omitempty" bson:"grouping,omitempty"`                       // batch notifications with related alerts
+	EscalationPolicy   string               `json:"escalation_policy,omitempty" bson:"escalation_policy,omitempty"`     // EscalationPolicy ID, notified besides Webhook and Targets
+	PendingFor         string               `json:"pending_for,omitempty" bson:"pending_for,omitempty"`                 // breach must persist this long before firing, e.g. "2m"
+	EvaluationInterval string               `json:"evaluation_interval,omitempty" bson:"evaluation_interval,omitempty"` // evaluate less often than every engine tick, e.g. "5m"
+	Version            int64                `json:"version" bson:"version"`                                             // optimistic concurrency; incremented on every write
+	CreatedAt          time.Time            `json:"created_at" bson:"created_at"`
+	UpdatedAt          time.Time            `json:"updated_at" bson:"updated_at"`
+}
+
+// MarshalJSON encodes the rule without its webhook secrets, which would let
+// anyone who can read the rule forge its deliveries. webhook_secret_count
+// reports how many are set instead.
+func (a Alert) MarshalJSON() ([]byte, error) {
+	type alert Alert // without this method
+	return json.Marshal(struct {
+		alert
+		WebhookSecrets     []string `json:"webhook_secrets,omitempty"`
+		WebhookSecretCount int      `json:"webhook_secret_count,omitempty"`
+	}{alert: alert(a), WebhookSecretCount: len(a.WebhookSecrets)})
+}
+
+// AlertGrouping batches a rule's notifications with those of other alerts
+// sent to the same target, as Alertmanager does: alerts whose GroupBy
+// labels have the same values form one group, and each target receives one
+// message per group listing them.
+type AlertGrouping struct {
+	GroupBy        []string `json:"group_by" bson:"group_by"`                                   // label names, e.g. ["service"]; empty groups everything per target
+	GroupWait      string   `json:"group_wait,omitempty" bson:"group_wait,omitempty"`           // collect alerts of a new group this long, default "30s"
+	GroupInterval  string   `json:"group_interval,omitempty" bson:"group_interval,omitempty"`   // minimum time between messages for a group, default "5m"
+	RepeatInterval string   `json:"repeat_interval,omitempty" bson:"repeat_interval,omitempty"` // re-notify a still-firing alert after, default "4h"
 }
 
 // AlertEvent represents a triggered alert instance.
@@ -40,8 +175,114 @@ type AlertEvent struct {
 	TraceID     string                 `json:"trace_id,omitempty" bson:"trace_id,omitempty"`
 	Value       float64                `json:"value" bson:"value"`
 	Threshold   float64                `json:"threshold" bson:"threshold"`
-	Status      string                 `json:"status" bson:"status"` // firing, resolved
+	Status      string                 `json:"status" bson:"status"` // firing, acknowledged, resolved
+	Labels      map[string]string      `json:"labels,omitempty" bson:"labels,omitempty"`
+	SilencedBy  []string               `json:"silenced_by,omitempty" bson:"silenced_by,omitempty"` // silences that suppressed notifications
 	Meta        map[string]interface{} `json:"meta,omitempty" bson:"meta,omitempty"`
 	TriggeredAt time.Time              `json:"triggered_at" bson:"triggered_at"`
 	ResolvedAt  *time.Time             `json:"resolved_at,omitempty" bson:"resolved_at,omitempty"`
+	AckedBy     string                 `json:"acked_by,omitempty" bson:"acked_by,omitempty"`
+	AckedAt     *time.Time             `json:"acked_at,omitempty" bson:"acked_at,omitempty"`
+	Assignee    string                 `json:"assignee,omitempty" bson:"assignee,omitempty"`
+	Timeline    []AlertEventEntry      `json:"timeline,omitempty" bson:"timeline,omitempty"` // acks, assignments and comments, oldest first
+}
+
+// AlertEventEntry is one item of an alert event's timeline.
+type AlertEventEntry struct {
+	Kind      string    `json:"kind" bson:"kind"` // acknowledged, assigned, comment
+	Author    string    `json:"author" bson:"author"`
+	Text      string    `json:"text,omitempty" bson:"text,omitempty"` // the comment, or the new assignee
+	CreatedAt time.Time `json:"created_at" bson:"created_at"`
+}
+
+// Alert event timeline entry kinds.
+const (
+	TimelineAcknowledged = "acknowledged"
+	TimelineAssigned     = "assigned"
+	TimelineComment      = "comment"
+)
+
+// Alert lifecycle states. A rule moves inactive → pending → firing →
+// resolved; AlertEvent.Status uses the firing and resolved values.
+const (
+	AlertStateInactive = "inactive"
+	AlertStatePending  = "pending"
+	AlertStateFiring   = "firing"
+	AlertStateResolved = "resolved"
+)
+
+// AlertEventAcknowledged is the status of a firing AlertEvent that an
+// operator has acknowledged. It resolves like a firing event.
+const AlertEventAcknowledged = "acknowledged"
+
+// Alert severities, following the PagerDuty Events API v2 levels.
+const (
+	SeverityCritical = "critical"
+	SeverityError    = "error"
+	SeverityWarning  = "warning"
+	SeverityInfo     = "info"
+)
+
+// AlertState is the persisted lifecycle state of an alert rule. The engine
+// only creates or updates AlertEvents when the state changes. Rules that
+// fire per key, such as security rules grouped by source IP, have one
+// state per active key; it is removed once the key is no longer active.
+type AlertState struct {
+	ID              string               `json:"id" bson:"_id"`                                // alert rule ID, plus the key for per-key states
+	AlertID         string               `json:"alert_id,omitempty" bson:"alert_id,omitempty"` // rule of a per-key state
+	Labels          map[string]string    `json:"labels,omitempty" bson:"labels,omitempty"`     // key of a per-key state
+	State           string               `json:"state" bson:"state"`
+	ActiveSince     *time.Time           `json:"active_since,omitempty" bson:"active_since,omitempty"` // first breach of the current episode
+	EventID         string               `json:"event_id,omitempty" bson:"event_id,omitempty"`         // AlertEvent of the current or last firing
+	LastValue       float64              `json:"last_value" bson:"last_value"`
+	LastEvaluatedAt time.Time            `json:"last_evaluated_at" bson:"last_evaluated_at"`
+	LastNotifiedAt  *time.Time           `json:"last_notified_at,omitempty" bson:"last_notified_at,omitempty"` // notifications last queued for the current firing
+	EscalationStep  int                  `json:"escalation_step,omitempty" bson:"escalation_step,omitempty"`   // next step of the rule's escalation policy
+	Escalated       []NotificationTarget `json:"-" bson:"escalated,omitempty"`                                 // targets paged by escalation, told when the alert resolves
+	UpdatedAt       time.Time            `json:"updated_at" bson:"updated_at"`                                 // last state change
+	Fence           int64                `json:"-" bson:"fence,omitempty"`                                     // fencing token of the leader that wrote it
+}
+
+// RuleInfo is the subset of a rule copied into notifications, so they can
+// be rendered later without depending on the rule still existing.
+type RuleInfo struct {
+	ID        string         `json:"id" bson:"id"`
+	Name      string         `json:"name" bson:"name"`
+	Type      string         `json:"type" bson:"type"`
+	Severity  string         `json:"severity" bson:"severity"`
+	Condition AlertCondition `json:"condition" bson:"condition"`
+}
+
+// BacktestRequest asks how a rule would have behaved over a past time
+// range. Step defaults to the rule's evaluation interval, or the engine's.
+type BacktestRequest struct {
+	Rule Alert     `json:"rule"`
+	From time.Time `json:"from"`
+	To   time.Time `json:"to"` // default now
+	Step string    `json:"step,omitempty"`
+}
+
+// BacktestResult is the outcome of replaying a rule over a time range:
+// every period it would have fired, and how many notifications its
+// targets would have received.
+type BacktestResult struct {
+	From          time.Time         `json:"from"`
+	To            time.Time         `json:"to"`
+	Step          string            `json:"step"`
+	Evaluations   int               `json:"evaluations"`
+	Firings       int               `json:"firings"`
+	Notifications int               `json:"notifications"` // messages to the rule's targets, without escalation
+	Episodes      []BacktestEpisode `json:"episodes"`
+	TimedOut      bool              `json:"timed_out,omitempty"` // ran out of time; To is the last step evaluated
+}
+
+// BacktestEpisode is one period during which a backtested rule would have
+// fired.
+type BacktestEpisode struct {
+	Labels     map[string]string `json:"labels,omitempty"` // series or key, for rules that fire per key
+	FiredAt    time.Time         `json:"fired_at"`
+	ResolvedAt *time.Time        `json:"resolved_at,omitempty"` // nil if still firing at the end of the range
+	Value      float64           `json:"value"`                 // when it fired
+	Threshold  float64           `json:"threshold"`
+	Silenced   bool              `json:"silenced,omitempty"` // a current silence matched when it fired
 }
@@ -0,0 +1,32 @@
+package domain
+
+import "time"
+
+// EngineStats reports the alert engine's evaluation loop on one replica.
+// Only the leader evaluates rules, so followers report no ticks.
+type EngineStats struct {
+	Workers       int         `json:"workers"`      // concurrent rule evaluations
+	RuleTimeout   string      `json:"rule_timeout"` // per-rule evaluation limit
+	Ticks         int64       `json:"ticks"`
+	SkippedTicks  int64       `json:"skipped_ticks"` // missed because the previous tick overran the interval
+	LastTickAt    *time.Time  `json:"last_tick_at,omitempty"`
+	LastTickRules int         `json:"last_tick_rules"` // rules due in the last tick
+	LastTickMs    float64     `json:"last_tick_ms"`
+	AvgTickMs     float64     `json:"avg_tick_ms"`
+	MaxTickMs     float64     `json:"max_tick_ms"`
+	Rules         []RuleStats `json:"rules"` // slowest first
+}
+
+// RuleStats reports the evaluations of one rule since the engine started.
+type RuleStats struct {
+	AlertID         string    `json:"alert_id"`
+	Name            string    `json:"name"`
+	Interval        string    `json:"interval,omitempty"` // the rule's own evaluation interval
+	Evaluations     int64     `json:"evaluations"`
+	Failures        int64     `json:"failures"`
+	Timeouts        int64     `json:"timeouts"`
+	LastEvaluatedAt time.Time `json:"last_evaluated_at"`
+	LastMs          float64   `json:"last_ms"`
+	AvgMs           float64   `json:"avg_ms"`
+	MaxMs           float64   `json:"max_ms"`
+}
@@ -0,0 +1,24 @@
+package domain
+
+import "errors"
+
+// Sentinel errors returned by repositories and use cases. Handlers map them
+// to HTTP status codes.
+var (
+	ErrNotFound = errors.New("not found")
+	ErrConflict = errors.New("conflict")
+
+	// ErrNotLeader is returned for writes attempted by a replica that no
+	// longer holds the leader lease.
+	ErrNotLeader = errors.New("not the leader")
+)
+
+// ValidationError reports an invalid field in a request payload.
+type ValidationError struct {
+	Field   string
+	Message string
+}
+
+func (e *ValidationError) Error() string {
+	return e.Field + " " + e.Message
+}
@@ -0,0 +1,37 @@
+package domain
+
+import "time"
+
+// Lease is a time-bound claim on a role that only one replica may hold at
+// a time, such as running the alert engine. Each new holder gets a higher
+// Token; writes carry it so those of a stale holder can be refused.
+type Lease struct {
+	Name       string    `json:"name" bson:"_id"`
+	Holder     string    `json:"holder" bson:"holder"` // instance ID
+	Token      int64     `json:"token" bson:"token"`   // fencing token, incremented on every takeover
+	AcquiredAt time.Time `json:"acquired_at" bson:"acquired_at"`
+	RenewedAt  time.Time `json:"renewed_at" bson:"renewed_at"`
+	ExpiresAt  time.Time `json:"expires_at" bson:"expires_at"`
+}
+
+// Leader election backends.
+const (
+	LeaderElectionMongo = "mongo"
+	LeaderElectionRedis = "redis"
+	LeaderElectionNone  = "none" // every replica leads
+)
+
+// LeaderStatus reports who holds a leader lease, as seen by one replica.
+type LeaderStatus struct {
+	Backend    string `json:"backend"`
+	InstanceID string `json:"instance_id"` // the replica answering
+	Leader     bool   `json:"leader"`      // whether it currently leads
+	Lease      *Lease `json:"lease,omitempty"`
+}
+
+// Fence guards writes that only the leader may make.
+type Fence interface {
+	// Token returns the fencing token of the current leadership, or
+	// ErrNotLeader once it has been lost. It is 0 when election is off.
+	Token() (int64, error)
+}
@@ -0,0 +1,79 @@
+package domain
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Notification delivery statuses.
+const (
+	NotificationPending   = "pending"   // waiting for its next delivery attempt
+	NotificationSending   = "sending"   // claimed by a delivery worker
+	NotificationDelivered = "delivered" // receiver acknowledged with a 2xx
+	NotificationDead      = "dead"      // attempts exhausted; retry manually
+)
+
+// Notification channels.
+const (
+	ChannelWebhook    = "webhook"    // generic JSON POST, optionally templated and signed
+	ChannelSlack      = "slack"      // Slack incoming webhook, Block Kit message
+	ChannelTeams      = "teams"      // Microsoft Teams incoming webhook, Adaptive Card
+	ChannelMattermost = "mattermost" // Mattermost (or other Slack-compatible) incoming webhook
+	ChannelEmail      = "email"      // SMTP, multipart HTML and plaintext
+	ChannelPagerDuty  = "pagerduty"  // PagerDuty Events API v2 incident
+	ChannelOpsgenie   = "opsgenie"   // Opsgenie Alert API
+)
+
+// NotificationTarget is one destination for a rule's notifications.
+type NotificationTarget struct {
+	Type string   `json:"type" bson:"type"`                     // one of the Channel* constants
+	URL  string   `json:"url,omitempty" bson:"url,omitempty"`   // incoming webhook URL for HTTP channels
+	To   []string `json:"to,omitempty" bson:"to,omitempty"`     // email recipients
+	Key  string   `json:"key,omitempty" bson:"key,omitempty"`   // PagerDuty routing key or Opsgenie API key; write-only
+	Body string   `json:"body,omitempty" bson:"body,omitempty"` // text/template replacing the request body of webhook and chat targets
+}
+
+// MarshalJSON encodes the target without its key, which would let anyone
+// who can read a rule, policy or schedule raise incidents in its name.
+// key_set reports whether one is stored.
+func (t NotificationTarget) MarshalJSON() ([]byte, error) {
+	type target NotificationTarget // without this method
+	return json.Marshal(struct {
+		target
+		Key    string `json:"key,omitempty"`
+		KeySet bool   `json:"key_set,omitempty"`
+	}{target: target(t), KeySet: t.Key != ""})
+}
+
+// Notification is an outbox entry: one alert event transition to deliver to
+// one target. Entries are written by the alert engine and drained by the
+// delivery workers, so a receiver outage delays a page instead of losing it.
+type Notification struct {
+	ID            string             `json:"id" bson:"_id,omitempty"`
+	AlertID       string             `json:"alert_id" bson:"alert_id"`
+	EventID       string             `json:"event_id" bson:"event_id"`
+	Channel       string             `json:"channel" bson:"channel"`                       // Channel* constant
+	Target        string             `json:"target" bson:"target"`                         // receiver URL, or comma-separated email recipients
+	Event         AlertEvent         `json:"event" bson:"event"`                           // snapshot at enqueue time
+	Rule          RuleInfo           `json:"rule" bson:"rule"`                             // snapshot at enqueue time
+	Group         *NotificationGroup `json:"group,omitempty" bson:"group,omitempty"`       // set when the rule groups its notifications
+	Template      string             `json:"template,omitempty" bson:"template,omitempty"` // custom body template
+	Secrets       []string           `json:"-" bson:"secrets,omitempty"`                   // signing secrets, never exposed over the API
+	Status        string             `json:"status" bson:"status"`                         // pending, sending, delivered, dead
+	Attempts      int                `json:"attempts" bson:"attempts"`
+	NextAttemptAt time.Time          `json:"next_attempt_at" bson:"next_attempt_at"`
+	LockedUntil   *time.Time         `json:"locked_until,omitempty" bson:"locked_until,omitempty"` // lease held while sending
+	LastError     string             `json:"last_error,omitempty" bson:"last_error,omitempty"`
+	CreatedAt     time.Time          `json:"created_at" bson:"created_at"`
+	UpdatedAt     time.Time          `json:"updated_at" bson:"updated_at"`
+	DeliveredAt   *time.Time         `json:"delivered_at,omitempty" bson:"delivered_at,omitempty"`
+}
+
+// NotificationGroup places a notification in an alert group. Notifications
+// for the same channel, target and Key are delivered together.
+type NotificationGroup struct {
+	Key      string            `json:"key" bson:"key"`           // group labels, e.g. "{service=api-gateway}"
+	Labels   map[string]string `json:"labels" bson:"labels"`     // values of the rule's group_by labels
+	Wait     string            `json:"wait" bson:"wait"`         // group_wait of the rule
+	Interval string            `json:"interval" bson:"interval"` // group_interval of the rule
+}
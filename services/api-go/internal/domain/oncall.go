@@ -0,0 +1,60 @@
+package domain
+
+import "time"
+
+// OnCallSchedule is a weekly rotation: Members take turns in list order,
+// handing over every 7 days at the weekday and time of Handoff. Overrides
+// replace the rotation for a period, e.g. to swap shifts or cover leave.
+type OnCallSchedule struct {
+	ID        string           `json:"id" bson:"_id,omitempty"`
+	Name      string           `json:"name" bson:"name"`
+	Timezone  string           `json:"timezone,omitempty" bson:"timezone,omitempty"` // IANA name for handoffs, default UTC
+	Handoff   time.Time        `json:"handoff" bson:"handoff"`                       // start of the first shift
+	Members   []OnCallMember   `json:"members" bson:"members"`                       // in rotation order
+	Overrides []OnCallOverride `json:"overrides,omitempty" bson:"overrides,omitempty"`
+	CreatedAt time.Time        `json:"created_at" bson:"created_at"`
+	UpdatedAt time.Time        `json:"updated_at" bson:"updated_at"`
+}
+
+// OnCallMember is a responder and the targets that reach them.
+type OnCallMember struct {
+	Name    string               `json:"name" bson:"name"`
+	Targets []NotificationTarget `json:"targets" bson:"targets"`
+}
+
+// OnCallOverride puts Member on call from StartsAt until EndsAt. When
+// overrides overlap, the last one in the list wins.
+type OnCallOverride struct {
+	Member   OnCallMember `json:"member" bson:"member"`
+	StartsAt time.Time    `json:"starts_at" bson:"starts_at"`
+	EndsAt   time.Time    `json:"ends_at" bson:"ends_at"`
+}
+
+// OnCallShift is who is on call for a schedule at a point in time.
+type OnCallShift struct {
+	ScheduleID   string       `json:"schedule_id"`
+	ScheduleName string       `json:"schedule_name"`
+	Member       OnCallMember `json:"member"`
+	StartsAt     time.Time    `json:"starts_at"`
+	EndsAt       time.Time    `json:"ends_at"`
+	Override     bool         `json:"override"` // covered by an override rather than the rotation
+}
+
+// EscalationPolicy notifies responders in steps until the alert is
+// acknowledged or resolves. Rules reference a policy by ID.
+type EscalationPolicy struct {
+	ID          string           `json:"id" bson:"_id,omitempty"`
+	Name        string           `json:"name" bson:"name"`
+	Description string           `json:"description,omitempty" bson:"description,omitempty"`
+	Steps       []EscalationStep `json:"steps" bson:"steps"`
+	CreatedAt   time.Time        `json:"created_at" bson:"created_at"`
+	UpdatedAt   time.Time        `json:"updated_at" bson:"updated_at"`
+}
+
+// EscalationStep notifies whoever is on call for Schedules, plus Targets,
+// once the alert has been firing for After.
+type EscalationStep struct {
+	After     string               `json:"after,omitempty" bson:"after,omitempty"`         // time since the alert fired, e.g. "15m"; default immediately
+	Schedules []string             `json:"schedules,omitempty" bson:"schedules,omitempty"` // OnCallSchedule IDs
+	Targets   []NotificationTarget `json:"targets,omitempty" bson:"targets,omitempty"`     // fixed destinations, e.g. the team channel
+}
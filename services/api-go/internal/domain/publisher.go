@@ -0,0 +1,9 @@
+package domain
+
+import "context"
+
+// AlertPublisher fans out alert event transitions (firing, resolved) to
+// realtime consumers such as the WebSocket service.
+type AlertPublisher interface {
+	PublishAlert(ctx context.Context, event *AlertEvent) error
+}
@@ -1,6 +1,9 @@
 package domain
 
-import "context"
+import (
+	"context"
+	"time"
+)
 
 // ── Repository Interfaces ──
 // These interfaces enable dependency injection and testability.
@@ -14,30 +17,111 @@ type LogsRepository interface {
 // MetricsRepository defines the contract for metric event persistence.
 type MetricsRepository interface {
 	Find(ctx context.Context, f MetricsFilter) ([]MetricEvent, int64, error)
+	// Scan returns the first f.Limit events matching f in one query, without
+	// counting them or clamping the limit. Page is ignored; more reports
+	// whether further events matched.
+	Scan(ctx context.Context, f MetricsFilter) (events []MetricEvent, more bool, err error)
 }
 
 // SecurityRepository defines the contract for security event persistence.
 type SecurityRepository interface {
 	Find(ctx context.Context, f SecurityFilter) ([]SecurityEvent, int64, error)
+	// Count counts the events matching f per distinct value of the groupBy
+	// fields, largest counts first. Limit bounds the number of keys.
+	Count(ctx context.Context, f SecurityFilter, groupBy []string) ([]SecurityCount, error)
 }
 
 // AlertsRepository defines the contract for alert rule persistence.
 type AlertsRepository interface {
 	FindAll(ctx context.Context) ([]Alert, error)
+	FindByID(ctx context.Context, id string) (*Alert, error)
 	FindEnabled(ctx context.Context, service string) ([]Alert, error)
 	Create(ctx context.Context, alert *Alert) (string, error)
+	// Update replaces the rule if its stored version equals alert.Version,
+	// returning ErrConflict otherwise. The version is incremented.
+	Update(ctx context.Context, alert *Alert) error
+	SetEnabled(ctx context.Context, id string, enabled bool) (*Alert, error)
+	Delete(ctx context.Context, id string) error
 }
 
 // AlertEventsRepository defines the contract for triggered alert persistence.
 type AlertEventsRepository interface {
 	Create(ctx context.Context, event *AlertEvent) (string, error)
+	Find(ctx context.Context, f AlertEventsFilter) ([]AlertEvent, int64, error)
 	FindByAlert(ctx context.Context, alertID string, limit int) ([]AlertEvent, error)
 	FindRecent(ctx context.Context, limit int) ([]AlertEvent, error)
+	FindByID(ctx context.Context, id string) (*AlertEvent, error)
+	Resolve(ctx context.Context, id string, resolvedAt time.Time) (*AlertEvent, error)
+	// Acknowledge moves a firing event to acknowledged, recording entry as
+	// the acknowledgement. It returns ErrConflict if the event is not firing.
+	Acknowledge(ctx context.Context, id string, entry AlertEventEntry) (*AlertEvent, error)
+	// Assign sets the event's assignee to entry.Text.
+	Assign(ctx context.Context, id string, entry AlertEventEntry) (*AlertEvent, error)
+	AddComment(ctx context.Context, id string, entry AlertEventEntry) (*AlertEvent, error)
+}
+
+// AlertStatesRepository defines the contract for alert lifecycle state persistence.
+type AlertStatesRepository interface {
+	Find(ctx context.Context, alertID string) (*AlertState, error)         // nil if the rule has never been evaluated
+	FindByAlert(ctx context.Context, alertID string) ([]AlertState, error) // the rule's state and its per-key states
+	// Save and Delete refuse, with ErrNotLeader, to touch a state last
+	// written under a higher fencing token than state.Fence or fence.
+	Save(ctx context.Context, state *AlertState) error
+	Delete(ctx context.Context, id string, fence int64) error
+}
+
+// LeasesRepository defines the contract for leader leases. Expiry is judged
+// by the store's clock, so replicas need not agree on the time.
+type LeasesRepository interface {
+	// Acquire renews the lease if holder holds it, or takes it over with
+	// the next token if it is free or expired. It returns the lease as it
+	// now stands, which is held by another replica if the takeover failed.
+	Acquire(ctx context.Context, name, holder string, ttl time.Duration) (*Lease, error)
+	// Release expires the lease if holder still holds it under token.
+	Release(ctx context.Context, name, holder string, token int64) error
+	Find(ctx context.Context, name string) (*Lease, error) // ErrNotFound if never held
+}
+
+// NotificationsRepository defines the contract for the notification outbox.
+type NotificationsRepository interface {
+	Create(ctx context.Context, n *Notification) (string, error)
+	Find(ctx context.Context, f NotificationsFilter) ([]Notification, int64, error)
+	// ClaimDue leases the oldest due notification (pending and past its
+	// NextAttemptAt, or sending with an expired lease) for delivery.
+	// It returns nil when nothing is due.
+	ClaimDue(ctx context.Context, now time.Time, lease time.Duration) (*Notification, error)
+	// ClaimBatch leases up to limit further due pending notifications that
+	// can be sent in one message with like: the same channel, target, body
+	// template and signing secrets. With a group key only that group's
+	// notifications are claimed, and those held back for the group's next
+	// message count as due.
+	ClaimBatch(ctx context.Context, like *Notification, group string, now time.Time, lease time.Duration, limit int) ([]Notification, error)
+	// LastDelivered returns when the group's latest message to the target
+	// was delivered, or nil if none has been.
+	LastDelivered(ctx context.Context, channel, target, group string) (*time.Time, error)
+	// HasEarlierUndelivered reports whether a notification for the same
+	// event, channel and target created before n is still pending or being
+	// sent, so transitions reach each target in order.
+	HasEarlierUndelivered(ctx context.Context, n *Notification) (bool, error)
+	// Reschedule returns a claimed notification to pending until at,
+	// without counting an attempt.
+	Reschedule(ctx context.Context, id string, at time.Time) error
+	MarkDelivered(ctx context.Context, id string, at time.Time) error
+	// MarkFailed records a failed attempt. The notification goes back to
+	// pending until nextAttemptAt, or to dead when dead is true.
+	MarkFailed(ctx context.Context, id string, attempts int, nextAttemptAt time.Time, lastErr string, dead bool) error
+	// Retry resets a notification that is not currently being sent to
+	// pending with a fresh attempt budget.
+	Retry(ctx context.Context, id string, now time.Time) (*Notification, error)
 }
 
 // ServicesRepository defines the contract for service registry persistence.
 type ServicesRepository interface {
 	FindAll(ctx context.Context, f ServicesFilter) ([]Service, int64, error)
+	// UpdateStatus sets a service's status unless a heartbeat arrived after
+	// lastHeartbeat, and reports whether it did.
+	UpdateStatus(ctx context.Context, name string, lastHeartbeat time.Time, status string) (bool, error)
+	SetHeartbeatGrace(ctx context.Context, name string, grace *HeartbeatGrace) (*Service, error)
 }
 
 // ── Filter Types ──
@@ -48,21 +132,24 @@ type LogsFilter struct {
 	Level   string
 	TraceID string
 	Query   string
-	From    string // RFC3339
-	To      string // RFC3339
+	Tags    map[string]string // exact tag values
+	From    string            // RFC3339
+	To      string            // RFC3339
 	Page    int
 	Limit   int
 }
 
 // MetricsFilter holds query parameters for filtering metrics.
 type MetricsFilter struct {
-	Service string
-	Name    string
-	TraceID string
-	From    string // RFC3339
-	To      string // RFC3339
-	Page    int
-	Limit   int
+	Service  string
+	Name     string
+	TraceID  string
+	Matchers []Matcher // label "service" matches the service, any other label a tag
+	From     string    // RFC3339
+	To       string    // RFC3339
+	Sort     string    // "asc" or "desc" by timestamp (default desc)
+	Page     int
+	Limit    int
 }
 
 // SecurityFilter holds query parameters for filtering security events.
@@ -78,9 +165,67 @@ type SecurityFilter struct {
 	Limit    int
 }
 
+// SilencesRepository defines the contract for silences and maintenance windows.
+type SilencesRepository interface {
+	FindAll(ctx context.Context) ([]Silence, error)
+	FindByID(ctx context.Context, id string) (*Silence, error)
+	// FindCurrent returns silences that have started and not ended at now.
+	// Recurring ones may still be between maintenance windows.
+	FindCurrent(ctx context.Context, now time.Time) ([]Silence, error)
+	Create(ctx context.Context, s *Silence) (string, error)
+	Update(ctx context.Context, s *Silence) error
+	Delete(ctx context.Context, id string) error
+}
+
+// SchedulesRepository defines the contract for on-call schedule persistence.
+type SchedulesRepository interface {
+	FindAll(ctx context.Context) ([]OnCallSchedule, error)
+	FindByID(ctx context.Context, id string) (*OnCallSchedule, error)
+	Create(ctx context.Context, s *OnCallSchedule) (string, error)
+	Update(ctx context.Context, s *OnCallSchedule) error
+	Delete(ctx context.Context, id string) error
+}
+
+// EscalationPoliciesRepository defines the contract for escalation policy persistence.
+type EscalationPoliciesRepository interface {
+	FindAll(ctx context.Context) ([]EscalationPolicy, error)
+	FindByID(ctx context.Context, id string) (*EscalationPolicy, error)
+	Create(ctx context.Context, p *EscalationPolicy) (string, error)
+	Update(ctx context.Context, p *EscalationPolicy) error
+	Delete(ctx context.Context, id string) error
+}
+
+// AlertEventsFilter holds query parameters for filtering alert events.
+type AlertEventsFilter struct {
+	AlertID string
+	Service string
+	Status  string
+	From    string // RFC3339, on triggered_at
+	To      string // RFC3339, on triggered_at
+	Page    int
+	Limit   int
+}
+
+// NotificationsFilter holds query parameters for filtering notifications.
+type NotificationsFilter struct {
+	Status  string
+	AlertID string
+	EventID string
+	Channel string
+	Page    int
+	Limit   int
+}
+
 // ServicesFilter holds query parameters for filtering services.
 type ServicesFilter struct {
+	Name   string
 	Status string
+	Sort   string // ServicesByName, or latest heartbeat first (default)
+	After  string // with ServicesByName, only services named after this
 	Page   int
 	Limit  int
 }
+
+// ServicesByName sorts services by their unique name, an order heartbeats
+// do not change, so scans can page with After instead of Page.
+const ServicesByName = "name"
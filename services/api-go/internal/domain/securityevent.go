@@ -17,3 +17,12 @@ type SecurityEvent struct {
 	Timestamp     time.Time              `json:"timestamp" bson:"timestamp"`
 	ReceivedAt    time.Time              `json:"received_at" bson:"received_at"`
 }
+
+// SecurityCount is the number of security events sharing a key, for
+// example one source IP, with the time range they span.
+type SecurityCount struct {
+	Key       map[string]string
+	Count     int64
+	FirstSeen time.Time
+	LastSeen  time.Time
+}
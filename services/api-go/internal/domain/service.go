@@ -3,13 +3,30 @@ package domain
 import "time"
 
 type Service struct {
-	ID            string                 `json:"id" bson:"_id,omitempty"`
-	Name          string                 `json:"name" bson:"name"`
-	Host          string                 `json:"host" bson:"host"`
-	Version       string                 `json:"version,omitempty" bson:"version,omitempty"`
-	LastHeartbeat time.Time              `json:"last_heartbeat" bson:"last_heartbeat"`
-	Status        string                 `json:"status" bson:"status"` // healthy, degraded, unhealthy
-	Meta          map[string]interface{} `json:"meta,omitempty" bson:"meta,omitempty"`
-	Tags          map[string]string      `json:"tags,omitempty" bson:"tags,omitempty"`
-	CreatedAt     time.Time              `json:"created_at" bson:"created_at"`
+	ID             string                 `json:"id" bson:"_id,omitempty"`
+	Name           string                 `json:"name" bson:"name"`
+	Host           string                 `json:"host" bson:"host"`
+	Version        string                 `json:"version,omitempty" bson:"version,omitempty"`
+	LastHeartbeat  time.Time              `json:"last_heartbeat" bson:"last_heartbeat"`
+	Status         string                 `json:"status" bson:"status"` // healthy, degraded, unhealthy
+	HeartbeatGrace *HeartbeatGrace        `json:"heartbeat_grace,omitempty" bson:"heartbeat_grace,omitempty"`
+	Meta           map[string]interface{} `json:"meta,omitempty" bson:"meta,omitempty"`
+	Tags           map[string]string      `json:"tags,omitempty" bson:"tags,omitempty"`
+	CreatedAt      time.Time              `json:"created_at" bson:"created_at"`
+}
+
+// Service statuses, reported by the service's heartbeats or set by the
+// heartbeat monitor once heartbeats stop.
+const (
+	ServiceHealthy   = "healthy"
+	ServiceDegraded  = "degraded"
+	ServiceUnhealthy = "unhealthy"
+)
+
+// HeartbeatGrace is how long a service may go without a heartbeat before
+// it is considered degraded, then unhealthy. Empty fields use the
+// server-wide defaults.
+type HeartbeatGrace struct {
+	DegradedAfter  string `json:"degraded_after,omitempty" bson:"degraded_after,omitempty"`   // e.g. "90s"
+	UnhealthyAfter string `json:"unhealthy_after,omitempty" bson:"unhealthy_after,omitempty"` // e.g. "5m"
 }
@@ -0,0 +1,52 @@
+package domain
+
+import "time"
+
+// Label matcher operators, as in Prometheus selectors.
+const (
+	MatchEqual     = "="
+	MatchNotEqual  = "!="
+	MatchRegexp    = "=~" // fully anchored RE2
+	MatchNotRegexp = "!~"
+)
+
+// Matcher selects alerts by one of their labels: "alertname" (the rule
+// name), "service", or a series tag such as "host".
+type Matcher struct {
+	Label string `json:"label" bson:"label"`
+	Op    string `json:"op" bson:"op"` // =, !=, =~, !~ (default =)
+	Value string `json:"value" bson:"value"`
+}
+
+// Silence suppresses notifications for alerts matching all of its
+// matchers. A one-off silence applies from StartsAt until EndsAt; with a
+// Schedule it is a recurring maintenance window, active for Schedule.Duration
+// after each cron activation between StartsAt and the optional EndsAt.
+// Silenced alerts are still recorded as AlertEvents.
+type Silence struct {
+	ID        string               `json:"id" bson:"_id,omitempty"`
+	Matchers  []Matcher            `json:"matchers" bson:"matchers"`
+	StartsAt  time.Time            `json:"starts_at" bson:"starts_at"`
+	EndsAt    *time.Time           `json:"ends_at,omitempty" bson:"ends_at,omitempty"`   // required unless Schedule is set
+	Schedule  *MaintenanceSchedule `json:"schedule,omitempty" bson:"schedule,omitempty"` // recurring maintenance window
+	CreatedBy string               `json:"created_by" bson:"created_by"`
+	Comment   string               `json:"comment,omitempty" bson:"comment,omitempty"`
+	Status    string               `json:"status" bson:"-"` // computed: active, scheduled, expired
+	CreatedAt time.Time            `json:"created_at" bson:"created_at"`
+	UpdatedAt time.Time            `json:"updated_at" bson:"updated_at"`
+}
+
+// MaintenanceSchedule makes a silence recur, e.g. every Saturday
+// 02:00–04:00: {Cron: "0 2 * * 6", Duration: "2h"}.
+type MaintenanceSchedule struct {
+	Cron     string `json:"cron" bson:"cron"`                             // minute hour day-of-month month day-of-week
+	Duration string `json:"duration" bson:"duration"`                     // how long each window lasts, e.g. "2h"
+	Timezone string `json:"timezone,omitempty" bson:"timezone,omitempty"` // IANA name, default UTC
+}
+
+// Silence statuses.
+const (
+	SilenceActive    = "active"
+	SilenceScheduled = "scheduled" // not yet started, or between maintenance windows
+	SilenceExpired   = "expired"
+)
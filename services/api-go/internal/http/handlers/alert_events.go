@@ -0,0 +1,138 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+
+	"github.com/lightwatch/monitoring-platform/services/api-go/internal/domain"
+	"github.com/lightwatch/monitoring-platform/services/api-go/internal/usecase"
+)
+
+// AlertEventsHandler handles HTTP requests for alert event history and
+// for responders acknowledging, assigning and commenting on events.
+type AlertEventsHandler struct {
+	uc     *usecase.QueryAlertEvents
+	manage *usecase.ManageAlertEvents
+}
+
+// NewAlertEventsHandler creates a new AlertEventsHandler.
+func NewAlertEventsHandler(uc *usecase.QueryAlertEvents, manage *usecase.ManageAlertEvents) *AlertEventsHandler {
+	return &AlertEventsHandler{uc: uc, manage: manage}
+}
+
+// List handles GET /api/alerts/events
+func (h *AlertEventsHandler) List(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	filter := alertEventsFilter(q)
+	filter.AlertID = q.Get("alert_id")
+
+	data, total, err := h.uc.Execute(r.Context(), filter)
+	if err != nil {
+		Error(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	JSON(w, http.StatusOK, PaginatedResponse{
+		Data:  data,
+		Total: total,
+		Page:  filter.Page,
+		Limit: filter.Limit,
+	})
+}
+
+// ListByAlert handles GET /api/alerts/{id}/events
+func (h *AlertEventsHandler) ListByAlert(w http.ResponseWriter, r *http.Request) {
+	filter := alertEventsFilter(r.URL.Query())
+
+	data, total, err := h.uc.ForAlert(r.Context(), r.PathValue("id"), filter)
+	if err != nil {
+		UsecaseError(w, err)
+		return
+	}
+
+	JSON(w, http.StatusOK, PaginatedResponse{
+		Data:  data,
+		Total: total,
+		Page:  filter.Page,
+		Limit: filter.Limit,
+	})
+}
+
+// Get handles GET /api/alerts/{id}/events/{event_id}
+func (h *AlertEventsHandler) Get(w http.ResponseWriter, r *http.Request) {
+	evt, err := h.uc.Get(r.Context(), r.PathValue("id"), r.PathValue("event_id"))
+	if err != nil {
+		UsecaseError(w, err)
+		return
+	}
+	JSON(w, http.StatusOK, map[string]interface{}{"data": evt})
+}
+
+// Acknowledge handles POST /api/alerts/events/{id}/ack
+func (h *AlertEventsHandler) Acknowledge(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		By string `json:"by"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		Error(w, http.StatusBadRequest, "invalid JSON body")
+		return
+	}
+
+	evt, err := h.manage.Acknowledge(r.Context(), r.PathValue("id"), body.By)
+	if err != nil {
+		UsecaseError(w, err)
+		return
+	}
+	JSON(w, http.StatusOK, map[string]interface{}{"data": evt})
+}
+
+// Assign handles POST /api/alerts/events/{id}/assign
+func (h *AlertEventsHandler) Assign(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		Assignee string `json:"assignee"`
+		By       string `json:"by"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		Error(w, http.StatusBadRequest, "invalid JSON body")
+		return
+	}
+
+	evt, err := h.manage.Assign(r.Context(), r.PathValue("id"), body.Assignee, body.By)
+	if err != nil {
+		UsecaseError(w, err)
+		return
+	}
+	JSON(w, http.StatusOK, map[string]interface{}{"data": evt})
+}
+
+// Comment handles POST /api/alerts/events/{id}/comments
+func (h *AlertEventsHandler) Comment(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		Author string `json:"author"`
+		Text   string `json:"text"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		Error(w, http.StatusBadRequest, "invalid JSON body")
+		return
+	}
+
+	evt, err := h.manage.Comment(r.Context(), r.PathValue("id"), body.Author, body.Text)
+	if err != nil {
+		UsecaseError(w, err)
+		return
+	}
+	JSON(w, http.StatusCreated, map[string]interface{}{"data": evt})
+}
+
+func alertEventsFilter(q url.Values) domain.AlertEventsFilter {
+	page, limit := parsePagination(q)
+	return domain.AlertEventsFilter{
+		Service: q.Get("service"),
+		Status:  q.Get("status"),
+		From:    q.Get("from"),
+		To:      q.Get("to"),
+		Page:    page,
+		Limit:   limit,
+	}
+}
@@ -2,7 +2,10 @@ package handlers
 
 import (
 	"encoding/json"
+	"io"
 	"net/http"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/lightwatch/monitoring-platform/services/api-go/internal/domain"
@@ -11,12 +14,13 @@ import (
 
 // AlertsHandler handles HTTP requests for alert rules.
 type AlertsHandler struct {
-	uc *usecase.ManageAlerts
+	uc       *usecase.ManageAlerts
+	backtest *usecase.BacktestAlert
 }
 
 // NewAlertsHandler creates a new AlertsHandler.
-func NewAlertsHandler(uc *usecase.ManageAlerts) *AlertsHandler {
-	return &AlertsHandler{uc: uc}
+func NewAlertsHandler(uc *usecase.ManageAlerts, backtest *usecase.BacktestAlert) *AlertsHandler {
+	return &AlertsHandler{uc: uc, backtest: backtest}
 }
 
 // List handles GET /api/alerts
@@ -29,6 +33,17 @@ func (h *AlertsHandler) List(w http.ResponseWriter, r *http.Request) {
 	JSON(w, http.StatusOK, map[string]interface{}{"data": alerts})
 }
 
+// Get handles GET /api/alerts/{id}
+func (h *AlertsHandler) Get(w http.ResponseWriter, r *http.Request) {
+	alert, err := h.uc.Get(r.Context(), r.PathValue("id"))
+	if err != nil {
+		UsecaseError(w, err)
+		return
+	}
+	w.Header().Set("ETag", strconv.Quote(strconv.FormatInt(alert.Version, 10)))
+	JSON(w, http.StatusOK, map[string]interface{}{"data": alert})
+}
+
 // Create handles POST /api/alerts
 func (h *AlertsHandler) Create(w http.ResponseWriter, r *http.Request) {
 	var alert domain.Alert
@@ -37,27 +52,128 @@ func (h *AlertsHandler) Create(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if alert.Name == "" || alert.Service == "" {
-		Error(w, http.StatusBadRequest, "name and service are required")
+	now := time.Now().UTC()
+	alert.CreatedAt = now
+	alert.UpdatedAt = now
+
+	id, err := h.uc.Create(r.Context(), &alert)
+	if err != nil {
+		UsecaseError(w, err)
 		return
 	}
-	if alert.Condition.Metric == "" || alert.Condition.Operator == "" {
-		Error(w, http.StatusBadRequest, "condition.metric and condition.operator are required")
+
+	JSON(w, http.StatusCreated, map[string]string{"id": id})
+}
+
+// Backtest handles POST /api/alerts/backtest
+// The body carries a rule definition and the time range to replay it over;
+// nothing is stored.
+func (h *AlertsHandler) Backtest(w http.ResponseWriter, r *http.Request) {
+	var req domain.BacktestRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		Error(w, http.StatusBadRequest, "invalid JSON body")
 		return
 	}
 
-	now := time.Now().UTC()
-	alert.CreatedAt = now
-	alert.UpdatedAt = now
-	if alert.Type == "" {
-		alert.Type = domain.AlertTypeThreshold
+	result, err := h.backtest.Run(r.Context(), req)
+	if err != nil {
+		UsecaseError(w, err)
+		return
 	}
+	JSON(w, http.StatusOK, map[string]interface{}{"data": result})
+}
 
-	id, err := h.uc.Create(r.Context(), &alert)
+// Update handles PUT /api/alerts/{id}
+// The body replaces the whole rule and must carry the current version.
+func (h *AlertsHandler) Update(w http.ResponseWriter, r *http.Request) {
+	var alert domain.Alert
+	if err := json.NewDecoder(r.Body).Decode(&alert); err != nil {
+		Error(w, http.StatusBadRequest, "invalid JSON body")
+		return
+	}
+
+	if err := h.uc.Update(r.Context(), r.PathValue("id"), &alert); err != nil {
+		UsecaseError(w, err)
+		return
+	}
+	JSON(w, http.StatusOK, map[string]interface{}{"data": alert})
+}
+
+// Patch handles PATCH /api/alerts/{id}
+// Fields present in the body are merged into the stored rule. The version
+// the client read must be sent, in the body or as an If-Match header with
+// the ETag of GET /api/alerts/{id}; without one the request is refused
+// with 428, and a stale one with 409.
+func (h *AlertsHandler) Patch(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(r.Body)
 	if err != nil {
-		Error(w, http.StatusInternalServerError, err.Error())
+		Error(w, http.StatusBadRequest, "invalid JSON body")
+		return
+	}
+	var precondition struct {
+		Version *int64 `json:"version"`
+	}
+	if err := json.Unmarshal(body, &precondition); err != nil {
+		Error(w, http.StatusBadRequest, "invalid JSON body")
+		return
+	}
+	version := precondition.Version
+	if version == nil && r.Header.Get("If-Match") != "" {
+		v, err := strconv.ParseInt(strings.Trim(r.Header.Get("If-Match"), `"`), 10, 64)
+		if err != nil {
+			Error(w, http.StatusBadRequest, "If-Match must be the rule's version")
+			return
+		}
+		version = &v
+	}
+	if version == nil {
+		Error(w, http.StatusPreconditionRequired, "send the rule's version in the body or an If-Match header")
 		return
 	}
 
-	JSON(w, http.StatusCreated, map[string]string{"id": id})
+	id := r.PathValue("id")
+	alert, err := h.uc.Get(r.Context(), id)
+	if err != nil {
+		UsecaseError(w, err)
+		return
+	}
+	if err := json.Unmarshal(body, alert); err != nil {
+		Error(w, http.StatusBadRequest, "invalid JSON body")
+		return
+	}
+	alert.Version = *version
+
+	if err := h.uc.Update(r.Context(), id, alert); err != nil {
+		UsecaseError(w, err)
+		return
+	}
+	JSON(w, http.StatusOK, map[string]interface{}{"data": alert})
+}
+
+// Delete handles DELETE /api/alerts/{id}
+func (h *AlertsHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	if err := h.uc.Delete(r.Context(), r.PathValue("id")); err != nil {
+		UsecaseError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Enable handles POST /api/alerts/{id}/enable
+func (h *AlertsHandler) Enable(w http.ResponseWriter, r *http.Request) {
+	h.setEnabled(w, r, true)
+}
+
+// Disable handles POST /api/alerts/{id}/disable
+func (h *AlertsHandler) Disable(w http.ResponseWriter, r *http.Request) {
+	h.setEnabled(w, r, false)
+}
+
+func (h *AlertsHandler) setEnabled(w http.ResponseWriter, r *http.Request, enabled bool) {
+	alert, err := h.uc.SetEnabled(r.Context(), r.PathValue("id"), enabled)
+	if err != nil {
+		UsecaseError(w, err)
+		return
+	}
+	JSON(w, http.StatusOK, map[string]interface{}{"data": alert})
 }
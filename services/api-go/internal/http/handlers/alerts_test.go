@@ -0,0 +1,316 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/lightwatch/monitoring-platform/services/api-go/internal/domain"
+	"github.com/lightwatch/monitoring-platform/services/api-go/internal/usecase"
+)
+
+// memAlerts is an in-memory domain.AlertsRepository that checks versions
+// like the Mongo one.
+type memAlerts struct {
+	mu    sync.Mutex
+	rules map[string]domain.Alert
+	next  int
+}
+
+func (m *memAlerts) FindAll(context.Context) ([]domain.Alert, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	out := make([]domain.Alert, 0, len(m.rules))
+	for _, a := range m.rules {
+		out = append(out, a)
+	}
+	return out, nil
+}
+
+func (m *memAlerts) FindByID(_ context.Context, id string) (*domain.Alert, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	a, ok := m.rules[id]
+	if !ok {
+		return nil, domain.ErrNotFound
+	}
+	return &a, nil
+}
+
+func (m *memAlerts) FindEnabled(ctx context.Context, _ string) ([]domain.Alert, error) {
+	return m.FindAll(ctx)
+}
+
+func (m *memAlerts) Create(_ context.Context, a *domain.Alert) (string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.rules == nil {
+		m.rules = make(map[string]domain.Alert)
+	}
+	m.next++
+	a.ID = fmt.Sprintf("rule-%d", m.next)
+	a.Version = 1
+	m.rules[a.ID] = *a
+	return a.ID, nil
+}
+
+func (m *memAlerts) Update(_ context.Context, a *domain.Alert) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	stored, ok := m.rules[a.ID]
+	if !ok {
+		return domain.ErrNotFound
+	}
+	if stored.Version != a.Version {
+		return domain.ErrConflict
+	}
+	a.Version++
+	a.UpdatedAt = time.Now()
+	m.rules[a.ID] = *a
+	return nil
+}
+
+func (m *memAlerts) SetEnabled(_ context.Context, id string, enabled bool) (*domain.Alert, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	a, ok := m.rules[id]
+	if !ok {
+		return nil, domain.ErrNotFound
+	}
+	a.Enabled = enabled
+	a.Version++
+	m.rules[id] = a
+	return &a, nil
+}
+
+func (m *memAlerts) Delete(_ context.Context, id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.rules, id)
+	return nil
+}
+
+const (
+	testSecret = "whsec_0123456789abcdef"
+	testKey    = "pd-routing-key-0123"
+)
+
+// newAlertsServer serves the alert routes over repo, which holds one rule
+// with a signed webhook and a PagerDuty target, with the returned ID.
+func newAlertsServer(t *testing.T) (*httptest.Server, *memAlerts, string) {
+	t.Helper()
+	repo := &memAlerts{}
+	id, err := repo.Create(context.Background(), &domain.Alert{
+		Name:           "High CPU",
+		Type:           domain.AlertTypeThreshold,
+		Service:        "api-gateway",
+		Enabled:        true,
+		Condition:      domain.AlertCondition{Metric: "cpu", Operator: "gt", Threshold: 90},
+		Webhook:        "https://hooks.example.com/lightwatch",
+		WebhookSecrets: []string{testSecret},
+		Targets:        []domain.NotificationTarget{{Type: domain.ChannelPagerDuty, Key: testKey}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	h := NewAlertsHandler(usecase.NewManageAlerts(repo, nil), nil)
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/alerts", h.List)
+	mux.HandleFunc("GET /api/alerts/{id}", h.Get)
+	mux.HandleFunc("PUT /api/alerts/{id}", h.Update)
+	mux.HandleFunc("PATCH /api/alerts/{id}", h.Patch)
+	mux.HandleFunc("POST /api/alerts/{id}/enable", h.Enable)
+	mux.HandleFunc("POST /api/alerts/{id}/disable", h.Disable)
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	return srv, repo, id
+}
+
+func do(t *testing.T, method, url, body string, header ...string) (int, string) {
+	t.Helper()
+	req, err := http.NewRequest(method, url, strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i+1 < len(header); i += 2 {
+		req.Header.Set(header[i], header[i+1])
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	b, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return resp.StatusCode, string(b)
+}
+
+func TestAlertResponsesOmitWebhookSecrets(t *testing.T) {
+	srv, _, id := newAlertsServer(t)
+	rule := srv.URL + "/api/alerts/" + id
+	put := `{"name":"High CPU","type":"threshold","service":"api-gateway","enabled":true,` +
+		`"condition":{"metric":"cpu","operator":"gt","threshold":95},` +
+		`"webhook":"https://hooks.example.com/lightwatch","version":%d}`
+
+	tests := []struct {
+		name, method, url, body string
+	}{
+		{name: "list", method: "GET", url: srv.URL + "/api/alerts"},
+		{name: "get", method: "GET", url: rule},
+		{name: "put", method: "PUT", url: rule, body: fmt.Sprintf(put, 1)},
+		{name: "patch", method: "PATCH", url: rule, body: `{"severity":"warning","version":2}`},
+		{name: "disable", method: "POST", url: rule + "/disable"},
+		{name: "enable", method: "POST", url: rule + "/enable"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status, body := do(t, tt.method, tt.url, tt.body)
+			if status != http.StatusOK {
+				t.Fatalf("status = %d: %s", status, body)
+			}
+			if strings.Contains(body, testSecret) || strings.Contains(body, `"webhook_secrets"`) {
+				t.Fatalf("response carries the webhook secret: %s", body)
+			}
+			if !strings.Contains(body, `"webhook_secret_count":1`) {
+				t.Fatalf("response lacks webhook_secret_count: %s", body)
+			}
+		})
+	}
+}
+
+func TestAlertUpdateKeepsWebhookSecrets(t *testing.T) {
+	srv, repo, id := newAlertsServer(t)
+	rule := srv.URL + "/api/alerts/" + id
+
+	// A rule read back and written unchanged keeps its secret
+	_, body := do(t, "GET", rule, "")
+	var got struct{ Data json.RawMessage }
+	if err := json.Unmarshal([]byte(body), &got); err != nil {
+		t.Fatal(err)
+	}
+	if status, body := do(t, "PUT", rule, string(got.Data)); status != http.StatusOK {
+		t.Fatalf("PUT status = %d: %s", status, body)
+	}
+	stored, _ := repo.FindByID(context.Background(), id)
+	if len(stored.WebhookSecrets) != 1 || stored.WebhookSecrets[0] != testSecret {
+		t.Fatalf("secrets after round trip = %v", stored.WebhookSecrets)
+	}
+
+	// An empty list removes it
+	if status, body := do(t, "PATCH", rule, `{"webhook_secrets":[],"version":2}`); status != http.StatusOK {
+		t.Fatalf("PATCH status = %d: %s", status, body)
+	}
+	stored, _ = repo.FindByID(context.Background(), id)
+	if len(stored.WebhookSecrets) != 0 {
+		t.Fatalf("secrets after clearing = %v", stored.WebhookSecrets)
+	}
+}
+
+func TestAlertResponsesOmitTargetKeys(t *testing.T) {
+	srv, repo, id := newAlertsServer(t)
+	rule := srv.URL + "/api/alerts/" + id
+
+	status, body := do(t, "GET", rule, "")
+	if status != http.StatusOK {
+		t.Fatalf("status = %d: %s", status, body)
+	}
+	if strings.Contains(body, testKey) || strings.Contains(body, `"key":`) {
+		t.Fatalf("response carries the target key: %s", body)
+	}
+	if !strings.Contains(body, `"key_set":true`) {
+		t.Fatalf("response lacks key_set: %s", body)
+	}
+
+	tests := []struct {
+		name    string
+		targets string
+		wantKey string
+		status  int
+	}{
+		{name: "key omitted", targets: `[{"type":"pagerduty"}]`, wantKey: testKey, status: http.StatusOK},
+		{name: "key replaced", targets: `[{"type":"pagerduty","key":"pd-routing-key-4567"}]`, wantKey: "pd-routing-key-4567", status: http.StatusOK},
+		{name: "key from another type", targets: `[{"type":"opsgenie"}]`, status: http.StatusBadRequest},
+		{name: "key from another position", targets: `[{"type":"slack","url":"https://hooks.slack.com/x"},{"type":"pagerduty"}]`, status: http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			before, _ := repo.FindByID(context.Background(), id)
+			put := fmt.Sprintf(`{"name":"High CPU","service":"api-gateway","condition":{"metric":"cpu","operator":"gt","threshold":90},`+
+				`"targets":%s,"version":%d}`, tt.targets, before.Version)
+			status, body := do(t, "PUT", rule, put)
+			if status != tt.status {
+				t.Fatalf("status = %d, want %d: %s", status, tt.status, body)
+			}
+			if strings.Contains(body, `"key":`) {
+				t.Fatalf("response carries the target key: %s", body)
+			}
+			if tt.status != http.StatusOK {
+				return
+			}
+			stored, _ := repo.FindByID(context.Background(), id)
+			if got := stored.Targets[0].Key; got != tt.wantKey {
+				t.Fatalf("stored key = %q, want %q", got, tt.wantKey)
+			}
+		})
+	}
+}
+
+func TestAlertPatchRequiresVersion(t *testing.T) {
+	srv, repo, id := newAlertsServer(t)
+	rule := srv.URL + "/api/alerts/" + id
+
+	tests := []struct {
+		name    string
+		body    string
+		ifMatch string
+		status  int
+	}{
+		{name: "no version", body: `{"severity":"warning"}`, status: http.StatusPreconditionRequired},
+		{name: "null version", body: `{"severity":"warning","version":null}`, status: http.StatusPreconditionRequired},
+		{name: "stale version", body: `{"severity":"warning","version":0}`, status: http.StatusConflict},
+		{name: "stale If-Match", body: `{"severity":"warning"}`, ifMatch: `"0"`, status: http.StatusConflict},
+		{name: "malformed If-Match", body: `{"severity":"warning"}`, ifMatch: "*", status: http.StatusBadRequest},
+		{name: "current version", body: `{"severity":"warning","version":%d}`, status: http.StatusOK},
+		{name: "current If-Match", body: `{"severity":"critical"}`, ifMatch: "etag", status: http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			before, _ := repo.FindByID(context.Background(), id)
+			body := tt.body
+			if strings.Contains(body, "%d") {
+				body = fmt.Sprintf(body, before.Version)
+			}
+			var header []string
+			if tt.ifMatch == "etag" {
+				req, _ := http.NewRequest("GET", rule, nil)
+				resp, err := http.DefaultClient.Do(req)
+				if err != nil {
+					t.Fatal(err)
+				}
+				resp.Body.Close()
+				header = []string{"If-Match", resp.Header.Get("ETag")}
+			} else if tt.ifMatch != "" {
+				header = []string{"If-Match", tt.ifMatch}
+			}
+
+			status, resp := do(t, "PATCH", rule, body, header...)
+			if status != tt.status {
+				t.Fatalf("status = %d, want %d: %s", status, tt.status, resp)
+			}
+			after, _ := repo.FindByID(context.Background(), id)
+			if changed := after.Version != before.Version; changed != (tt.status == http.StatusOK) {
+				t.Fatalf("version %d -> %d with status %d", before.Version, after.Version, status)
+			}
+		})
+	}
+}
@@ -0,0 +1,22 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/lightwatch/monitoring-platform/services/api-go/internal/usecase"
+)
+
+// EngineHandler handles the alert engine metrics endpoint.
+type EngineHandler struct {
+	engine *usecase.DetectAnomaly
+}
+
+// NewEngineHandler creates a new EngineHandler.
+func NewEngineHandler(engine *usecase.DetectAnomaly) *EngineHandler {
+	return &EngineHandler{engine: engine}
+}
+
+// Stats handles GET /api/engine/stats
+func (h *EngineHandler) Stats(w http.ResponseWriter, r *http.Request) {
+	JSON(w, http.StatusOK, map[string]interface{}{"data": h.engine.Stats()})
+}
@@ -0,0 +1,79 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/lightwatch/monitoring-platform/services/api-go/internal/domain"
+	"github.com/lightwatch/monitoring-platform/services/api-go/internal/usecase"
+)
+
+// EscalationPoliciesHandler handles HTTP requests for escalation policies.
+type EscalationPoliciesHandler struct {
+	uc *usecase.ManageEscalationPolicies
+}
+
+// NewEscalationPoliciesHandler creates a new EscalationPoliciesHandler.
+func NewEscalationPoliciesHandler(uc *usecase.ManageEscalationPolicies) *EscalationPoliciesHandler {
+	return &EscalationPoliciesHandler{uc: uc}
+}
+
+// List handles GET /api/escalation-policies
+func (h *EscalationPoliciesHandler) List(w http.ResponseWriter, r *http.Request) {
+	policies, err := h.uc.List(r.Context())
+	if err != nil {
+		Error(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	JSON(w, http.StatusOK, map[string]interface{}{"data": policies})
+}
+
+// Get handles GET /api/escalation-policies/{id}
+func (h *EscalationPoliciesHandler) Get(w http.ResponseWriter, r *http.Request) {
+	policy, err := h.uc.Get(r.Context(), r.PathValue("id"))
+	if err != nil {
+		UsecaseError(w, err)
+		return
+	}
+	JSON(w, http.StatusOK, map[string]interface{}{"data": policy})
+}
+
+// Create handles POST /api/escalation-policies
+func (h *EscalationPoliciesHandler) Create(w http.ResponseWriter, r *http.Request) {
+	var policy domain.EscalationPolicy
+	if err := json.NewDecoder(r.Body).Decode(&policy); err != nil {
+		Error(w, http.StatusBadRequest, "invalid JSON body")
+		return
+	}
+
+	id, err := h.uc.Create(r.Context(), &policy)
+	if err != nil {
+		UsecaseError(w, err)
+		return
+	}
+	JSON(w, http.StatusCreated, map[string]string{"id": id})
+}
+
+// Update handles PUT /api/escalation-policies/{id}
+func (h *EscalationPoliciesHandler) Update(w http.ResponseWriter, r *http.Request) {
+	var policy domain.EscalationPolicy
+	if err := json.NewDecoder(r.Body).Decode(&policy); err != nil {
+		Error(w, http.StatusBadRequest, "invalid JSON body")
+		return
+	}
+
+	if err := h.uc.Update(r.Context(), r.PathValue("id"), &policy); err != nil {
+		UsecaseError(w, err)
+		return
+	}
+	JSON(w, http.StatusOK, map[string]interface{}{"data": policy})
+}
+
+// Delete handles DELETE /api/escalation-policies/{id}
+func (h *EscalationPoliciesHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	if err := h.uc.Delete(r.Context(), r.PathValue("id")); err != nil {
+		UsecaseError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,27 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/lightwatch/monitoring-platform/services/api-go/internal/usecase"
+)
+
+// LeaderHandler handles the alert engine leader status endpoint.
+type LeaderHandler struct {
+	uc *usecase.ElectLeader
+}
+
+// NewLeaderHandler creates a new LeaderHandler.
+func NewLeaderHandler(uc *usecase.ElectLeader) *LeaderHandler {
+	return &LeaderHandler{uc: uc}
+}
+
+// Status handles GET /api/leader
+func (h *LeaderHandler) Status(w http.ResponseWriter, r *http.Request) {
+	status, err := h.uc.Status(r.Context())
+	if err != nil {
+		Error(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	JSON(w, http.StatusOK, map[string]interface{}{"data": status})
+}
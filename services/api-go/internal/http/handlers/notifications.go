@@ -0,0 +1,56 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/lightwatch/monitoring-platform/services/api-go/internal/domain"
+	"github.com/lightwatch/monitoring-platform/services/api-go/internal/usecase"
+)
+
+// NotificationsHandler handles HTTP requests for the notification outbox.
+type NotificationsHandler struct {
+	uc *usecase.ManageNotifications
+}
+
+// NewNotificationsHandler creates a new NotificationsHandler.
+func NewNotificationsHandler(uc *usecase.ManageNotifications) *NotificationsHandler {
+	return &NotificationsHandler{uc: uc}
+}
+
+// List handles GET /api/notifications
+func (h *NotificationsHandler) List(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	page, limit := parsePagination(q)
+
+	filter := domain.NotificationsFilter{
+		Status:  q.Get("status"),
+		AlertID: q.Get("alert_id"),
+		EventID: q.Get("event_id"),
+		Channel: q.Get("channel"),
+		Page:    page,
+		Limit:   limit,
+	}
+
+	data, total, err := h.uc.List(r.Context(), filter)
+	if err != nil {
+		Error(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	JSON(w, http.StatusOK, PaginatedResponse{
+		Data:  data,
+		Total: total,
+		Page:  page,
+		Limit: limit,
+	})
+}
+
+// Retry handles POST /api/notifications/{id}/retry
+func (h *NotificationsHandler) Retry(w http.ResponseWriter, r *http.Request) {
+	n, err := h.uc.Retry(r.Context(), r.PathValue("id"))
+	if err != nil {
+		UsecaseError(w, err)
+		return
+	}
+	JSON(w, http.StatusAccepted, map[string]interface{}{"data": n})
+}
@@ -2,9 +2,12 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
 	"net/url"
 	"strconv"
+
+	"github.com/lightwatch/monitoring-platform/services/api-go/internal/domain"
 )
 
 // PaginatedResponse is the standard envelope for paginated list endpoints.
@@ -30,6 +33,21 @@ func Error(w http.ResponseWriter, status int, message string) {
 	})
 }
 
+// UsecaseError maps use case and repository errors to an HTTP error response.
+func UsecaseError(w http.ResponseWriter, err error) {
+	var ve *domain.ValidationError
+	switch {
+	case errors.As(err, &ve):
+		Error(w, http.StatusBadRequest, ve.Error())
+	case errors.Is(err, domain.ErrNotFound):
+		Error(w, http.StatusNotFound, err.Error())
+	case errors.Is(err, domain.ErrConflict):
+		Error(w, http.StatusConflict, err.Error())
+	default:
+		Error(w, http.StatusInternalServerError, err.Error())
+	}
+}
+
 // parsePagination extracts page and limit from query string with defaults.
 func parsePagination(q url.Values) (int, int) {
 	page, _ := strconv.Atoi(q.Get("page"))
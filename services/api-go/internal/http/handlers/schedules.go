@@ -0,0 +1,124 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/lightwatch/monitoring-platform/services/api-go/internal/domain"
+	"github.com/lightwatch/monitoring-platform/services/api-go/internal/usecase"
+)
+
+// SchedulesHandler handles HTTP requests for on-call schedules.
+type SchedulesHandler struct {
+	uc *usecase.ManageSchedules
+}
+
+// NewSchedulesHandler creates a new SchedulesHandler.
+func NewSchedulesHandler(uc *usecase.ManageSchedules) *SchedulesHandler {
+	return &SchedulesHandler{uc: uc}
+}
+
+// List handles GET /api/schedules
+func (h *SchedulesHandler) List(w http.ResponseWriter, r *http.Request) {
+	schedules, err := h.uc.List(r.Context())
+	if err != nil {
+		Error(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	JSON(w, http.StatusOK, map[string]interface{}{"data": schedules})
+}
+
+// Get handles GET /api/schedules/{id}
+func (h *SchedulesHandler) Get(w http.ResponseWriter, r *http.Request) {
+	schedule, err := h.uc.Get(r.Context(), r.PathValue("id"))
+	if err != nil {
+		UsecaseError(w, err)
+		return
+	}
+	JSON(w, http.StatusOK, map[string]interface{}{"data": schedule})
+}
+
+// Create handles POST /api/schedules
+func (h *SchedulesHandler) Create(w http.ResponseWriter, r *http.Request) {
+	var schedule domain.OnCallSchedule
+	if err := json.NewDecoder(r.Body).Decode(&schedule); err != nil {
+		Error(w, http.StatusBadRequest, "invalid JSON body")
+		return
+	}
+
+	id, err := h.uc.Create(r.Context(), &schedule)
+	if err != nil {
+		UsecaseError(w, err)
+		return
+	}
+	JSON(w, http.StatusCreated, map[string]string{"id": id})
+}
+
+// Update handles PUT /api/schedules/{id}
+func (h *SchedulesHandler) Update(w http.ResponseWriter, r *http.Request) {
+	var schedule domain.OnCallSchedule
+	if err := json.NewDecoder(r.Body).Decode(&schedule); err != nil {
+		Error(w, http.StatusBadRequest, "invalid JSON body")
+		return
+	}
+
+	if err := h.uc.Update(r.Context(), r.PathValue("id"), &schedule); err != nil {
+		UsecaseError(w, err)
+		return
+	}
+	JSON(w, http.StatusOK, map[string]interface{}{"data": schedule})
+}
+
+// Delete handles DELETE /api/schedules/{id}
+func (h *SchedulesHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	if err := h.uc.Delete(r.Context(), r.PathValue("id")); err != nil {
+		UsecaseError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// OnCall handles GET /api/oncall: who is on call now, or at ?at= (RFC3339),
+// for every schedule.
+func (h *SchedulesHandler) OnCall(w http.ResponseWriter, r *http.Request) {
+	at, ok := parseAt(w, r)
+	if !ok {
+		return
+	}
+	shifts, err := h.uc.OnCall(r.Context(), at)
+	if err != nil {
+		Error(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	JSON(w, http.StatusOK, map[string]interface{}{"data": shifts})
+}
+
+// ScheduleOnCall handles GET /api/schedules/{id}/oncall
+func (h *SchedulesHandler) ScheduleOnCall(w http.ResponseWriter, r *http.Request) {
+	at, ok := parseAt(w, r)
+	if !ok {
+		return
+	}
+	shift, err := h.uc.OnCallFor(r.Context(), r.PathValue("id"), at)
+	if err != nil {
+		UsecaseError(w, err)
+		return
+	}
+	JSON(w, http.StatusOK, map[string]interface{}{"data": shift})
+}
+
+// parseAt reads the optional ?at= timestamp, defaulting to now. It writes
+// a 400 response and returns false if the value is malformed.
+func parseAt(w http.ResponseWriter, r *http.Request) (time.Time, bool) {
+	v := r.URL.Query().Get("at")
+	if v == "" {
+		return time.Now(), true
+	}
+	at, err := time.Parse(time.RFC3339, v)
+	if err != nil {
+		Error(w, http.StatusBadRequest, "at must be an RFC3339 timestamp")
+		return time.Time{}, false
+	}
+	return at, true
+}
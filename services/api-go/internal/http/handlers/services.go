@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"encoding/json"
 	"net/http"
 
 	"github.com/lightwatch/monitoring-platform/services/api-go/internal/domain"
@@ -9,12 +10,13 @@ import (
 
 // ServicesHandler handles HTTP requests for service registry.
 type ServicesHandler struct {
-	uc *usecase.QueryServices
+	uc         *usecase.QueryServices
+	heartbeats *usecase.MonitorHeartbeats
 }
 
 // NewServicesHandler creates a new ServicesHandler.
-func NewServicesHandler(uc *usecase.QueryServices) *ServicesHandler {
-	return &ServicesHandler{uc: uc}
+func NewServicesHandler(uc *usecase.QueryServices, heartbeats *usecase.MonitorHeartbeats) *ServicesHandler {
+	return &ServicesHandler{uc: uc, heartbeats: heartbeats}
 }
 
 // List handles GET /api/services
@@ -41,3 +43,20 @@ func (h *ServicesHandler) List(w http.ResponseWriter, r *http.Request) {
 		Limit: limit,
 	})
 }
+
+// SetHeartbeatGrace handles PUT /api/services/{name}/heartbeat-grace
+// An empty body object restores the default grace periods.
+func (h *ServicesHandler) SetHeartbeatGrace(w http.ResponseWriter, r *http.Request) {
+	var grace domain.HeartbeatGrace
+	if err := json.NewDecoder(r.Body).Decode(&grace); err != nil {
+		Error(w, http.StatusBadRequest, "invalid JSON body")
+		return
+	}
+
+	svc, err := h.heartbeats.SetGrace(r.Context(), r.PathValue("name"), &grace)
+	if err != nil {
+		UsecaseError(w, err)
+		return
+	}
+	JSON(w, http.StatusOK, map[string]interface{}{"data": svc})
+}
@@ -0,0 +1,79 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/lightwatch/monitoring-platform/services/api-go/internal/domain"
+	"github.com/lightwatch/monitoring-platform/services/api-go/internal/usecase"
+)
+
+// SilencesHandler handles HTTP requests for silences and maintenance windows.
+type SilencesHandler struct {
+	uc *usecase.ManageSilences
+}
+
+// NewSilencesHandler creates a new SilencesHandler.
+func NewSilencesHandler(uc *usecase.ManageSilences) *SilencesHandler {
+	return &SilencesHandler{uc: uc}
+}
+
+// List handles GET /api/silences
+func (h *SilencesHandler) List(w http.ResponseWriter, r *http.Request) {
+	silences, err := h.uc.List(r.Context(), r.URL.Query().Get("status"))
+	if err != nil {
+		Error(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	JSON(w, http.StatusOK, map[string]interface{}{"data": silences})
+}
+
+// Get handles GET /api/silences/{id}
+func (h *SilencesHandler) Get(w http.ResponseWriter, r *http.Request) {
+	silence, err := h.uc.Get(r.Context(), r.PathValue("id"))
+	if err != nil {
+		UsecaseError(w, err)
+		return
+	}
+	JSON(w, http.StatusOK, map[string]interface{}{"data": silence})
+}
+
+// Create handles POST /api/silences
+func (h *SilencesHandler) Create(w http.ResponseWriter, r *http.Request) {
+	var silence domain.Silence
+	if err := json.NewDecoder(r.Body).Decode(&silence); err != nil {
+		Error(w, http.StatusBadRequest, "invalid JSON body")
+		return
+	}
+
+	id, err := h.uc.Create(r.Context(), &silence)
+	if err != nil {
+		UsecaseError(w, err)
+		return
+	}
+	JSON(w, http.StatusCreated, map[string]string{"id": id})
+}
+
+// Update handles PUT /api/silences/{id}
+func (h *SilencesHandler) Update(w http.ResponseWriter, r *http.Request) {
+	var silence domain.Silence
+	if err := json.NewDecoder(r.Body).Decode(&silence); err != nil {
+		Error(w, http.StatusBadRequest, "invalid JSON body")
+		return
+	}
+
+	if err := h.uc.Update(r.Context(), r.PathValue("id"), &silence); err != nil {
+		UsecaseError(w, err)
+		return
+	}
+	JSON(w, http.StatusOK, map[string]interface{}{"data": silence})
+}
+
+// Delete handles DELETE /api/silences/{id}
+func (h *SilencesHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	if err := h.uc.Delete(r.Context(), r.PathValue("id")); err != nil {
+		UsecaseError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
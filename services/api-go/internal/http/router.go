@@ -13,7 +13,14 @@ func NewRouter(
 	metrics *handlers.MetricsHandler,
 	security *handlers.SecurityHandler,
 	alerts *handlers.AlertsHandler,
+	alertEvents *handlers.AlertEventsHandler,
+	notifications *handlers.NotificationsHandler,
+	silences *handlers.SilencesHandler,
+	schedules *handlers.SchedulesHandler,
+	policies *handlers.EscalationPoliciesHandler,
 	services *handlers.ServicesHandler,
+	leader *handlers.LeaderHandler,
+	engine *handlers.EngineHandler,
 	health *handlers.HealthHandler,
 ) *http.ServeMux {
 	mux := http.NewServeMux()
@@ -21,8 +28,13 @@ func NewRouter(
 	// Health
 	mux.HandleFunc("GET /api/health", health.Check)
 
+	// Alert engine
+	mux.HandleFunc("GET /api/leader", leader.Status)
+	mux.HandleFunc("GET /api/engine/stats", engine.Stats)
+
 	// Services
 	mux.HandleFunc("GET /api/services", services.List)
+	mux.HandleFunc("PUT /api/services/{name}/heartbeat-grace", services.SetHeartbeatGrace)
 
 	// Logs
 	mux.HandleFunc("GET /api/logs", logs.List)
@@ -36,6 +48,48 @@ func NewRouter(
 	// Alerts
 	mux.HandleFunc("GET /api/alerts", alerts.List)
 	mux.HandleFunc("POST /api/alerts", alerts.Create)
+	mux.HandleFunc("POST /api/alerts/backtest", alerts.Backtest)
+	mux.HandleFunc("GET /api/alerts/{id}", alerts.Get)
+	mux.HandleFunc("PUT /api/alerts/{id}", alerts.Update)
+	mux.HandleFunc("PATCH /api/alerts/{id}", alerts.Patch)
+	mux.HandleFunc("DELETE /api/alerts/{id}", alerts.Delete)
+	mux.HandleFunc("POST /api/alerts/{id}/enable", alerts.Enable)
+	mux.HandleFunc("POST /api/alerts/{id}/disable", alerts.Disable)
+
+	// Alert events
+	mux.HandleFunc("GET /api/alerts/events", alertEvents.List)
+	mux.HandleFunc("GET /api/alerts/{id}/events", alertEvents.ListByAlert)
+	mux.HandleFunc("GET /api/alerts/{id}/events/{event_id}", alertEvents.Get)
+	mux.HandleFunc("POST /api/alerts/events/{id}/ack", alertEvents.Acknowledge)
+	mux.HandleFunc("POST /api/alerts/events/{id}/assign", alertEvents.Assign)
+	mux.HandleFunc("POST /api/alerts/events/{id}/comments", alertEvents.Comment)
+
+	// Notification outbox
+	mux.HandleFunc("GET /api/notifications", notifications.List)
+	mux.HandleFunc("POST /api/notifications/{id}/retry", notifications.Retry)
+
+	// Silences and maintenance windows
+	mux.HandleFunc("GET /api/silences", silences.List)
+	mux.HandleFunc("POST /api/silences", silences.Create)
+	mux.HandleFunc("GET /api/silences/{id}", silences.Get)
+	mux.HandleFunc("PUT /api/silences/{id}", silences.Update)
+	mux.HandleFunc("DELETE /api/silences/{id}", silences.Delete)
+
+	// On-call schedules
+	mux.HandleFunc("GET /api/oncall", schedules.OnCall)
+	mux.HandleFunc("GET /api/schedules", schedules.List)
+	mux.HandleFunc("POST /api/schedules", schedules.Create)
+	mux.HandleFunc("GET /api/schedules/{id}", schedules.Get)
+	mux.HandleFunc("PUT /api/schedules/{id}", schedules.Update)
+	mux.HandleFunc("DELETE /api/schedules/{id}", schedules.Delete)
+	mux.HandleFunc("GET /api/schedules/{id}/oncall", schedules.ScheduleOnCall)
+
+	// Escalation policies
+	mux.HandleFunc("GET /api/escalation-policies", policies.List)
+	mux.HandleFunc("POST /api/escalation-policies", policies.Create)
+	mux.HandleFunc("GET /api/escalation-policies/{id}", policies.Get)
+	mux.HandleFunc("PUT /api/escalation-policies/{id}", policies.Update)
+	mux.HandleFunc("DELETE /api/escalation-policies/{id}", policies.Delete)
 
 	return mux
 }
@@ -3,6 +3,7 @@ package observability
 import (
 	"encoding/json"
 	"fmt"
+	"io"
 	"os"
 	"time"
 )
@@ -10,11 +11,18 @@ import (
 // Logger provides structured JSON logging.
 type Logger struct {
 	service string
+	out     io.Writer
 }
 
 // NewLogger creates a logger tagged with a service name.
 func NewLogger(service string) *Logger {
-	return &Logger{service: service}
+	return &Logger{service: service, out: os.Stdout}
+}
+
+// Discard returns a logger that drops every entry, for dry runs of code
+// that logs what it does.
+func Discard() *Logger {
+	return &Logger{out: io.Discard}
 }
 
 func (l *Logger) log(level, msg string, fields map[string]interface{}) {
@@ -28,7 +36,7 @@ func (l *Logger) log(level, msg string, fields map[string]interface{}) {
 		entry[k] = v
 	}
 	data, _ := json.Marshal(entry)
-	fmt.Fprintln(os.Stdout, string(data))
+	fmt.Fprintln(l.out, string(data))
 }
 
 // Info logs at INFO level.
@@ -0,0 +1,94 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/lightwatch/monitoring-platform/services/api-go/internal/domain"
+)
+
+// MongoAlertStatesRepository implements domain.AlertStatesRepository using MongoDB.
+type MongoAlertStatesRepository struct {
+	col *mongo.Collection
+}
+
+func NewAlertStatesRepository(db *mongo.Database) *MongoAlertStatesRepository {
+	return &MongoAlertStatesRepository{col: db.Collection("alert_states")}
+}
+
+func (r *MongoAlertStatesRepository) Find(ctx context.Context, alertID string) (*domain.AlertState, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	var state domain.AlertState
+	err := r.col.FindOne(ctx, bson.M{"_id": alertID}).Decode(&state)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &state, nil
+}
+
+func (r *MongoAlertStatesRepository) FindByAlert(ctx context.Context, alertID string) ([]domain.AlertState, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	// The rule-level state has no alert_id; it predates per-key states
+	filter := bson.M{"$or": bson.A{
+		bson.M{"_id": alertID},
+		bson.M{"alert_id": alertID},
+	}}
+	cursor, err := r.col.Find(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var states []domain.AlertState
+	if err := cursor.All(ctx, &states); err != nil {
+		return nil, err
+	}
+	return states, nil
+}
+
+func (r *MongoAlertStatesRepository) Save(ctx context.Context, state *domain.AlertState) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	opts := options.Replace().SetUpsert(true)
+	_, err := r.col.ReplaceOne(ctx, fenced(state.ID, state.Fence), state, opts)
+	if mongo.IsDuplicateKeyError(err) {
+		// The state exists but was written under a newer leadership
+		return fmt.Errorf("%w: alert state %s has a newer fencing token", domain.ErrNotLeader, state.ID)
+	}
+	return err
+}
+
+func (r *MongoAlertStatesRepository) Delete(ctx context.Context, id string, fence int64) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	_, err := r.col.DeleteOne(ctx, fenced(id, fence))
+	return err
+}
+
+// fenced matches the state with the given ID unless it was written under a
+// higher fencing token. States written without leader election carry none.
+func fenced(id string, fence int64) bson.M {
+	filter := bson.M{"_id": id}
+	if fence > 0 {
+		filter["$or"] = bson.A{
+			bson.M{"fence": bson.M{"$exists": false}},
+			bson.M{"fence": bson.M{"$lte": fence}},
+		}
+	}
+	return filter
+}
@@ -2,6 +2,8 @@ package repository
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"time"
 
 	"go.mongodb.org/mongo-driver/bson"
@@ -69,6 +71,7 @@ func (r *MongoAlertsRepository) Create(ctx context.Context, alert *domain.Alert)
 	alert.CreatedAt = now
 	alert.UpdatedAt = now
 	alert.ID = primitive.NewObjectID().Hex()
+	alert.Version = 1
 	if alert.Type == "" {
 		alert.Type = domain.AlertTypeThreshold
 	}
@@ -80,6 +83,95 @@ func (r *MongoAlertsRepository) Create(ctx context.Context, alert *domain.Alert)
 	return alert.ID, nil
 }
 
+func (r *MongoAlertsRepository) FindByID(ctx context.Context, id string) (*domain.Alert, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	var alert domain.Alert
+	err := r.col.FindOne(ctx, bson.M{"_id": id}).Decode(&alert)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, domain.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &alert, nil
+}
+
+func (r *MongoAlertsRepository) Update(ctx context.Context, alert *domain.Alert) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	expected := alert.Version
+	alert.Version = expected + 1
+	alert.UpdatedAt = time.Now()
+
+	filter := bson.M{"_id": alert.ID, "version": expected}
+	if expected == 0 {
+		// Rules created before versioning have no version field
+		filter = bson.M{"_id": alert.ID, "version": bson.M{"$in": bson.A{0, nil}}}
+	}
+
+	res, err := r.col.ReplaceOne(ctx, filter, alert)
+	if err != nil {
+		alert.Version = expected
+		return err
+	}
+	if res.MatchedCount == 0 {
+		alert.Version = expected
+		return r.missingOrConflict(ctx, alert.ID)
+	}
+	return nil
+}
+
+func (r *MongoAlertsRepository) SetEnabled(ctx context.Context, id string, enabled bool) (*domain.Alert, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	update := bson.M{
+		"$set": bson.M{"enabled": enabled, "updated_at": time.Now()},
+		"$inc": bson.M{"version": 1},
+	}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	var alert domain.Alert
+	err := r.col.FindOneAndUpdate(ctx, bson.M{"_id": id}, update, opts).Decode(&alert)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, domain.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &alert, nil
+}
+
+func (r *MongoAlertsRepository) Delete(ctx context.Context, id string) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	res, err := r.col.DeleteOne(ctx, bson.M{"_id": id})
+	if err != nil {
+		return err
+	}
+	if res.DeletedCount == 0 {
+		return domain.ErrNotFound
+	}
+	return nil
+}
+
+// missingOrConflict tells apart a failed versioned write on a deleted rule
+// from one that lost a race against another writer.
+func (r *MongoAlertsRepository) missingOrConflict(ctx context.Context, id string) error {
+	n, err := r.col.CountDocuments(ctx, bson.M{"_id": id})
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return domain.ErrNotFound
+	}
+	return fmt.Errorf("%w: alert was modified concurrently, reload it and retry with the current version", domain.ErrConflict)
+}
+
 // MongoAlertEventsRepository implements domain.AlertEventsRepository.
 type MongoAlertEventsRepository struct {
 	col *mongo.Collection
@@ -101,6 +193,49 @@ func (r *MongoAlertEventsRepository) Create(ctx context.Context, event *domain.A
 	return event.ID, nil
 }
 
+func (r *MongoAlertEventsRepository) Find(ctx context.Context, f domain.AlertEventsFilter) ([]domain.AlertEvent, int64, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	filter := bson.M{}
+	if f.AlertID != "" {
+		filter["alert_id"] = f.AlertID
+	}
+	if f.Service != "" {
+		filter["service"] = f.Service
+	}
+	if f.Status != "" {
+		filter["status"] = f.Status
+	}
+	applyTimeRangeOn(filter, "triggered_at", f.From, f.To)
+
+	limit := clampLimit(f.Limit, 50)
+	page := clampPage(f.Page)
+	skip := int64((page - 1) * limit)
+
+	total, err := r.col.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	opts := options.Find().
+		SetSort(bson.D{{Key: "triggered_at", Value: -1}}).
+		SetSkip(skip).
+		SetLimit(int64(limit))
+
+	cursor, err := r.col.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer cursor.Close(ctx)
+
+	var results []domain.AlertEvent
+	if err := cursor.All(ctx, &results); err != nil {
+		return nil, 0, err
+	}
+	return results, total, nil
+}
+
 func (r *MongoAlertEventsRepository) FindByAlert(ctx context.Context, alertID string, limit int) ([]domain.AlertEvent, error) {
 	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
 	defer cancel()
@@ -148,3 +283,97 @@ func (r *MongoAlertEventsRepository) FindRecent(ctx context.Context, limit int)
 	}
 	return results, nil
 }
+
+func (r *MongoAlertEventsRepository) FindByID(ctx context.Context, id string) (*domain.AlertEvent, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	var event domain.AlertEvent
+	err := r.col.FindOne(ctx, bson.M{"_id": id}).Decode(&event)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, domain.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &event, nil
+}
+
+func (r *MongoAlertEventsRepository) Resolve(ctx context.Context, id string, resolvedAt time.Time) (*domain.AlertEvent, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	update := bson.M{"$set": bson.M{
+		"status":      domain.AlertStateResolved,
+		"resolved_at": resolvedAt,
+	}}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	var event domain.AlertEvent
+	if err := r.col.FindOneAndUpdate(ctx, bson.M{"_id": id}, update, opts).Decode(&event); err != nil {
+		return nil, err
+	}
+	return &event, nil
+}
+
+func (r *MongoAlertEventsRepository) Acknowledge(ctx context.Context, id string, entry domain.AlertEventEntry) (*domain.AlertEvent, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	// Only a firing event can be acknowledged; the status filter makes
+	// concurrent acks and a racing resolve safe.
+	filter := bson.M{"_id": id, "status": domain.AlertStateFiring}
+	update := bson.M{
+		"$set": bson.M{
+			"status":   domain.AlertEventAcknowledged,
+			"acked_by": entry.Author,
+			"acked_at": entry.CreatedAt,
+		},
+		"$push": bson.M{"timeline": entry},
+	}
+	event, err := r.findOneAndUpdate(ctx, filter, update)
+	if !errors.Is(err, domain.ErrNotFound) {
+		return event, err
+	}
+
+	current, err := r.FindByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return nil, fmt.Errorf("%w: alert event is %s", domain.ErrConflict, current.Status)
+}
+
+func (r *MongoAlertEventsRepository) Assign(ctx context.Context, id string, entry domain.AlertEventEntry) (*domain.AlertEvent, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	update := bson.M{
+		"$set":  bson.M{"assignee": entry.Text},
+		"$push": bson.M{"timeline": entry},
+	}
+	return r.findOneAndUpdate(ctx, bson.M{"_id": id}, update)
+}
+
+func (r *MongoAlertEventsRepository) AddComment(ctx context.Context, id string, entry domain.AlertEventEntry) (*domain.AlertEvent, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	update := bson.M{"$push": bson.M{"timeline": entry}}
+	return r.findOneAndUpdate(ctx, bson.M{"_id": id}, update)
+}
+
+// findOneAndUpdate applies update to the event matching filter and returns
+// the updated event, or domain.ErrNotFound if none matches.
+func (r *MongoAlertEventsRepository) findOneAndUpdate(ctx context.Context, filter, update bson.M) (*domain.AlertEvent, error) {
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	var event domain.AlertEvent
+	err := r.col.FindOneAndUpdate(ctx, filter, update, opts).Decode(&event)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, domain.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &event, nil
+}
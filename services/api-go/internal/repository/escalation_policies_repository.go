@@ -0,0 +1,100 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/lightwatch/monitoring-platform/services/api-go/internal/domain"
+)
+
+// MongoEscalationPoliciesRepository implements domain.EscalationPoliciesRepository using MongoDB.
+type MongoEscalationPoliciesRepository struct {
+	col *mongo.Collection
+}
+
+func NewEscalationPoliciesRepository(db *mongo.Database) *MongoEscalationPoliciesRepository {
+	return &MongoEscalationPoliciesRepository{col: db.Collection("escalation_policies")}
+}
+
+func (r *MongoEscalationPoliciesRepository) FindAll(ctx context.Context) ([]domain.EscalationPolicy, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	opts := options.Find().SetSort(bson.D{{Key: "name", Value: 1}})
+	cursor, err := r.col.Find(ctx, bson.M{}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var results []domain.EscalationPolicy
+	if err := cursor.All(ctx, &results); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+func (r *MongoEscalationPoliciesRepository) FindByID(ctx context.Context, id string) (*domain.EscalationPolicy, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	var p domain.EscalationPolicy
+	err := r.col.FindOne(ctx, bson.M{"_id": id}).Decode(&p)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, domain.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+func (r *MongoEscalationPoliciesRepository) Create(ctx context.Context, p *domain.EscalationPolicy) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	now := time.Now()
+	p.ID = primitive.NewObjectID().Hex()
+	p.CreatedAt = now
+	p.UpdatedAt = now
+
+	if _, err := r.col.InsertOne(ctx, p); err != nil {
+		return "", err
+	}
+	return p.ID, nil
+}
+
+func (r *MongoEscalationPoliciesRepository) Update(ctx context.Context, p *domain.EscalationPolicy) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	p.UpdatedAt = time.Now()
+	res, err := r.col.ReplaceOne(ctx, bson.M{"_id": p.ID}, p)
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return domain.ErrNotFound
+	}
+	return nil
+}
+
+func (r *MongoEscalationPoliciesRepository) Delete(ctx context.Context, id string) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	res, err := r.col.DeleteOne(ctx, bson.M{"_id": id})
+	if err != nil {
+		return err
+	}
+	if res.DeletedCount == 0 {
+		return domain.ErrNotFound
+	}
+	return nil
+}
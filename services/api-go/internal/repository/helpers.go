@@ -1,9 +1,13 @@
 package repository
 
 import (
+	"regexp"
 	"time"
 
 	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/lightwatch/monitoring-platform/services/api-go/internal/domain"
 )
 
 const (
@@ -30,8 +34,13 @@ func clampPage(page int) int {
 	return page
 }
 
-// applyTimeRange adds $gte/$lte time filter to a bson.M filter.
+// applyTimeRange adds $gte/$lte time filter on "timestamp" to a bson.M filter.
 func applyTimeRange(filter bson.M, from, to string) {
+	applyTimeRangeOn(filter, "timestamp", from, to)
+}
+
+// applyTimeRangeOn adds $gte/$lte time filter on field to a bson.M filter.
+func applyTimeRangeOn(filter bson.M, field, from, to string) {
 	var fromT, toT time.Time
 	var hasFrom, hasTo bool
 
@@ -56,6 +65,50 @@ func applyTimeRange(filter bson.M, from, to string) {
 		if hasTo {
 			ts["$lte"] = toT
 		}
-		filter["timestamp"] = ts
+		filter[field] = ts
+	}
+}
+
+// applyMatchers adds label matchers to a bson.M filter. Label "service"
+// matches the service field and any other label the tag of that name. As
+// in Prometheus, a missing tag has the empty value, and regular expressions
+// are anchored at both ends.
+func applyMatchers(filter bson.M, ms []domain.Matcher) {
+	if len(ms) == 0 {
+		return
+	}
+	and := make([]bson.M, 0, len(ms))
+	for _, m := range ms {
+		field := "tags." + m.Label
+		if m.Label == "service" {
+			field = "service"
+		}
+
+		// Values the empty string matches must also match a missing field
+		var value interface{} = m.Value
+		matchesEmpty := m.Value == ""
+		if m.Op == domain.MatchRegexp || m.Op == domain.MatchNotRegexp {
+			pattern := "^(?:" + m.Value + ")$"
+			value = primitive.Regex{Pattern: pattern}
+			matchesEmpty, _ = regexp.MatchString(pattern, "")
+		}
+
+		var cond interface{}
+		switch {
+		case m.Op == domain.MatchNotEqual || m.Op == domain.MatchNotRegexp:
+			if matchesEmpty {
+				cond = bson.M{"$nin": bson.A{value, nil}}
+			} else if m.Op == domain.MatchNotEqual {
+				cond = bson.M{"$ne": value}
+			} else {
+				cond = bson.M{"$not": value}
+			}
+		case matchesEmpty:
+			cond = bson.M{"$in": bson.A{value, nil}}
+		default:
+			cond = value
+		}
+		and = append(and, bson.M{field: cond})
 	}
+	filter["$and"] = and
 }
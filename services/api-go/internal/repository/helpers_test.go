@@ -0,0 +1,102 @@
+package repository
+
+import (
+	"reflect"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/lightwatch/monitoring-platform/services/api-go/internal/domain"
+)
+
+func TestApplyMatchers(t *testing.T) {
+	re := func(p string) primitive.Regex { return primitive.Regex{Pattern: p} }
+	tests := []struct {
+		name string
+		m    domain.Matcher
+		want bson.M
+	}{
+		{
+			name: "service equal",
+			m:    domain.Matcher{Label: "service", Op: domain.MatchEqual, Value: "api"},
+			want: bson.M{"service": "api"},
+		},
+		{
+			name: "tag equal",
+			m:    domain.Matcher{Label: "host", Op: domain.MatchEqual, Value: "srv-1"},
+			want: bson.M{"tags.host": "srv-1"},
+		},
+		{
+			name: "equal empty matches missing tag",
+			m:    domain.Matcher{Label: "host", Op: domain.MatchEqual, Value: ""},
+			want: bson.M{"tags.host": bson.M{"$in": bson.A{"", nil}}},
+		},
+		{
+			name: "not equal",
+			m:    domain.Matcher{Label: "host", Op: domain.MatchNotEqual, Value: "srv-1"},
+			want: bson.M{"tags.host": bson.M{"$ne": "srv-1"}},
+		},
+		{
+			name: "not equal empty requires tag",
+			m:    domain.Matcher{Label: "host", Op: domain.MatchNotEqual, Value: ""},
+			want: bson.M{"tags.host": bson.M{"$nin": bson.A{"", nil}}},
+		},
+		{
+			name: "regexp is anchored",
+			m:    domain.Matcher{Label: "host", Op: domain.MatchRegexp, Value: "srv-.*|db-1"},
+			want: bson.M{"tags.host": re("^(?:srv-.*|db-1)$")},
+		},
+		{
+			name: "regexp matching empty matches missing tag",
+			m:    domain.Matcher{Label: "host", Op: domain.MatchRegexp, Value: "srv-.*|"},
+			want: bson.M{"tags.host": bson.M{"$in": bson.A{re("^(?:srv-.*|)$"), nil}}},
+		},
+		{
+			name: "not regexp",
+			m:    domain.Matcher{Label: "host", Op: domain.MatchNotRegexp, Value: "srv-.+"},
+			want: bson.M{"tags.host": bson.M{"$not": re("^(?:srv-.+)$")}},
+		},
+		{
+			name: "not regexp matching empty requires tag",
+			m:    domain.Matcher{Label: "host", Op: domain.MatchNotRegexp, Value: ".*"},
+			want: bson.M{"tags.host": bson.M{"$nin": bson.A{re("^(?:.*)$"), nil}}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filter := bson.M{}
+			applyMatchers(filter, []domain.Matcher{tt.m})
+			got, ok := filter["$and"].([]bson.M)
+			if !ok || len(got) != 1 {
+				t.Fatalf("$and = %#v, want one condition", filter["$and"])
+			}
+			if !reflect.DeepEqual(got[0], tt.want) {
+				t.Fatalf("condition = %#v, want %#v", got[0], tt.want)
+			}
+		})
+	}
+}
+
+func TestApplyMatchersCombines(t *testing.T) {
+	filter := bson.M{"name": "cpu"}
+	applyMatchers(filter, nil)
+	if _, ok := filter["$and"]; ok {
+		t.Fatalf("no matchers added $and: %#v", filter)
+	}
+
+	applyMatchers(filter, []domain.Matcher{
+		{Label: "service", Op: domain.MatchEqual, Value: "api"},
+		{Label: "host", Op: domain.MatchRegexp, Value: "srv-.*"},
+	})
+	want := bson.M{
+		"name": "cpu",
+		"$and": []bson.M{
+			{"service": "api"},
+			{"tags.host": primitive.Regex{Pattern: "^(?:srv-.*)$"}},
+		},
+	}
+	if !reflect.DeepEqual(filter, want) {
+		t.Fatalf("filter = %#v, want %#v", filter, want)
+	}
+}
@@ -0,0 +1,88 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/lightwatch/monitoring-platform/services/api-go/internal/domain"
+)
+
+// MongoLeasesRepository implements domain.LeasesRepository using MongoDB,
+// one document per lease. Times are taken from the server ($$NOW).
+type MongoLeasesRepository struct {
+	col *mongo.Collection
+}
+
+func NewLeasesRepository(db *mongo.Database) *MongoLeasesRepository {
+	return &MongoLeasesRepository{col: db.Collection("leases")}
+}
+
+func (r *MongoLeasesRepository) Acquire(ctx context.Context, name, holder string, ttl time.Duration) (*domain.Lease, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	// A single pipeline update decides atomically: a lease held by another
+	// replica and not yet expired is left as it is; our own is renewed; a
+	// free or expired one is taken over with the next token.
+	taken := bson.M{"$and": bson.A{
+		bson.M{"$ne": bson.A{"$holder", holder}},
+		bson.M{"$gt": bson.A{"$expires_at", "$$NOW"}}, // false for a new lease
+	}}
+	mine := bson.M{"$eq": bson.A{"$holder", holder}}
+	keep := func(field string, otherwise interface{}) bson.M {
+		return bson.M{"$cond": bson.A{taken, "$" + field, otherwise}}
+	}
+	update := mongo.Pipeline{{{Key: "$set", Value: bson.M{
+		"holder": keep("holder", holder),
+		"token": keep("token", bson.M{"$cond": bson.A{mine,
+			"$token",
+			bson.M{"$add": bson.A{bson.M{"$ifNull": bson.A{"$token", 0}}, 1}},
+		}}),
+		"acquired_at": keep("acquired_at", bson.M{"$cond": bson.A{mine, "$acquired_at", "$$NOW"}}),
+		"renewed_at":  keep("renewed_at", "$$NOW"),
+		"expires_at":  keep("expires_at", bson.M{"$add": bson.A{"$$NOW", ttl.Milliseconds()}}),
+	}}}}
+
+	opts := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)
+	var lease domain.Lease
+	err := r.col.FindOneAndUpdate(ctx, bson.M{"_id": name}, update, opts).Decode(&lease)
+	if mongo.IsDuplicateKeyError(err) {
+		return r.Find(ctx, name) // another replica created the lease first
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &lease, nil
+}
+
+func (r *MongoLeasesRepository) Release(ctx context.Context, name, holder string, token int64) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	// Expire rather than delete, so the next holder's token keeps rising
+	_, err := r.col.UpdateOne(ctx,
+		bson.M{"_id": name, "holder": holder, "token": token},
+		bson.M{"$currentDate": bson.M{"expires_at": true}},
+	)
+	return err
+}
+
+func (r *MongoLeasesRepository) Find(ctx context.Context, name string) (*domain.Lease, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	var lease domain.Lease
+	err := r.col.FindOne(ctx, bson.M{"_id": name}).Decode(&lease)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, domain.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &lease, nil
+}
@@ -37,6 +37,9 @@ func (r *MongoLogsRepository) Find(ctx context.Context, f domain.LogsFilter) ([]
 	if f.Query != "" {
 		filter["message"] = bson.M{"$regex": f.Query, "$options": "i"}
 	}
+	for k, v := range f.Tags {
+		filter["tags."+k] = v
+	}
 	applyTimeRange(filter, f.From, f.To)
 
 	limit := clampLimit(f.Limit, 50)
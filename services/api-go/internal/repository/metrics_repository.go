@@ -24,18 +24,7 @@ func (r *MongoMetricsRepository) Find(ctx context.Context, f domain.MetricsFilte
 	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
 	defer cancel()
 
-	filter := bson.M{}
-	if f.Service != "" {
-		filter["service"] = f.Service
-	}
-	if f.Name != "" {
-		filter["name"] = f.Name
-	}
-	if f.TraceID != "" {
-		filter["trace_id"] = f.TraceID
-	}
-	applyTimeRange(filter, f.From, f.To)
-
+	filter := metricsQuery(f)
 	limit := clampLimit(f.Limit, 100)
 	page := clampPage(f.Page)
 	skip := int64((page - 1) * limit)
@@ -46,7 +35,7 @@ func (r *MongoMetricsRepository) Find(ctx context.Context, f domain.MetricsFilte
 	}
 
 	opts := options.Find().
-		SetSort(bson.D{{Key: "timestamp", Value: -1}}).
+		SetSort(metricsSort(f)).
 		SetSkip(skip).
 		SetLimit(int64(limit))
 
@@ -63,3 +52,56 @@ func (r *MongoMetricsRepository) Find(ctx context.Context, f domain.MetricsFilte
 
 	return results, total, nil
 }
+
+func (r *MongoMetricsRepository) Scan(ctx context.Context, f domain.MetricsFilter) ([]domain.MetricEvent, bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	limit := f.Limit
+	if limit <= 0 {
+		limit = 100
+	}
+	// One extra document tells whether the result was cut off
+	opts := options.Find().
+		SetSort(metricsSort(f)).
+		SetLimit(int64(limit) + 1)
+
+	cursor, err := r.col.Find(ctx, metricsQuery(f), opts)
+	if err != nil {
+		return nil, false, err
+	}
+	defer cursor.Close(ctx)
+
+	var results []domain.MetricEvent
+	if err := cursor.All(ctx, &results); err != nil {
+		return nil, false, err
+	}
+	if len(results) > limit {
+		return results[:limit], true, nil
+	}
+	return results, false, nil
+}
+
+func metricsQuery(f domain.MetricsFilter) bson.M {
+	filter := bson.M{}
+	if f.Service != "" {
+		filter["service"] = f.Service
+	}
+	if f.Name != "" {
+		filter["name"] = f.Name
+	}
+	if f.TraceID != "" {
+		filter["trace_id"] = f.TraceID
+	}
+	applyMatchers(filter, f.Matchers)
+	applyTimeRange(filter, f.From, f.To)
+	return filter
+}
+
+func metricsSort(f domain.MetricsFilter) bson.D {
+	order := -1
+	if f.Sort == "asc" {
+		order = 1
+	}
+	return bson.D{{Key: "timestamp", Value: order}}
+}
@@ -0,0 +1,304 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/lightwatch/monitoring-platform/services/api-go/internal/domain"
+)
+
+// MongoNotificationsRepository implements domain.NotificationsRepository using MongoDB.
+type MongoNotificationsRepository struct {
+	col *mongo.Collection
+}
+
+func NewNotificationsRepository(db *mongo.Database) *MongoNotificationsRepository {
+	return &MongoNotificationsRepository{col: db.Collection("notifications")}
+}
+
+func (r *MongoNotificationsRepository) Create(ctx context.Context, n *domain.Notification) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	now := time.Now()
+	n.ID = primitive.NewObjectID().Hex()
+	n.CreatedAt = now
+	n.UpdatedAt = now
+	if n.Status == "" {
+		n.Status = domain.NotificationPending
+	}
+	if n.NextAttemptAt.IsZero() {
+		n.NextAttemptAt = now
+	}
+
+	if _, err := r.col.InsertOne(ctx, n); err != nil {
+		return "", err
+	}
+	return n.ID, nil
+}
+
+func (r *MongoNotificationsRepository) Find(ctx context.Context, f domain.NotificationsFilter) ([]domain.Notification, int64, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	filter := bson.M{}
+	if f.Status != "" {
+		filter["status"] = f.Status
+	}
+	if f.AlertID != "" {
+		filter["alert_id"] = f.AlertID
+	}
+	if f.EventID != "" {
+		filter["event_id"] = f.EventID
+	}
+	if f.Channel != "" {
+		filter["channel"] = f.Channel
+	}
+
+	limit := clampLimit(f.Limit, 50)
+	page := clampPage(f.Page)
+	skip := int64((page - 1) * limit)
+
+	total, err := r.col.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	opts := options.Find().
+		SetSort(bson.D{{Key: "created_at", Value: -1}}).
+		SetSkip(skip).
+		SetLimit(int64(limit))
+
+	cursor, err := r.col.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer cursor.Close(ctx)
+
+	var results []domain.Notification
+	if err := cursor.All(ctx, &results); err != nil {
+		return nil, 0, err
+	}
+	return results, total, nil
+}
+
+func (r *MongoNotificationsRepository) ClaimDue(ctx context.Context, now time.Time, lease time.Duration) (*domain.Notification, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	filter := bson.M{"$or": bson.A{
+		bson.M{"status": domain.NotificationPending, "next_attempt_at": bson.M{"$lte": now}},
+		// A worker that died mid-delivery leaves an expired lease behind
+		bson.M{"status": domain.NotificationSending, "locked_until": bson.M{"$lte": now}},
+	}}
+	update := bson.M{"$set": bson.M{
+		"status":       domain.NotificationSending,
+		"locked_until": now.Add(lease),
+		"updated_at":   now,
+	}}
+	opts := options.FindOneAndUpdate().
+		SetSort(bson.D{{Key: "next_attempt_at", Value: 1}}).
+		SetReturnDocument(options.After)
+
+	var n domain.Notification
+	err := r.col.FindOneAndUpdate(ctx, filter, update, opts).Decode(&n)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &n, nil
+}
+
+func (r *MongoNotificationsRepository) ClaimBatch(ctx context.Context, like *domain.Notification, group string, now time.Time, lease time.Duration, limit int) ([]domain.Notification, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	filter := bson.M{
+		"status":          domain.NotificationPending,
+		"next_attempt_at": bson.M{"$lte": now},
+		"channel":         like.Channel,
+		"target":          like.Target,
+		// Sent as one request, so they must render and sign alike; rules
+		// sharing a URL with other settings get messages of their own
+		"template": sameOrMissing(like.Template),
+		"secrets":  sameOrMissing(like.Secrets),
+	}
+	if group != "" {
+		filter["group.key"] = group
+		// Notifications held for group_wait have not been attempted yet;
+		// ones waiting for a retry keep their backoff
+		delete(filter, "next_attempt_at")
+		filter["$or"] = bson.A{
+			bson.M{"next_attempt_at": bson.M{"$lte": now}},
+			bson.M{"attempts": 0},
+		}
+	}
+	update := bson.M{"$set": bson.M{
+		"status":       domain.NotificationSending,
+		"locked_until": now.Add(lease),
+		"updated_at":   now,
+	}}
+	opts := options.FindOneAndUpdate().
+		SetSort(bson.D{{Key: "created_at", Value: 1}}).
+		SetReturnDocument(options.After)
+
+	// Claimed one at a time so each lease is atomic with respect to other workers
+	var results []domain.Notification
+	for len(results) < limit {
+		var n domain.Notification
+		err := r.col.FindOneAndUpdate(ctx, filter, update, opts).Decode(&n)
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			break
+		}
+		if err != nil {
+			return results, err
+		}
+		results = append(results, n)
+	}
+	return results, nil
+}
+
+func (r *MongoNotificationsRepository) LastDelivered(ctx context.Context, channel, target, group string) (*time.Time, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	filter := bson.M{
+		"channel":   channel,
+		"target":    target,
+		"group.key": group,
+		"status":    domain.NotificationDelivered,
+	}
+	opts := options.FindOne().
+		SetSort(bson.D{{Key: "delivered_at", Value: -1}}).
+		SetProjection(bson.M{"delivered_at": 1})
+
+	var n domain.Notification
+	err := r.col.FindOne(ctx, filter, opts).Decode(&n)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return n.DeliveredAt, nil
+}
+
+func (r *MongoNotificationsRepository) HasEarlierUndelivered(ctx context.Context, n *domain.Notification) (bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	filter := bson.M{
+		"event_id":   n.EventID,
+		"channel":    n.Channel,
+		"target":     n.Target,
+		"status":     bson.M{"$in": bson.A{domain.NotificationPending, domain.NotificationSending}},
+		"created_at": bson.M{"$lt": n.CreatedAt},
+	}
+	count, err := r.col.CountDocuments(ctx, filter, options.Count().SetLimit(1))
+	return count > 0, err
+}
+
+func (r *MongoNotificationsRepository) Reschedule(ctx context.Context, id string, at time.Time) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	update := bson.M{
+		"$set": bson.M{
+			"status":          domain.NotificationPending,
+			"next_attempt_at": at,
+			"updated_at":      time.Now(),
+		},
+		"$unset": bson.M{"locked_until": ""},
+	}
+	_, err := r.col.UpdateByID(ctx, id, update)
+	return err
+}
+
+func (r *MongoNotificationsRepository) MarkDelivered(ctx context.Context, id string, at time.Time) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	update := bson.M{
+		"$set": bson.M{
+			"status":       domain.NotificationDelivered,
+			"delivered_at": at,
+			"updated_at":   at,
+			"last_error":   "",
+		},
+		"$inc":   bson.M{"attempts": 1},
+		"$unset": bson.M{"locked_until": ""},
+	}
+	_, err := r.col.UpdateByID(ctx, id, update)
+	return err
+}
+
+func (r *MongoNotificationsRepository) MarkFailed(ctx context.Context, id string, attempts int, nextAttemptAt time.Time, lastErr string, dead bool) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	status := domain.NotificationPending
+	if dead {
+		status = domain.NotificationDead
+	}
+	update := bson.M{
+		"$set": bson.M{
+			"status":          status,
+			"attempts":        attempts,
+			"next_attempt_at": nextAttemptAt,
+			"last_error":      lastErr,
+			"updated_at":      time.Now(),
+		},
+		"$unset": bson.M{"locked_until": ""},
+	}
+	_, err := r.col.UpdateByID(ctx, id, update)
+	return err
+}
+
+func (r *MongoNotificationsRepository) Retry(ctx context.Context, id string, now time.Time) (*domain.Notification, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	filter := bson.M{"_id": id, "status": bson.M{"$ne": domain.NotificationSending}}
+	update := bson.M{"$set": bson.M{
+		"status":          domain.NotificationPending,
+		"attempts":        0,
+		"next_attempt_at": now,
+		"updated_at":      now,
+	}}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	var n domain.Notification
+	err := r.col.FindOneAndUpdate(ctx, filter, update, opts).Decode(&n)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		count, cerr := r.col.CountDocuments(ctx, bson.M{"_id": id})
+		if cerr != nil {
+			return nil, cerr
+		}
+		if count == 0 {
+			return nil, domain.ErrNotFound
+		}
+		return nil, fmt.Errorf("%w: notification is being delivered", domain.ErrConflict)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &n, nil
+}
+
+// sameOrMissing matches a field stored with omitempty: equal to v, or
+// absent when v is empty.
+func sameOrMissing[T string | []string](v T) interface{} {
+	if len(v) == 0 {
+		return nil // matches missing fields
+	}
+	return v
+}
@@ -0,0 +1,122 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/lightwatch/monitoring-platform/services/api-go/internal/domain"
+)
+
+// RedisLeasesRepository implements domain.LeasesRepository using Redis. A
+// lease is a hash at lease:<name> that expires with the lease (SET NX EX
+// semantics, done in Lua so renewal can check the holder); its token comes
+// from a counter at lease:<name>:token that outlives it.
+type RedisLeasesRepository struct {
+	client *redis.Client
+}
+
+func NewRedisLeasesRepository(client *redis.Client) *RedisLeasesRepository {
+	return &RedisLeasesRepository{client: client}
+}
+
+// KEYS: lease, token counter. ARGV: holder, ttl ms, now ms.
+var acquireLeaseScript = redis.NewScript(`
+local holder = redis.call('HGET', KEYS[1], 'holder')
+if not holder then
+	local token = redis.call('INCR', KEYS[2])
+	redis.call('HSET', KEYS[1], 'holder', ARGV[1], 'token', token, 'acquired_at', ARGV[3])
+	holder = ARGV[1]
+end
+if holder == ARGV[1] then
+	redis.call('HSET', KEYS[1], 'renewed_at', ARGV[3])
+	redis.call('PEXPIRE', KEYS[1], ARGV[2])
+end
+local lease = redis.call('HMGET', KEYS[1], 'holder', 'token', 'acquired_at', 'renewed_at')
+table.insert(lease, redis.call('PTTL', KEYS[1]))
+return lease
+`)
+
+// KEYS: lease. ARGV: holder, token.
+var releaseLeaseScript = redis.NewScript(`
+local lease = redis.call('HMGET', KEYS[1], 'holder', 'token')
+if lease[1] == ARGV[1] and lease[2] == ARGV[2] then
+	return redis.call('DEL', KEYS[1])
+end
+return 0
+`)
+
+// KEYS: lease.
+var findLeaseScript = redis.NewScript(`
+local lease = redis.call('HMGET', KEYS[1], 'holder', 'token', 'acquired_at', 'renewed_at')
+table.insert(lease, redis.call('PTTL', KEYS[1]))
+return lease
+`)
+
+func leaseKey(name string) string {
+	return "lease:" + name
+}
+
+func (r *RedisLeasesRepository) Acquire(ctx context.Context, name, holder string, ttl time.Duration) (*domain.Lease, error) {
+	ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+
+	now := time.Now()
+	res, err := acquireLeaseScript.Run(ctx, r.client,
+		[]string{leaseKey(name), leaseKey(name) + ":token"},
+		holder, ttl.Milliseconds(), now.UnixMilli(),
+	).Slice()
+	if err != nil {
+		return nil, err
+	}
+	return parseRedisLease(name, res, now)
+}
+
+func (r *RedisLeasesRepository) Release(ctx context.Context, name, holder string, token int64) error {
+	ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+
+	return releaseLeaseScript.Run(ctx, r.client, []string{leaseKey(name)}, holder, token).Err()
+}
+
+func (r *RedisLeasesRepository) Find(ctx context.Context, name string) (*domain.Lease, error) {
+	ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+
+	now := time.Now()
+	res, err := findLeaseScript.Run(ctx, r.client, []string{leaseKey(name)}).Slice()
+	if err != nil {
+		return nil, err
+	}
+	return parseRedisLease(name, res, now)
+}
+
+// parseRedisLease decodes [holder, token, acquired_at, renewed_at, pttl]
+// as returned by the scripts. The expiry is derived from the remaining
+// TTL, so it is only as accurate as the local clock.
+func parseRedisLease(name string, res []interface{}, now time.Time) (*domain.Lease, error) {
+	if len(res) != 5 {
+		return nil, errors.New("unexpected lease reply")
+	}
+	holder, _ := res[0].(string)
+	if holder == "" {
+		return nil, domain.ErrNotFound // free or expired
+	}
+	field := func(i int) int64 {
+		s, _ := res[i].(string)
+		n, _ := strconv.ParseInt(s, 10, 64)
+		return n
+	}
+	pttl, _ := res[4].(int64)
+	return &domain.Lease{
+		Name:       name,
+		Holder:     holder,
+		Token:      field(1),
+		AcquiredAt: time.UnixMilli(field(2)).UTC(),
+		RenewedAt:  time.UnixMilli(field(3)).UTC(),
+		ExpiresAt:  now.Add(time.Duration(pttl) * time.Millisecond).UTC(),
+	}, nil
+}
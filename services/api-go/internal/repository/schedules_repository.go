@@ -0,0 +1,100 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/lightwatch/monitoring-platform/services/api-go/internal/domain"
+)
+
+// MongoSchedulesRepository implements domain.SchedulesRepository using MongoDB.
+type MongoSchedulesRepository struct {
+	col *mongo.Collection
+}
+
+func NewSchedulesRepository(db *mongo.Database) *MongoSchedulesRepository {
+	return &MongoSchedulesRepository{col: db.Collection("oncall_schedules")}
+}
+
+func (r *MongoSchedulesRepository) FindAll(ctx context.Context) ([]domain.OnCallSchedule, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	opts := options.Find().SetSort(bson.D{{Key: "name", Value: 1}})
+	cursor, err := r.col.Find(ctx, bson.M{}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var results []domain.OnCallSchedule
+	if err := cursor.All(ctx, &results); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+func (r *MongoSchedulesRepository) FindByID(ctx context.Context, id string) (*domain.OnCallSchedule, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	var s domain.OnCallSchedule
+	err := r.col.FindOne(ctx, bson.M{"_id": id}).Decode(&s)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, domain.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+func (r *MongoSchedulesRepository) Create(ctx context.Context, s *domain.OnCallSchedule) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	now := time.Now()
+	s.ID = primitive.NewObjectID().Hex()
+	s.CreatedAt = now
+	s.UpdatedAt = now
+
+	if _, err := r.col.InsertOne(ctx, s); err != nil {
+		return "", err
+	}
+	return s.ID, nil
+}
+
+func (r *MongoSchedulesRepository) Update(ctx context.Context, s *domain.OnCallSchedule) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	s.UpdatedAt = time.Now()
+	res, err := r.col.ReplaceOne(ctx, bson.M{"_id": s.ID}, s)
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return domain.ErrNotFound
+	}
+	return nil
+}
+
+func (r *MongoSchedulesRepository) Delete(ctx context.Context, id string) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	res, err := r.col.DeleteOne(ctx, bson.M{"_id": id})
+	if err != nil {
+		return err
+	}
+	if res.DeletedCount == 0 {
+		return domain.ErrNotFound
+	}
+	return nil
+}
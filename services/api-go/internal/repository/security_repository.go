@@ -24,23 +24,7 @@ func (r *MongoSecurityRepository) Find(ctx context.Context, f domain.SecurityFil
 	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
 	defer cancel()
 
-	filter := bson.M{}
-	if f.Service != "" {
-		filter["service"] = f.Service
-	}
-	if f.IP != "" {
-		filter["source_ip"] = f.IP
-	}
-	if f.Type != "" {
-		filter["type"] = f.Type
-	}
-	if f.Severity != "" {
-		filter["severity"] = f.Severity
-	}
-	if f.TraceID != "" {
-		filter["trace_id"] = f.TraceID
-	}
-	applyTimeRange(filter, f.From, f.To)
+	filter := securityFilter(f)
 
 	limit := clampLimit(f.Limit, 50)
 	page := clampPage(f.Page)
@@ -69,3 +53,73 @@ func (r *MongoSecurityRepository) Find(ctx context.Context, f domain.SecurityFil
 
 	return results, total, nil
 }
+
+func (r *MongoSecurityRepository) Count(ctx context.Context, f domain.SecurityFilter, groupBy []string) ([]domain.SecurityCount, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	key := bson.M{}
+	for _, field := range groupBy {
+		key[field] = "$" + field
+	}
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: securityFilter(f)}},
+		{{Key: "$group", Value: bson.M{
+			"_id":        key,
+			"count":      bson.M{"$sum": 1},
+			"first_seen": bson.M{"$min": "$timestamp"},
+			"last_seen":  bson.M{"$max": "$timestamp"},
+		}}},
+		{{Key: "$sort", Value: bson.D{{Key: "count", Value: -1}}}},
+		{{Key: "$limit", Value: int64(clampLimit(f.Limit, DefaultLimit))}},
+	}
+
+	cursor, err := r.col.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var rows []struct {
+		Key       map[string]string `bson:"_id"`
+		Count     int64             `bson:"count"`
+		FirstSeen time.Time         `bson:"first_seen"`
+		LastSeen  time.Time         `bson:"last_seen"`
+	}
+	if err := cursor.All(ctx, &rows); err != nil {
+		return nil, err
+	}
+
+	results := make([]domain.SecurityCount, len(rows))
+	for i, row := range rows {
+		results[i] = domain.SecurityCount{
+			Key:       row.Key,
+			Count:     row.Count,
+			FirstSeen: row.FirstSeen,
+			LastSeen:  row.LastSeen,
+		}
+	}
+	return results, nil
+}
+
+// securityFilter translates a SecurityFilter into a MongoDB query.
+func securityFilter(f domain.SecurityFilter) bson.M {
+	filter := bson.M{}
+	if f.Service != "" {
+		filter["service"] = f.Service
+	}
+	if f.IP != "" {
+		filter["source_ip"] = f.IP
+	}
+	if f.Type != "" {
+		filter["type"] = f.Type
+	}
+	if f.Severity != "" {
+		filter["severity"] = f.Severity
+	}
+	if f.TraceID != "" {
+		filter["trace_id"] = f.TraceID
+	}
+	applyTimeRange(filter, f.From, f.To)
+	return filter
+}
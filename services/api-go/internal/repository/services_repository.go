@@ -2,6 +2,7 @@ package repository
 
 import (
 	"context"
+	"errors"
 	"time"
 
 	"go.mongodb.org/mongo-driver/bson"
@@ -25,9 +26,19 @@ func (r *MongoServicesRepository) FindAll(ctx context.Context, f domain.Services
 	defer cancel()
 
 	filter := bson.M{}
+	if f.Name != "" {
+		filter["name"] = f.Name
+	}
 	if f.Status != "" {
 		filter["status"] = f.Status
 	}
+	sort := bson.D{{Key: "last_heartbeat", Value: -1}}
+	if f.Sort == domain.ServicesByName {
+		sort = bson.D{{Key: "name", Value: 1}}
+		if f.After != "" && f.Name == "" {
+			filter["name"] = bson.M{"$gt": f.After}
+		}
+	}
 
 	limit := clampLimit(f.Limit, 100)
 	page := clampPage(f.Page)
@@ -39,7 +50,7 @@ func (r *MongoServicesRepository) FindAll(ctx context.Context, f domain.Services
 	}
 
 	opts := options.Find().
-		SetSort(bson.D{{Key: "last_heartbeat", Value: -1}}).
+		SetSort(sort).
 		SetSkip(skip).
 		SetLimit(int64(limit))
 
@@ -55,3 +66,37 @@ func (r *MongoServicesRepository) FindAll(ctx context.Context, f domain.Services
 	}
 	return results, total, nil
 }
+
+func (r *MongoServicesRepository) UpdateStatus(ctx context.Context, name string, lastHeartbeat time.Time, status string) (bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	// A heartbeat that arrived in the meantime has set a fresh status
+	filter := bson.M{"name": name, "last_heartbeat": lastHeartbeat}
+	res, err := r.col.UpdateOne(ctx, filter, bson.M{"$set": bson.M{"status": status}})
+	if err != nil {
+		return false, err
+	}
+	return res.ModifiedCount > 0, nil
+}
+
+func (r *MongoServicesRepository) SetHeartbeatGrace(ctx context.Context, name string, grace *domain.HeartbeatGrace) (*domain.Service, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	update := bson.M{"$set": bson.M{"heartbeat_grace": grace}}
+	if grace == nil {
+		update = bson.M{"$unset": bson.M{"heartbeat_grace": ""}}
+	}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	var svc domain.Service
+	err := r.col.FindOneAndUpdate(ctx, bson.M{"name": name}, update, opts).Decode(&svc)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, domain.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &svc, nil
+}
@@ -0,0 +1,114 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/lightwatch/monitoring-platform/services/api-go/internal/domain"
+)
+
+// MongoSilencesRepository implements domain.SilencesRepository using MongoDB.
+type MongoSilencesRepository struct {
+	col *mongo.Collection
+}
+
+func NewSilencesRepository(db *mongo.Database) *MongoSilencesRepository {
+	return &MongoSilencesRepository{col: db.Collection("silences")}
+}
+
+func (r *MongoSilencesRepository) FindAll(ctx context.Context) ([]domain.Silence, error) {
+	return r.find(ctx, bson.M{})
+}
+
+func (r *MongoSilencesRepository) FindCurrent(ctx context.Context, now time.Time) ([]domain.Silence, error) {
+	return r.find(ctx, bson.M{
+		"starts_at": bson.M{"$lte": now},
+		"$or": bson.A{
+			bson.M{"ends_at": bson.M{"$gt": now}},
+			bson.M{"ends_at": nil}, // open-ended maintenance window
+		},
+	})
+}
+
+func (r *MongoSilencesRepository) find(ctx context.Context, filter bson.M) ([]domain.Silence, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}})
+	cursor, err := r.col.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var results []domain.Silence
+	if err := cursor.All(ctx, &results); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+func (r *MongoSilencesRepository) FindByID(ctx context.Context, id string) (*domain.Silence, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	var s domain.Silence
+	err := r.col.FindOne(ctx, bson.M{"_id": id}).Decode(&s)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, domain.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+func (r *MongoSilencesRepository) Create(ctx context.Context, s *domain.Silence) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	now := time.Now()
+	s.ID = primitive.NewObjectID().Hex()
+	s.CreatedAt = now
+	s.UpdatedAt = now
+
+	if _, err := r.col.InsertOne(ctx, s); err != nil {
+		return "", err
+	}
+	return s.ID, nil
+}
+
+func (r *MongoSilencesRepository) Update(ctx context.Context, s *domain.Silence) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	s.UpdatedAt = time.Now()
+	res, err := r.col.ReplaceOne(ctx, bson.M{"_id": s.ID}, s)
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return domain.ErrNotFound
+	}
+	return nil
+}
+
+func (r *MongoSilencesRepository) Delete(ctx context.Context, id string) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	res, err := r.col.DeleteOne(ctx, bson.M{"_id": id})
+	if err != nil {
+		return err
+	}
+	if res.DeletedCount == 0 {
+		return domain.ErrNotFound
+	}
+	return nil
+}
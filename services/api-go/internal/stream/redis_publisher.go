@@ -0,0 +1,45 @@
+package stream
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/lightwatch/monitoring-platform/services/api-go/internal/domain"
+)
+
+// AlertsStream is the Redis stream consumed by realtime-node for /ws/alerts.
+const AlertsStream = "stream:alerts"
+
+// RedisPublisher implements domain.AlertPublisher using Redis Streams.
+//
+// Entries use the same envelope as ingest-node: a single "data" field
+// holding the JSON-encoded event. The stream is capped with an approximate
+// MAXLEN so it cannot grow without bound when no consumer is attached.
+type RedisPublisher struct {
+	client *redis.Client
+	maxLen int64
+}
+
+func NewRedisPublisher(client *redis.Client, maxLen int64) *RedisPublisher {
+	return &RedisPublisher{client: client, maxLen: maxLen}
+}
+
+func (p *RedisPublisher) PublishAlert(ctx context.Context, event *domain.AlertEvent) error {
+	ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	return p.client.XAdd(ctx, &redis.XAddArgs{
+		Stream: AlertsStream,
+		MaxLen: p.maxLen,
+		Approx: true,
+		Values: map[string]interface{}{"data": string(data)},
+	}).Err()
+}
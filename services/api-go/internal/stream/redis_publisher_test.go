@@ -0,0 +1,102 @@
+package stream
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+
+	"github.com/lightwatch/monitoring-platform/services/api-go/internal/domain"
+)
+
+func newTestPublisher(t *testing.T, maxLen int64) (*RedisPublisher, *miniredis.Miniredis) {
+	t.Helper()
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { client.Close() })
+	return NewRedisPublisher(client, maxLen), mr
+}
+
+func TestPublishAlertEnvelope(t *testing.T) {
+	p, mr := newTestPublisher(t, 100)
+	evt := &domain.AlertEvent{
+		ID:          "evt-1",
+		AlertID:     "rule-1",
+		AlertName:   "High CPU",
+		Service:     "api-gateway",
+		Value:       97.5,
+		Threshold:   90,
+		Status:      domain.AlertStateFiring,
+		TriggeredAt: time.Date(2026, 10, 17, 9, 0, 0, 0, time.UTC),
+	}
+	if err := p.PublishAlert(context.Background(), evt); err != nil {
+		t.Fatalf("PublishAlert: %v", err)
+	}
+
+	entries, err := mr.Stream("stream:alerts")
+	if err != nil {
+		t.Fatalf("read stream: %v", err)
+	}
+	if len(entries) != 1 {
+		t.Fatalf("got %d entries, want 1", len(entries))
+	}
+	// realtime-node reads the value following the "data" field, as written
+	// by ingest-node: XADD <stream> * data <json>
+	values := entries[0].Values
+	if len(values) != 2 || values[0] != "data" {
+		t.Fatalf("got fields %q, want a single data field", values)
+	}
+	var got domain.AlertEvent
+	if err := json.Unmarshal([]byte(values[1]), &got); err != nil {
+		t.Fatalf("data is not JSON: %v", err)
+	}
+	if got.ID != evt.ID || got.AlertID != evt.AlertID || got.Status != evt.Status || got.Value != evt.Value {
+		t.Errorf("got event %+v, want %+v", got, *evt)
+	}
+}
+
+func TestPublishAlertTrimsStream(t *testing.T) {
+	p, mr := newTestPublisher(t, 5)
+	for i := 0; i < 20; i++ {
+		evt := &domain.AlertEvent{ID: fmt.Sprintf("evt-%d", i), Status: domain.AlertStateFiring}
+		if err := p.PublishAlert(context.Background(), evt); err != nil {
+			t.Fatalf("PublishAlert %d: %v", i, err)
+		}
+	}
+
+	// Redis trims MAXLEN ~ lazily, in whole macro nodes; miniredis trims
+	// exactly, which shows the cap is sent and the oldest entries go first.
+	entries, err := mr.Stream("stream:alerts")
+	if err != nil {
+		t.Fatalf("read stream: %v", err)
+	}
+	if len(entries) != 5 {
+		t.Fatalf("got %d entries, want 5", len(entries))
+	}
+	var last domain.AlertEvent
+	if err := json.Unmarshal([]byte(entries[4].Values[1]), &last); err != nil {
+		t.Fatalf("data is not JSON: %v", err)
+	}
+	if last.ID != "evt-19" {
+		t.Errorf("newest entry is %q, want evt-19", last.ID)
+	}
+}
+
+func TestPublishAlertRedisDown(t *testing.T) {
+	p, mr := newTestPublisher(t, 100)
+	mr.Close()
+
+	start := time.Now()
+	err := p.PublishAlert(context.Background(), &domain.AlertEvent{ID: "evt-1"})
+	if err == nil {
+		t.Fatal("PublishAlert succeeded with Redis down")
+	}
+	// The engine logs the error and carries on; it must not stall a tick
+	if took := time.Since(start); took > 3*time.Second {
+		t.Errorf("PublishAlert took %s with Redis down", took)
+	}
+}
@@ -0,0 +1,69 @@
+package usecase
+
+import (
+	"time"
+
+	"github.com/lightwatch/monitoring-platform/services/api-go/internal/domain"
+)
+
+// transition is the action the engine must take after a state change.
+type transition int
+
+const (
+	transitionNone    transition = iota
+	transitionFire               // pending → firing: create event, notify
+	transitionResolve            // firing → resolved: resolve event, notify
+)
+
+// advance applies one evaluation result to st and reports the action the
+// engine must take. Rules move inactive → pending → firing → resolved; a
+// breach that clears while still pending returns to inactive without ever
+// producing an event. A resolved rule that breaches again starts a new
+// episode at pending.
+func advance(st *domain.AlertState, breached bool, pendingFor time.Duration, now time.Time) transition {
+	st.LastEvaluatedAt = now
+
+	if breached {
+		switch st.State {
+		case domain.AlertStateFiring:
+			return transitionNone
+		case domain.AlertStatePending:
+		default: // inactive, resolved
+			since := now
+			st.State = domain.AlertStatePending
+			st.ActiveSince = &since
+			st.UpdatedAt = now
+		}
+		if now.Sub(*st.ActiveSince) < pendingFor {
+			return transitionNone
+		}
+		st.State = domain.AlertStateFiring
+		st.UpdatedAt = now
+		return transitionFire
+	}
+
+	switch st.State {
+	case domain.AlertStatePending:
+		st.State = domain.AlertStateInactive
+		st.ActiveSince = nil
+		st.UpdatedAt = now
+	case domain.AlertStateFiring:
+		st.State = domain.AlertStateResolved
+		st.ActiveSince = nil
+		st.UpdatedAt = now
+		return transitionResolve
+	}
+	return transitionNone
+}
+
+// pendingFor returns the rule's pending period; zero fires immediately.
+func pendingFor(rule domain.Alert) time.Duration {
+	if rule.PendingFor == "" {
+		return 0
+	}
+	dur, err := parseDuration(rule.PendingFor)
+	if err != nil || dur < 0 {
+		return 0
+	}
+	return dur
+}
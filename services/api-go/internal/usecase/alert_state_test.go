@@ -0,0 +1,80 @@
+package usecase
+
+import (
+	"testing"
+	"time"
+
+	"github.com/lightwatch/monitoring-platform/services/api-go/internal/domain"
+)
+
+func TestAdvance(t *testing.T) {
+	t0 := time.Date(2026, 10, 17, 9, 0, 0, 0, time.UTC)
+	since := t0.Add(-time.Minute)
+
+	tests := []struct {
+		name        string
+		state       string
+		activeSince *time.Time
+		breached    bool
+		pendingFor  time.Duration
+		want        transition
+		wantState   string
+		wantSince   *time.Time // nil: cleared
+		wantUpdated bool
+	}{
+		{name: "inactive stays inactive", state: domain.AlertStateInactive, wantState: domain.AlertStateInactive},
+		{name: "inactive fires at once", state: domain.AlertStateInactive, breached: true, want: transitionFire, wantState: domain.AlertStateFiring, wantSince: &t0, wantUpdated: true},
+		{name: "new state fires at once", state: "", breached: true, want: transitionFire, wantState: domain.AlertStateFiring, wantSince: &t0, wantUpdated: true},
+		{name: "inactive becomes pending", state: domain.AlertStateInactive, breached: true, pendingFor: 2 * time.Minute, wantState: domain.AlertStatePending, wantSince: &t0, wantUpdated: true},
+		{name: "pending waits", state: domain.AlertStatePending, activeSince: &since, breached: true, pendingFor: 2 * time.Minute, wantState: domain.AlertStatePending, wantSince: &since},
+		{name: "pending fires once due", state: domain.AlertStatePending, activeSince: &since, breached: true, pendingFor: time.Minute, want: transitionFire, wantState: domain.AlertStateFiring, wantSince: &since, wantUpdated: true},
+		{name: "pending clears silently", state: domain.AlertStatePending, activeSince: &since, pendingFor: 2 * time.Minute, wantState: domain.AlertStateInactive, wantUpdated: true},
+		{name: "firing stays firing", state: domain.AlertStateFiring, activeSince: &since, breached: true, wantState: domain.AlertStateFiring, wantSince: &since},
+		{name: "firing resolves", state: domain.AlertStateFiring, activeSince: &since, want: transitionResolve, wantState: domain.AlertStateResolved, wantUpdated: true},
+		{name: "resolved stays resolved", state: domain.AlertStateResolved, wantState: domain.AlertStateResolved},
+		{name: "resolved starts a new episode", state: domain.AlertStateResolved, breached: true, pendingFor: time.Minute, wantState: domain.AlertStatePending, wantSince: &t0, wantUpdated: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			updated := t0.Add(-time.Hour)
+			st := &domain.AlertState{State: tt.state, ActiveSince: tt.activeSince, UpdatedAt: updated}
+
+			if got := advance(st, tt.breached, tt.pendingFor, t0); got != tt.want {
+				t.Errorf("transition = %d, want %d", got, tt.want)
+			}
+			if st.State != tt.wantState {
+				t.Errorf("state = %q, want %q", st.State, tt.wantState)
+			}
+			switch {
+			case tt.wantSince == nil && st.ActiveSince != nil:
+				t.Errorf("active since = %s, want it cleared", *st.ActiveSince)
+			case tt.wantSince != nil && (st.ActiveSince == nil || !st.ActiveSince.Equal(*tt.wantSince)):
+				t.Errorf("active since = %v, want %s", st.ActiveSince, *tt.wantSince)
+			}
+			if got := st.UpdatedAt.Equal(t0); got != tt.wantUpdated {
+				t.Errorf("updated = %v, want %v", got, tt.wantUpdated)
+			}
+			if !st.LastEvaluatedAt.Equal(t0) {
+				t.Errorf("last evaluated = %s, want %s", st.LastEvaluatedAt, t0)
+			}
+		})
+	}
+}
+
+func TestPendingFor(t *testing.T) {
+	tests := []struct {
+		in   string
+		want time.Duration
+	}{
+		{"", 0},
+		{"2m", 2 * time.Minute},
+		{"90s", 90 * time.Second},
+		{"soon", 0},
+		{"-1m", 0},
+	}
+	for _, tt := range tests {
+		if got := pendingFor(domain.Alert{PendingFor: tt.in}); got != tt.want {
+			t.Errorf("pendingFor(%q) = %s, want %s", tt.in, got, tt.want)
+		}
+	}
+}
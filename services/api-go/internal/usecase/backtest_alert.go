@@ -0,0 +1,252 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/lightwatch/monitoring-platform/services/api-go/internal/domain"
+	"github.com/lightwatch/monitoring-platform/services/api-go/internal/observability"
+)
+
+// maxBacktestSteps caps the evaluations of one backtest, each of which
+// queries the event store: a day at the default 30s step.
+const maxBacktestSteps = 2880
+
+// backtestTimeout keeps a backtest within the API's 30s write timeout. A
+// backtest that runs out of time returns what it replayed so far.
+const backtestTimeout = 25 * time.Second
+
+// BacktestAlert replays an alert rule over historical data, to show how
+// noisy it would have been before it is enabled. It runs the alert
+// engine's own evaluation and state machine with a clock stepping through
+// the range, but with in-memory stores for states, events and
+// notifications: nothing is written or published.
+type BacktestAlert struct {
+	engine   *DetectAnomaly
+	interval time.Duration
+}
+
+// NewBacktestAlert creates a new BacktestAlert use case. interval is the
+// engine's evaluation interval, the default step.
+func NewBacktestAlert(engine *DetectAnomaly, interval time.Duration) *BacktestAlert {
+	return &BacktestAlert{engine: engine, interval: interval}
+}
+
+// Run evaluates req.Rule at every step from req.From to req.To. Silences
+// are those that exist now and escalation policies are not replayed;
+// heartbeat rules cannot be backtested, as only the latest heartbeat of
+// each service is kept. Past backtestTimeout the result covers the range
+// up to the last step evaluated, and is marked as timed out.
+func (uc *BacktestAlert) Run(ctx context.Context, req domain.BacktestRequest) (*domain.BacktestResult, error) {
+	rule := req.Rule
+	if rule.Type == "" {
+		rule.Type = domain.AlertTypeThreshold
+	}
+	if err := validateAlert(&rule); err != nil {
+		var ve *domain.ValidationError
+		if errors.As(err, &ve) {
+			ve.Field = "rule." + ve.Field
+		}
+		return nil, err
+	}
+	if rule.Type == domain.AlertTypeHeartbeat {
+		return nil, invalid("rule.type", "heartbeat rules cannot be backtested")
+	}
+
+	from, to := req.From, req.To
+	if from.IsZero() {
+		return nil, invalid("from", "is required")
+	}
+	if now := time.Now(); to.IsZero() || to.After(now) {
+		to = now
+	}
+	if !to.After(from) {
+		return nil, invalid("to", "must be after from")
+	}
+	step := max(uc.interval, evaluationInterval(rule))
+	if req.Step != "" {
+		if err := validDuration("step", req.Step); err != nil {
+			return nil, err
+		}
+		step, _ = parseDuration(req.Step)
+	}
+	if to.Sub(from)/step >= maxBacktestSteps {
+		return nil, invalid("step", fmt.Sprintf("the range needs more than %d evaluations; use a larger step or a shorter range", maxBacktestSteps))
+	}
+
+	// The engine reads the same repositories at the replay time, and
+	// writes to the run instead
+	run := newBacktestRun()
+	var at time.Time
+	engine := *uc.engine
+	engine.clock = func() time.Time { return at }
+	engine.baselines = newBaselineCache()
+	engine.states = backtestStates{run}
+	engine.alertEvents = backtestEvents{run: run}
+	engine.notifications = backtestNotifications{run: run}
+	engine.fence = nil
+	engine.publisher = nil
+	engine.logger = observability.Discard()
+	rule.EscalationPolicy = ""
+	if rule.ID == "" {
+		rule.ID = "backtest" // keys per-key states as for a stored rule
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, backtestTimeout)
+	defer cancel()
+
+	res := &domain.BacktestResult{
+		From: from.UTC(),
+		To:   to.UTC(),
+		Step: step.String(),
+	}
+	last := from
+	for at = from; !at.After(to); at = at.Add(step) {
+		err := ctx.Err()
+		if err == nil {
+			err = engine.evaluate(ctx, rule)
+		}
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			res.To = last.UTC()
+			res.TimedOut = true
+			break
+		}
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, err
+			}
+			return nil, fmt.Errorf("evaluate at %s: %w", at.UTC().Format(time.RFC3339), err)
+		}
+		res.Evaluations++
+		last = at
+	}
+
+	res.Episodes = run.episodes
+	sort.SliceStable(res.Episodes, func(i, j int) bool {
+		return res.Episodes[i].FiredAt.Before(res.Episodes[j].FiredAt)
+	})
+	res.Firings = len(res.Episodes)
+	res.Notifications = run.notifications
+	return res, nil
+}
+
+// backtestRun holds what the engine writes during one backtest. Each
+// alert event it creates is an episode; its ID is the episode's index.
+type backtestRun struct {
+	states        map[string]domain.AlertState
+	events        []domain.AlertEvent
+	episodes      []domain.BacktestEpisode
+	notifications int
+}
+
+func newBacktestRun() *backtestRun {
+	return &backtestRun{
+		states:   make(map[string]domain.AlertState),
+		episodes: []domain.BacktestEpisode{},
+	}
+}
+
+// episode returns the index of the event with the given ID.
+func (r *backtestRun) episode(id string) (int, error) {
+	i, err := strconv.Atoi(id)
+	if err != nil || i < 0 || i >= len(r.events) {
+		return 0, domain.ErrNotFound
+	}
+	return i, nil
+}
+
+// backtestStates implements domain.AlertStatesRepository for a backtest.
+type backtestStates struct {
+	run *backtestRun
+}
+
+func (s backtestStates) Find(_ context.Context, alertID string) (*domain.AlertState, error) {
+	st, ok := s.run.states[alertID]
+	if !ok {
+		return nil, nil
+	}
+	return &st, nil
+}
+
+func (s backtestStates) FindByAlert(_ context.Context, alertID string) ([]domain.AlertState, error) {
+	var states []domain.AlertState
+	for _, st := range s.run.states {
+		if st.ID == alertID || st.AlertID == alertID {
+			states = append(states, st)
+		}
+	}
+	return states, nil
+}
+
+// Save also labels the state's episode with the series or key it fired
+// for, as the event's labels include those of the rule.
+func (s backtestStates) Save(_ context.Context, st *domain.AlertState) error {
+	s.run.states[st.ID] = *st
+	if i, err := s.run.episode(st.EventID); err == nil {
+		s.run.episodes[i].Labels = st.Labels
+	}
+	return nil
+}
+
+func (s backtestStates) Delete(_ context.Context, id string, _ int64) error {
+	delete(s.run.states, id)
+	return nil
+}
+
+// backtestEvents implements the part of domain.AlertEventsRepository the
+// engine uses, recording an episode for every event; the other methods
+// are not implemented.
+type backtestEvents struct {
+	domain.AlertEventsRepository
+	run *backtestRun
+}
+
+func (e backtestEvents) Create(_ context.Context, evt *domain.AlertEvent) (string, error) {
+	evt.ID = strconv.Itoa(len(e.run.events))
+	e.run.events = append(e.run.events, *evt)
+	e.run.episodes = append(e.run.episodes, domain.BacktestEpisode{
+		FiredAt:   evt.TriggeredAt.UTC(),
+		Value:     evt.Value,
+		Threshold: evt.Threshold,
+		Silenced:  len(evt.SilencedBy) > 0,
+	})
+	return evt.ID, nil
+}
+
+func (e backtestEvents) FindByID(_ context.Context, id string) (*domain.AlertEvent, error) {
+	i, err := e.run.episode(id)
+	if err != nil {
+		return nil, err
+	}
+	evt := e.run.events[i]
+	return &evt, nil
+}
+
+func (e backtestEvents) Resolve(_ context.Context, id string, resolvedAt time.Time) (*domain.AlertEvent, error) {
+	i, err := e.run.episode(id)
+	if err != nil {
+		return nil, err
+	}
+	resolved := resolvedAt.UTC()
+	e.run.events[i].Status = domain.AlertStateResolved
+	e.run.events[i].ResolvedAt = &resolved
+	e.run.episodes[i].ResolvedAt = &resolved
+	evt := e.run.events[i]
+	return &evt, nil
+}
+
+// backtestNotifications counts the notifications the engine queues; the
+// other methods of domain.NotificationsRepository are not implemented.
+type backtestNotifications struct {
+	domain.NotificationsRepository
+	run *backtestRun
+}
+
+func (n backtestNotifications) Create(context.Context, *domain.Notification) (string, error) {
+	n.run.notifications++
+	return strconv.Itoa(n.run.notifications), nil
+}
@@ -0,0 +1,228 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/lightwatch/monitoring-platform/services/api-go/internal/domain"
+	"github.com/lightwatch/monitoring-platform/services/api-go/internal/observability"
+)
+
+// memMetrics serves metric queries from a slice, as the Mongo repository
+// would.
+type memMetrics struct {
+	events []domain.MetricEvent
+}
+
+func (m *memMetrics) query(f domain.MetricsFilter) []domain.MetricEvent {
+	from, _ := time.Parse(time.RFC3339, f.From)
+	to, _ := time.Parse(time.RFC3339, f.To)
+	matchers, _ := compileMatchers(f.Matchers)
+	var out []domain.MetricEvent
+	for _, e := range m.events {
+		if (f.Service != "" && e.Service != f.Service) || (f.Name != "" && e.Name != f.Name) {
+			continue
+		}
+		if e.Timestamp.Before(from) || e.Timestamp.After(to) || !matchAll(matchers, metricLabels(e)) {
+			continue
+		}
+		out = append(out, e)
+	}
+	sort.SliceStable(out, func(i, j int) bool {
+		if f.Sort == "asc" {
+			return out[i].Timestamp.Before(out[j].Timestamp)
+		}
+		return out[i].Timestamp.After(out[j].Timestamp)
+	})
+	return out
+}
+
+func (m *memMetrics) Find(_ context.Context, f domain.MetricsFilter) ([]domain.MetricEvent, int64, error) {
+	out := m.query(f)
+	total := int64(len(out))
+	if skip := (max(f.Page, 1) - 1) * f.Limit; skip < len(out) {
+		out = out[skip:]
+	} else {
+		out = nil
+	}
+	if f.Limit > 0 && len(out) > f.Limit {
+		out = out[:f.Limit]
+	}
+	return out, total, nil
+}
+
+func (m *memMetrics) Scan(_ context.Context, f domain.MetricsFilter) ([]domain.MetricEvent, bool, error) {
+	out := m.query(f)
+	if f.Limit > 0 && len(out) > f.Limit {
+		return out[:f.Limit], true, nil
+	}
+	return out, false, nil
+}
+
+type noSilences struct {
+	domain.SilencesRepository
+}
+
+func (noSilences) FindCurrent(context.Context, time.Time) ([]domain.Silence, error) {
+	return nil, nil
+}
+
+func newBacktest(metrics domain.MetricsRepository) *BacktestAlert {
+	engine := NewDetectAnomaly(nil, nil, nil, metrics, nil, nil, nil, noSilences{}, nil, nil, nil, nil, nil, observability.Discard())
+	return NewBacktestAlert(engine, time.Minute)
+}
+
+// samples returns one sample of metric per minute for each host from
+// start, valued by value(host, minute).
+func samples(metric string, start time.Time, minutes int, hosts []string, value func(host string, minute int) float64) []domain.MetricEvent {
+	var events []domain.MetricEvent
+	for i := 0; i < minutes; i++ {
+		for _, h := range hosts {
+			events = append(events, domain.MetricEvent{
+				ID:        fmt.Sprintf("%s-%s-%d", metric, h, i),
+				Service:   "api",
+				Name:      metric,
+				Value:     value(h, i),
+				Tags:      map[string]string{"host": h},
+				Timestamp: start.Add(time.Duration(i) * time.Minute),
+			})
+		}
+	}
+	return events
+}
+
+func TestBacktestSharesEngineStateMachine(t *testing.T) {
+	start := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+	// srv-1 breaches from minute 10 to 19, srv-2 from minute 30 to the end
+	breach := func(h string, i int) float64 {
+		if (h == "srv-1" && i >= 10 && i < 20) || (h == "srv-2" && i >= 30) {
+			return 95
+		}
+		return 40
+	}
+	metrics := &memMetrics{events: append(
+		samples("cpu", start, 60, []string{"srv-1", "srv-2"}, breach),
+		samples("load", start, 60, []string{"srv-1"}, breach)...,
+	)}
+	targets := []domain.NotificationTarget{{Type: domain.ChannelSlack, URL: "https://hooks.slack.example/1"}}
+
+	tests := []struct {
+		name          string
+		rule          domain.Alert
+		wantEpisodes  int
+		wantResolved  int
+		wantNotified  int
+		wantLabelHost []string
+	}{
+		{
+			name: "rule-wide",
+			rule: domain.Alert{
+				Name: "Load", Service: "api", Targets: targets,
+				Condition: domain.AlertCondition{Metric: "load", Operator: "gt", Threshold: 90},
+			},
+			wantEpisodes:  1,
+			wantResolved:  1,
+			wantNotified:  2,
+			wantLabelHost: []string{""},
+		},
+		{
+			name: "per series",
+			rule: domain.Alert{
+				Name: "CPU", Targets: targets,
+				Selector:  []domain.Matcher{{Label: "service", Value: "api"}},
+				Condition: domain.AlertCondition{Metric: "cpu", Operator: "gt", Threshold: 90},
+			},
+			wantEpisodes:  2,
+			wantResolved:  1,
+			wantNotified:  3, // two firings and one resolve
+			wantLabelHost: []string{"srv-1", "srv-2"},
+		},
+		{
+			name: "per series, pending",
+			rule: domain.Alert{
+				Name: "CPU", Targets: targets, PendingFor: "5m",
+				Selector:  []domain.Matcher{{Label: "service", Value: "api"}},
+				Condition: domain.AlertCondition{Metric: "cpu", Operator: "gt", Threshold: 90},
+			},
+			wantEpisodes:  2,
+			wantResolved:  1,
+			wantNotified:  3,
+			wantLabelHost: []string{"srv-1", "srv-2"},
+		},
+		{
+			name: "per series, grouped repeats",
+			rule: domain.Alert{
+				Name: "CPU", Targets: targets,
+				Selector:  []domain.Matcher{{Label: "service", Value: "api"}},
+				Grouping:  &domain.AlertGrouping{RepeatInterval: "10m"},
+				Condition: domain.AlertCondition{Metric: "cpu", Operator: "gt", Threshold: 90},
+			},
+			wantEpisodes:  2,
+			wantResolved:  1,
+			wantNotified:  5, // srv-2 repeats at minutes 40 and 50
+			wantLabelHost: []string{"srv-1", "srv-2"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res, err := newBacktest(metrics).Run(context.Background(), domain.BacktestRequest{
+				Rule: tt.rule,
+				From: start,
+				To:   start.Add(59 * time.Minute),
+				Step: "1m",
+			})
+			if err != nil {
+				t.Fatalf("Run: %v", err)
+			}
+			if res.Evaluations != 60 || res.TimedOut {
+				t.Errorf("evaluations = %d, timed out = %v", res.Evaluations, res.TimedOut)
+			}
+			if res.Firings != tt.wantEpisodes {
+				t.Fatalf("firings = %d, want %d: %+v", res.Firings, tt.wantEpisodes, res.Episodes)
+			}
+			var resolved int
+			for i, ep := range res.Episodes {
+				if ep.ResolvedAt != nil {
+					resolved++
+				}
+				if got := ep.Labels["host"]; got != tt.wantLabelHost[i] {
+					t.Errorf("episode %d host = %q, want %q", i, got, tt.wantLabelHost[i])
+				}
+				if _, ok := ep.Labels["alertname"]; ok {
+					t.Errorf("episode %d labels %v include the rule's labels", i, ep.Labels)
+				}
+			}
+			if resolved != tt.wantResolved {
+				t.Errorf("resolved episodes = %d, want %d", resolved, tt.wantResolved)
+			}
+			if res.Notifications != tt.wantNotified {
+				t.Errorf("notifications = %d, want %d", res.Notifications, tt.wantNotified)
+			}
+		})
+	}
+}
+
+func TestBacktestTimesOut(t *testing.T) {
+	start := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+	uc := newBacktest(&memMetrics{})
+	ctx, cancel := context.WithTimeout(context.Background(), -time.Second)
+	defer cancel()
+
+	res, err := uc.Run(ctx, domain.BacktestRequest{
+		Rule: domain.Alert{
+			Name: "CPU", Service: "api",
+			Condition: domain.AlertCondition{Metric: "cpu", Operator: "gt", Threshold: 90},
+		},
+		From: start,
+		To:   start.Add(time.Hour),
+	})
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if !res.TimedOut || res.Evaluations != 0 || !res.To.Equal(start) {
+		t.Errorf("timed out = %v, evaluations = %d, to = %s; want a partial result", res.TimedOut, res.Evaluations, res.To)
+	}
+}
@@ -0,0 +1,227 @@
+package usecase
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/lightwatch/monitoring-platform/services/api-go/internal/domain"
+)
+
+// baselineRefresh is how long a computed baseline is reused before it is
+// rebuilt from metric history.
+const baselineRefresh = 5 * time.Minute
+
+// madScale converts a median absolute deviation into a standard deviation
+// estimate for normally distributed data.
+const madScale = 1.4826
+
+// A baseline's scale is at least minRelativeScale of its center, and at
+// least minAbsoluteScale, so a flat or mostly constant history (where even
+// MAD is 0) does not score tiny changes as huge deviations.
+const (
+	minRelativeScale = 0.01
+	minAbsoluteScale = 0.01
+)
+
+// baseline summarises the normal behaviour of one series.
+type baseline struct {
+	center  float64 // mean, EWMA or median
+	scale   float64 // standard deviation or scaled MAD
+	samples int
+}
+
+// score returns how many scale units value lies from the center.
+// Positive scores are above the baseline, negative below.
+func (b baseline) score(value float64) float64 {
+	scale := math.Max(b.scale, math.Max(minRelativeScale*math.Abs(b.center), minAbsoluteScale))
+	return (value - b.center) / scale
+}
+
+// computeBaseline builds a baseline from chronologically ordered values.
+func computeBaseline(method string, alpha float64, values []float64) baseline {
+	switch method {
+	case domain.BaselineEWMA:
+		return ewmaBaseline(values, alpha)
+	case domain.BaselineMAD:
+		return madBaseline(values)
+	default:
+		return zscoreBaseline(values)
+	}
+}
+
+func zscoreBaseline(values []float64) baseline {
+	var sum float64
+	for _, v := range values {
+		sum += v
+	}
+	mean := sum / float64(len(values))
+
+	var sq float64
+	for _, v := range values {
+		sq += (v - mean) * (v - mean)
+	}
+	return baseline{
+		center:  mean,
+		scale:   math.Sqrt(sq / float64(len(values))),
+		samples: len(values),
+	}
+}
+
+func ewmaBaseline(values []float64, alpha float64) baseline {
+	mean := values[0]
+	var variance float64
+	for _, v := range values[1:] {
+		diff := v - mean
+		mean += alpha * diff
+		variance = (1 - alpha) * (variance + alpha*diff*diff)
+	}
+	return baseline{
+		center:  mean,
+		scale:   math.Sqrt(variance),
+		samples: len(values),
+	}
+}
+
+func madBaseline(values []float64) baseline {
+	med := median(values)
+	dev := make([]float64, len(values))
+	for i, v := range values {
+		dev[i] = math.Abs(v - med)
+	}
+	return baseline{
+		center:  med,
+		scale:   median(dev) * madScale,
+		samples: len(values),
+	}
+}
+
+func median(values []float64) float64 {
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+	n := len(sorted)
+	if n%2 == 1 {
+		return sorted[n/2]
+	}
+	return (sorted[n/2-1] + sorted[n/2]) / 2
+}
+
+// seriesKey identifies a series by its tag set, e.g. "host=srv-1,region=eu".
+func seriesKey(tags map[string]string) string {
+	if len(tags) == 0 {
+		return ""
+	}
+	keys := make([]string, 0, len(tags))
+	for k := range tags {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var b strings.Builder
+	for i, k := range keys {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		b.WriteString(k)
+		b.WriteByte('=')
+		b.WriteString(tags[k])
+	}
+	return b.String()
+}
+
+// ruleBaselines holds the per-series baselines computed for one rule.
+type ruleBaselines struct {
+	computedAt time.Time
+	ruleUpdate time.Time // rule.UpdatedAt at computation time
+	series     map[string]baseline
+}
+
+// baselineCache keeps rolling baselines between ticks so history is only
+// re-read every baselineRefresh.
+type baselineCache struct {
+	mu    sync.Mutex
+	rules map[string]*ruleBaselines
+}
+
+func newBaselineCache() *baselineCache {
+	return &baselineCache{rules: make(map[string]*ruleBaselines)}
+}
+
+// get returns the cached baselines for a rule if they are still fresh and
+// were computed for the current rule definition.
+func (c *baselineCache) get(rule domain.Alert, now time.Time) (map[string]baseline, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	rb, ok := c.rules[rule.ID]
+	if !ok || now.Sub(rb.computedAt) > baselineRefresh || !rb.ruleUpdate.Equal(rule.UpdatedAt) {
+		return nil, false
+	}
+	return rb.series, true
+}
+
+func (c *baselineCache) set(rule domain.Alert, now time.Time, series map[string]baseline) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.rules[rule.ID] = &ruleBaselines{
+		computedAt: now,
+		ruleUpdate: rule.UpdatedAt,
+		series:     series,
+	}
+}
+
+// prune drops baselines that have not been refreshed recently, e.g. for
+// rules that were disabled or deleted.
+func (c *baselineCache) prune(now time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for id, rb := range c.rules {
+		if now.Sub(rb.computedAt) > 4*baselineRefresh {
+			delete(c.rules, id)
+		}
+	}
+}
+
+// aggregate reduces values with one of the domain.Aggregate* functions.
+// values must be non-empty and newest first; "last" returns values[0].
+func aggregate(values []float64, fn string) float64 {
+	switch fn {
+	case domain.AggregateAvg:
+		var sum float64
+		for _, v := range values {
+			sum += v
+		}
+		return sum / float64(len(values))
+	case domain.AggregateMin:
+		m := values[0]
+		for _, v := range values[1:] {
+			m = math.Min(m, v)
+		}
+		return m
+	case domain.AggregateMax:
+		m := values[0]
+		for _, v := range values[1:] {
+			m = math.Max(m, v)
+		}
+		return m
+	case domain.AggregateP95:
+		return percentile(values, 95)
+	default:
+		return values[0]
+	}
+}
+
+// percentile returns the p-th percentile using nearest-rank.
+func percentile(values []float64, p float64) float64 {
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+	rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
@@ -0,0 +1,131 @@
+package usecase
+
+import (
+	"math"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/lightwatch/monitoring-platform/services/api-go/internal/domain"
+)
+
+func TestComputeBaseline(t *testing.T) {
+	tests := []struct {
+		name       string
+		method     string
+		alpha      float64
+		values     []float64
+		wantCenter float64
+		wantScale  float64
+	}{
+		{name: "zscore", method: domain.BaselineZScore, values: []float64{2, 4, 4, 4, 5, 5, 7, 9}, wantCenter: 5, wantScale: 2},
+		{name: "unknown method is zscore", method: "", values: []float64{2, 4, 4, 4, 5, 5, 7, 9}, wantCenter: 5, wantScale: 2},
+		{name: "zscore flat", method: domain.BaselineZScore, values: []float64{3, 3, 3}, wantCenter: 3, wantScale: 0},
+		{name: "ewma single value", method: domain.BaselineEWMA, alpha: 0.5, values: []float64{7}, wantCenter: 7, wantScale: 0},
+		{name: "ewma step", method: domain.BaselineEWMA, alpha: 0.5, values: []float64{0, 10}, wantCenter: 5, wantScale: 5},
+		{name: "ewma follows recent values", method: domain.BaselineEWMA, alpha: 0.5, values: []float64{0, 10, 10}, wantCenter: 7.5, wantScale: math.Sqrt(18.75)},
+		{name: "mad odd", method: domain.BaselineMAD, values: []float64{1, 2, 3, 4, 100}, wantCenter: 3, wantScale: madScale},
+		{name: "mad even", method: domain.BaselineMAD, values: []float64{4, 1, 3, 2}, wantCenter: 2.5, wantScale: madScale},
+		{name: "mad ignores one outlier", method: domain.BaselineMAD, values: []float64{10, 10, 10, 10, 1000}, wantCenter: 10, wantScale: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			values := append([]float64(nil), tt.values...)
+			b := computeBaseline(tt.method, tt.alpha, values)
+			if math.Abs(b.center-tt.wantCenter) > 1e-9 || math.Abs(b.scale-tt.wantScale) > 1e-9 {
+				t.Errorf("baseline = %v±%v, want %v±%v", b.center, b.scale, tt.wantCenter, tt.wantScale)
+			}
+			if b.samples != len(tt.values) {
+				t.Errorf("samples = %d, want %d", b.samples, len(tt.values))
+			}
+			if !reflect.DeepEqual(values, tt.values) {
+				t.Errorf("values reordered to %v", values)
+			}
+		})
+	}
+}
+
+func TestBaselineScore(t *testing.T) {
+	tests := []struct {
+		name  string
+		b     baseline
+		value float64
+		want  float64
+	}{
+		{name: "at center", b: baseline{center: 5, scale: 2}, value: 5, want: 0},
+		{name: "above", b: baseline{center: 5, scale: 2}, value: 9, want: 2},
+		{name: "below", b: baseline{center: 5, scale: 2}, value: 1, want: -2},
+		{name: "small scale is floored", b: baseline{center: 100, scale: 0.2}, value: 103, want: 3},
+		{name: "constant series, small deviation", b: computeBaseline(domain.BaselineZScore, 0, []float64{100, 100, 100, 100}), value: 100.5, want: 0.5},
+		{name: "constant series, large deviation", b: computeBaseline(domain.BaselineMAD, 0, []float64{100, 100, 100, 100}), value: 110, want: 10},
+		{name: "mostly constant series", b: computeBaseline(domain.BaselineMAD, 0, []float64{50, 50, 50, 50, 80}), value: 50.2, want: 0.4},
+		{name: "flat history below zero", b: baseline{center: -10}, value: -11, want: -10},
+		{name: "flat history at zero", b: baseline{}, value: 0.05, want: 5},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.b.score(tt.value)
+			if math.Abs(got-tt.want) > 1e-9 {
+				t.Fatalf("score(%v) = %v, want %v", tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSeriesKey(t *testing.T) {
+	tests := []struct {
+		tags map[string]string
+		want string
+	}{
+		{tags: nil, want: ""},
+		{tags: map[string]string{"host": "srv-1"}, want: "host=srv-1"},
+		{tags: map[string]string{"region": "eu", "host": "srv-1"}, want: "host=srv-1,region=eu"},
+		{tags: map[string]string{"host": ""}, want: "host="},
+	}
+	for _, tt := range tests {
+		if got := seriesKey(tt.tags); got != tt.want {
+			t.Errorf("seriesKey(%v) = %q, want %q", tt.tags, got, tt.want)
+		}
+	}
+}
+
+func TestBaselineCache(t *testing.T) {
+	now := time.Date(2024, 11, 20, 12, 0, 0, 0, time.UTC)
+	rule := domain.Alert{ID: "rule-1", UpdatedAt: now.Add(-time.Hour)}
+	series := map[string]baseline{"host=srv-1": {center: 5, scale: 2, samples: 8}}
+
+	c := newBaselineCache()
+	c.set(rule, now, series)
+
+	tests := []struct {
+		name string
+		rule domain.Alert
+		at   time.Time
+		want bool
+	}{
+		{name: "fresh", rule: rule, at: now.Add(baselineRefresh), want: true},
+		{name: "stale", rule: rule, at: now.Add(baselineRefresh + time.Second)},
+		{name: "rule edited", rule: domain.Alert{ID: "rule-1", UpdatedAt: now}, at: now},
+		{name: "other rule", rule: domain.Alert{ID: "rule-2", UpdatedAt: rule.UpdatedAt}, at: now},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := c.get(tt.rule, tt.at)
+			if ok != tt.want {
+				t.Fatalf("get ok = %v, want %v", ok, tt.want)
+			}
+			if ok && !reflect.DeepEqual(got, series) {
+				t.Fatalf("get = %v, want %v", got, series)
+			}
+		})
+	}
+
+	c.prune(now.Add(4 * baselineRefresh))
+	if _, ok := c.rules[rule.ID]; !ok {
+		t.Fatal("pruned a recent baseline")
+	}
+	c.prune(now.Add(4*baselineRefresh + time.Second))
+	if _, ok := c.rules[rule.ID]; ok {
+		t.Fatal("kept an abandoned baseline")
+	}
+}
@@ -0,0 +1,385 @@
+package usecase
+
+import (
+	"context"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/lightwatch/monitoring-platform/services/api-go/internal/domain"
+	"github.com/lightwatch/monitoring-platform/services/api-go/pkg/webhook"
+)
+
+// alertFact is one labelled value shown in a chat message.
+type alertFact struct {
+	title string
+	value string
+}
+
+// chatSummary extracts what every chat format shows for a transition: a
+// title carrying the state, and the service, value, threshold and times.
+func chatSummary(p webhook.Payload) (title string, facts []alertFact) {
+	title = alertTitle(p)
+
+	facts = []alertFact{
+		{"Service", p.Service},
+		{"Value", formatValue(p.Value)},
+		{"Threshold", formatValue(p.Threshold)},
+		{"Triggered", p.TriggeredAt.UTC().Format(time.RFC3339)},
+	}
+	if p.ResolvedAt != nil {
+		facts = append(facts, alertFact{"Resolved", p.ResolvedAt.UTC().Format(time.RFC3339)})
+	}
+	if labels := seriesLabels(p.Labels); labels != "" {
+		facts = append(facts, alertFact{"Labels", labels})
+	}
+	return title, facts
+}
+
+func alertTitle(p webhook.Payload) string {
+	return "[" + strings.ToUpper(p.Status) + "] " + p.AlertName
+}
+
+// maxGroupLines caps the alerts listed in one group message, since chat
+// services limit message size; the rest are only counted.
+const maxGroupLines = 20
+
+// groupLines returns the alerts of a group message to list, and how many
+// more are left out.
+func groupLines(ps []webhook.Payload) ([]webhook.Payload, int) {
+	if len(ps) > maxGroupLines {
+		return ps[:maxGroupLines], len(ps) - maxGroupLines
+	}
+	return ps, 0
+}
+
+// groupLine describes one alert of a group message.
+func groupLine(p webhook.Payload) string {
+	line := p.Service + ": value " + formatValue(p.Value) + ", threshold " + formatValue(p.Threshold)
+	if labels := seriesLabels(p.Labels); labels != "" {
+		line += " (" + labels + ")"
+	}
+	return line
+}
+
+func moreAlerts(n int) string {
+	return "…and " + strconv.Itoa(n) + " more"
+}
+
+// seriesLabels lists labels other than the ones already shown as facts.
+func seriesLabels(labels map[string]string) string {
+	var parts []string
+	for k, v := range labels {
+		if k == "alertname" || k == "service" {
+			continue
+		}
+		parts = append(parts, k+"="+v)
+	}
+	sort.Strings(parts)
+	return strings.Join(parts, ", ")
+}
+
+func formatValue(v float64) string {
+	return strconv.FormatFloat(math.Round(v*100)/100, 'f', -1, 64)
+}
+
+func firing(p webhook.Payload) bool {
+	return p.Status == domain.AlertStateFiring
+}
+
+// ── Slack ──
+
+// slackEscaper escapes the control characters of Slack's mrkdwn.
+var slackEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")
+
+// SlackNotifier posts Block Kit messages to Slack incoming webhooks, or the
+// target's body template when it has one.
+type SlackNotifier struct {
+	client *http.Client
+}
+
+// NewSlackNotifier creates the Slack channel.
+func NewSlackNotifier(client *http.Client) *SlackNotifier {
+	return &SlackNotifier{client: client}
+}
+
+// Notify implements Notifier.
+func (sn *SlackNotifier) Notify(ctx context.Context, n *domain.Notification, p webhook.Payload) error {
+	if n.Template != "" {
+		return postTemplate(ctx, sn.client, n, p)
+	}
+	title, facts := chatSummary(p)
+	emoji := ":white_check_mark:"
+	if firing(p) {
+		emoji = ":rotating_light:"
+	}
+
+	fields := make([]interface{}, 0, len(facts))
+	for _, f := range facts {
+		fields = append(fields, map[string]interface{}{
+			"type": "mrkdwn",
+			"text": "*" + f.title + "*\n" + slackEscaper.Replace(f.value),
+		})
+	}
+	blocks := []interface{}{
+		map[string]interface{}{
+			"type": "header",
+			"text": map[string]interface{}{"type": "plain_text", "text": emoji + " " + title, "emoji": true},
+		},
+		map[string]interface{}{"type": "section", "fields": fields},
+	}
+	if p.Link != "" {
+		blocks = append(blocks, map[string]interface{}{
+			"type": "actions",
+			"elements": []interface{}{map[string]interface{}{
+				"type": "button",
+				"text": map[string]interface{}{"type": "plain_text", "text": "View event"},
+				"url":  p.Link,
+			}},
+		})
+	}
+
+	return marshalAndPost(ctx, sn.client, n.Target, map[string]interface{}{
+		"text":   slackEscaper.Replace(title), // shown in notifications and clients without Block Kit
+		"blocks": blocks,
+	})
+}
+
+// NotifyBatch implements GroupNotifier with one section per alert.
+func (sn *SlackNotifier) NotifyBatch(ctx context.Context, ns []*domain.Notification, ps []webhook.Payload) error {
+	if n := ns[0]; n.Template != "" && n.Group != nil {
+		return postTemplate(ctx, sn.client, n, groupPayload(n.Group, latestPerEvent(ns, ps)))
+	}
+	ps = latestPerEvent(ns, ps)
+	title := groupTitle(ns[0].Group, ps)
+	lines, more := groupLines(ps)
+	emoji := ":white_check_mark:"
+	if openAlerts(ps) > 0 {
+		emoji = ":rotating_light:"
+	}
+
+	blocks := []interface{}{
+		map[string]interface{}{
+			"type": "header",
+			"text": map[string]interface{}{"type": "plain_text", "text": emoji + " " + title, "emoji": true},
+		},
+	}
+	for _, p := range lines {
+		text := "*" + slackEscaper.Replace(alertTitle(p)) + "*\n" + slackEscaper.Replace(groupLine(p))
+		if p.Link != "" {
+			text += "\n<" + p.Link + "|View event>"
+		}
+		blocks = append(blocks, map[string]interface{}{
+			"type": "section",
+			"text": map[string]interface{}{"type": "mrkdwn", "text": text},
+		})
+	}
+	if more > 0 {
+		blocks = append(blocks, map[string]interface{}{
+			"type":     "context",
+			"elements": []interface{}{map[string]interface{}{"type": "plain_text", "text": moreAlerts(more)}},
+		})
+	}
+
+	return marshalAndPost(ctx, sn.client, ns[0].Target, map[string]interface{}{
+		"text":   slackEscaper.Replace(title),
+		"blocks": blocks,
+	})
+}
+
+// ── Microsoft Teams ──
+
+// TeamsNotifier posts Adaptive Cards to Microsoft Teams incoming webhooks
+// (including Workflows webhooks, which accept the same message envelope),
+// or the target's body template when it has one.
+type TeamsNotifier struct {
+	client *http.Client
+}
+
+// NewTeamsNotifier creates the Microsoft Teams channel.
+func NewTeamsNotifier(client *http.Client) *TeamsNotifier {
+	return &TeamsNotifier{client: client}
+}
+
+// Notify implements Notifier.
+func (tn *TeamsNotifier) Notify(ctx context.Context, n *domain.Notification, p webhook.Payload) error {
+	if n.Template != "" {
+		return postTemplate(ctx, tn.client, n, p)
+	}
+	title, facts := chatSummary(p)
+	color := "Good"
+	if firing(p) {
+		color = "Attention"
+	}
+
+	factSet := make([]interface{}, 0, len(facts))
+	for _, f := range facts {
+		factSet = append(factSet, map[string]interface{}{"title": f.title, "value": f.value})
+	}
+	card := map[string]interface{}{
+		"$schema": "http://adaptivecards.io/schemas/adaptive-card.json",
+		"type":    "AdaptiveCard",
+		"version": "1.4",
+		"body": []interface{}{
+			map[string]interface{}{
+				"type":   "TextBlock",
+				"text":   title,
+				"size":   "Large",
+				"weight": "Bolder",
+				"color":  color,
+				"wrap":   true,
+			},
+			map[string]interface{}{"type": "FactSet", "facts": factSet},
+		},
+	}
+	if p.Link != "" {
+		card["actions"] = []interface{}{map[string]interface{}{
+			"type":  "Action.OpenUrl",
+			"title": "View event",
+			"url":   p.Link,
+		}}
+	}
+
+	return marshalAndPost(ctx, tn.client, n.Target, map[string]interface{}{
+		"type": "message",
+		"attachments": []interface{}{map[string]interface{}{
+			"contentType": "application/vnd.microsoft.card.adaptive",
+			"content":     card,
+		}},
+	})
+}
+
+// NotifyBatch implements GroupNotifier with one fact per alert.
+func (tn *TeamsNotifier) NotifyBatch(ctx context.Context, ns []*domain.Notification, ps []webhook.Payload) error {
+	if n := ns[0]; n.Template != "" && n.Group != nil {
+		return postTemplate(ctx, tn.client, n, groupPayload(n.Group, latestPerEvent(ns, ps)))
+	}
+	ps = latestPerEvent(ns, ps)
+	title := groupTitle(ns[0].Group, ps)
+	lines, more := groupLines(ps)
+	color := "Good"
+	if openAlerts(ps) > 0 {
+		color = "Attention"
+	}
+
+	factSet := make([]interface{}, 0, len(lines))
+	for _, p := range lines {
+		value := groupLine(p)
+		if p.Link != "" {
+			value += " [View event](" + p.Link + ")"
+		}
+		factSet = append(factSet, map[string]interface{}{"title": alertTitle(p), "value": value})
+	}
+	body := []interface{}{
+		map[string]interface{}{
+			"type":   "TextBlock",
+			"text":   title,
+			"size":   "Large",
+			"weight": "Bolder",
+			"color":  color,
+			"wrap":   true,
+		},
+		map[string]interface{}{"type": "FactSet", "facts": factSet},
+	}
+	if more > 0 {
+		body = append(body, map[string]interface{}{"type": "TextBlock", "text": moreAlerts(more), "isSubtle": true})
+	}
+
+	return marshalAndPost(ctx, tn.client, ns[0].Target, map[string]interface{}{
+		"type": "message",
+		"attachments": []interface{}{map[string]interface{}{
+			"contentType": "application/vnd.microsoft.card.adaptive",
+			"content": map[string]interface{}{
+				"$schema": "http://adaptivecards.io/schemas/adaptive-card.json",
+				"type":    "AdaptiveCard",
+				"version": "1.4",
+				"body":    body,
+			},
+		}},
+	})
+}
+
+// ── Mattermost ──
+
+// MattermostNotifier posts messages with a colored attachment to
+// Mattermost incoming webhooks. Rocket.Chat and other receivers that accept
+// Slack's legacy attachment format work too. A target's body template
+// replaces the message.
+type MattermostNotifier struct {
+	client *http.Client
+}
+
+// NewMattermostNotifier creates the Mattermost channel.
+func NewMattermostNotifier(client *http.Client) *MattermostNotifier {
+	return &MattermostNotifier{client: client}
+}
+
+// Notify implements Notifier.
+func (mn *MattermostNotifier) Notify(ctx context.Context, n *domain.Notification, p webhook.Payload) error {
+	if n.Template != "" {
+		return postTemplate(ctx, mn.client, n, p)
+	}
+	title, facts := chatSummary(p)
+	color := "#2eb886"
+	if firing(p) {
+		color = "#d00000"
+	}
+
+	fields := make([]interface{}, 0, len(facts))
+	for _, f := range facts {
+		fields = append(fields, map[string]interface{}{"title": f.title, "value": f.value, "short": true})
+	}
+	attachment := map[string]interface{}{
+		"fallback": title,
+		"color":    color,
+		"title":    title,
+		"fields":   fields,
+	}
+	if p.Link != "" {
+		attachment["title_link"] = p.Link
+	}
+
+	return marshalAndPost(ctx, mn.client, n.Target, map[string]interface{}{
+		"attachments": []interface{}{attachment},
+	})
+}
+
+// NotifyBatch implements GroupNotifier with one attachment per alert.
+func (mn *MattermostNotifier) NotifyBatch(ctx context.Context, ns []*domain.Notification, ps []webhook.Payload) error {
+	if n := ns[0]; n.Template != "" && n.Group != nil {
+		return postTemplate(ctx, mn.client, n, groupPayload(n.Group, latestPerEvent(ns, ps)))
+	}
+	ps = latestPerEvent(ns, ps)
+	title := groupTitle(ns[0].Group, ps)
+	lines, more := groupLines(ps)
+
+	attachments := make([]interface{}, 0, len(lines))
+	for _, p := range lines {
+		color := "#2eb886"
+		if p.Status != domain.AlertStateResolved {
+			color = "#d00000"
+		}
+		attachment := map[string]interface{}{
+			"fallback": alertTitle(p),
+			"color":    color,
+			"title":    alertTitle(p),
+			"text":     groupLine(p),
+		}
+		if p.Link != "" {
+			attachment["title_link"] = p.Link
+		}
+		attachments = append(attachments, attachment)
+	}
+	text := "**" + title + "**"
+	if more > 0 {
+		text += "\n" + moreAlerts(more)
+	}
+
+	return marshalAndPost(ctx, mn.client, ns[0].Target, map[string]interface{}{
+		"text":        text,
+		"attachments": attachments,
+	})
+}
@@ -0,0 +1,258 @@
+package usecase
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/lightwatch/monitoring-platform/services/api-go/internal/domain"
+	"github.com/lightwatch/monitoring-platform/services/api-go/internal/observability"
+	"github.com/lightwatch/monitoring-platform/services/api-go/pkg/webhook"
+)
+
+// receiver is a local stand-in for an incoming webhook. It records the
+// requests it gets and answers with status.
+type receiver struct {
+	*httptest.Server
+	mu       sync.Mutex
+	status   int
+	requests []recorded
+}
+
+type recorded struct {
+	method string
+	path   string
+	query  string
+	header http.Header
+	body   []byte
+}
+
+func newReceiver(t *testing.T, status int) *receiver {
+	t.Helper()
+	rc := &receiver{status: status}
+	rc.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		rc.mu.Lock()
+		rc.requests = append(rc.requests, recorded{method: r.Method, path: r.URL.Path, query: r.URL.RawQuery, header: r.Header.Clone(), body: body})
+		rc.mu.Unlock()
+		w.WriteHeader(rc.status)
+	}))
+	t.Cleanup(rc.Close)
+	return rc
+}
+
+// last decodes the body of the latest request.
+func (rc *receiver) last(t *testing.T) map[string]interface{} {
+	t.Helper()
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	if len(rc.requests) == 0 {
+		t.Fatal("receiver got no request")
+	}
+	var msg map[string]interface{}
+	if err := json.Unmarshal(rc.requests[len(rc.requests)-1].body, &msg); err != nil {
+		t.Fatalf("request body is not JSON: %v", err)
+	}
+	return msg
+}
+
+// path walks a decoded JSON document, e.g. path(msg, "attachments", 0, "color").
+func path(t *testing.T, doc interface{}, keys ...interface{}) interface{} {
+	t.Helper()
+	for _, k := range keys {
+		switch k := k.(type) {
+		case string:
+			m, ok := doc.(map[string]interface{})
+			if !ok {
+				t.Fatalf("%v: not an object at %q", keys, k)
+			}
+			doc = m[k]
+		case int:
+			a, ok := doc.([]interface{})
+			if !ok || k >= len(a) {
+				t.Fatalf("%v: no element %d", keys, k)
+			}
+			doc = a[k]
+		}
+	}
+	return doc
+}
+
+func testPayload(status string) webhook.Payload {
+	p := webhook.Payload{
+		Version:     webhook.PayloadVersion,
+		EventID:     "evt-1",
+		Status:      status,
+		AlertID:     "rule-1",
+		AlertName:   "High CPU",
+		Service:     "api-gateway",
+		Value:       97.456,
+		Threshold:   90,
+		Labels:      map[string]string{"alertname": "High CPU", "service": "api-gateway", "host": "srv-1"},
+		TriggeredAt: time.Date(2026, 10, 17, 9, 0, 0, 0, time.UTC),
+		Link:        "http://localhost:3003/api/alerts/rule-1/events/evt-1",
+	}
+	if status == domain.AlertStateResolved {
+		resolved := p.TriggeredAt.Add(10 * time.Minute)
+		p.ResolvedAt = &resolved
+	}
+	return p
+}
+
+func TestChatNotifiers(t *testing.T) {
+	client := &http.Client{Timeout: 5 * time.Second}
+	tests := []struct {
+		name     string
+		notifier Notifier
+		check    func(t *testing.T, msg map[string]interface{}, firing bool)
+	}{
+		{
+			name:     "slack",
+			notifier: NewSlackNotifier(client),
+			check: func(t *testing.T, msg map[string]interface{}, firing bool) {
+				header := path(t, msg, "blocks", 0, "text", "text").(string)
+				want := ":white_check_mark: [RESOLVED] High CPU"
+				if firing {
+					want = ":rotating_light: [FIRING] High CPU"
+				}
+				if header != want {
+					t.Errorf("header = %q, want %q", header, want)
+				}
+				if text := path(t, msg, "text"); !strings.Contains(text.(string), "High CPU") {
+					t.Errorf("fallback text = %q", text)
+				}
+				fields := path(t, msg, "blocks", 1, "fields").([]interface{})
+				if got := path(t, fields[1], "text"); got != "*Value*\n97.46" {
+					t.Errorf("value field = %q", got)
+				}
+				if got := path(t, msg, "blocks", 2, "elements", 0, "url"); got != "http://localhost:3003/api/alerts/rule-1/events/evt-1" {
+					t.Errorf("button url = %v", got)
+				}
+				if resolved := len(fields) > 4 && strings.HasPrefix(path(t, fields[4], "text").(string), "*Resolved*"); resolved == firing {
+					t.Errorf("resolved field present = %v, want %v", resolved, !firing)
+				}
+			},
+		},
+		{
+			name:     "teams",
+			notifier: NewTeamsNotifier(client),
+			check: func(t *testing.T, msg map[string]interface{}, firing bool) {
+				if got := path(t, msg, "type"); got != "message" {
+					t.Errorf("type = %v, want message", got)
+				}
+				if got := path(t, msg, "attachments", 0, "contentType"); got != "application/vnd.microsoft.card.adaptive" {
+					t.Errorf("contentType = %v", got)
+				}
+				card := path(t, msg, "attachments", 0, "content")
+				color := "Good"
+				if firing {
+					color = "Attention"
+				}
+				if got := path(t, card, "body", 0, "color"); got != color {
+					t.Errorf("title color = %v, want %s", got, color)
+				}
+				if got := path(t, card, "body", 1, "facts", 0, "value"); got != "api-gateway" {
+					t.Errorf("service fact = %v", got)
+				}
+				if got := path(t, card, "actions", 0, "url"); got != "http://localhost:3003/api/alerts/rule-1/events/evt-1" {
+					t.Errorf("action url = %v", got)
+				}
+			},
+		},
+		{
+			name:     "mattermost",
+			notifier: NewMattermostNotifier(client),
+			check: func(t *testing.T, msg map[string]interface{}, firing bool) {
+				color, title := "#2eb886", "[RESOLVED] High CPU"
+				if firing {
+					color, title = "#d00000", "[FIRING] High CPU"
+				}
+				if got := path(t, msg, "attachments", 0, "color"); got != color {
+					t.Errorf("color = %v, want %s", got, color)
+				}
+				if got := path(t, msg, "attachments", 0, "title"); got != title {
+					t.Errorf("title = %v, want %s", got, title)
+				}
+				if got := path(t, msg, "attachments", 0, "title_link"); got != "http://localhost:3003/api/alerts/rule-1/events/evt-1" {
+					t.Errorf("title_link = %v", got)
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		for _, status := range []string{domain.AlertStateFiring, domain.AlertStateResolved} {
+			t.Run(tt.name+"/"+status, func(t *testing.T) {
+				rc := newReceiver(t, http.StatusOK)
+				n := &domain.Notification{Channel: tt.name, Target: rc.URL}
+				if err := tt.notifier.Notify(context.Background(), n, testPayload(status)); err != nil {
+					t.Fatalf("Notify: %v", err)
+				}
+				if ct := rc.requests[0].header.Get("Content-Type"); ct != "application/json" {
+					t.Errorf("Content-Type = %q", ct)
+				}
+				tt.check(t, rc.last(t), status == domain.AlertStateFiring)
+			})
+		}
+
+		t.Run(tt.name+"/error status", func(t *testing.T) {
+			rc := newReceiver(t, http.StatusServiceUnavailable)
+			n := &domain.Notification{Channel: tt.name, Target: rc.URL}
+			err := tt.notifier.Notify(context.Background(), n, testPayload(domain.AlertStateFiring))
+			if err == nil || !strings.Contains(err.Error(), "503") {
+				t.Fatalf("Notify error = %v, want the 503 status", err)
+			}
+		})
+	}
+}
+
+// failedRepo records the outcome of deliveries; nothing else is used.
+type failedRepo struct {
+	domain.NotificationsRepository
+	attempts int
+	next     time.Time
+	lastErr  string
+	dead     bool
+}
+
+func (r *failedRepo) HasEarlierUndelivered(context.Context, *domain.Notification) (bool, error) {
+	return false, nil
+}
+
+func (r *failedRepo) MarkFailed(_ context.Context, _ string, attempts int, next time.Time, lastErr string, dead bool) error {
+	r.attempts, r.next, r.lastErr, r.dead = attempts, next, lastErr, dead
+	return nil
+}
+
+func TestChatErrorStatusIsRetried(t *testing.T) {
+	for _, channel := range []string{domain.ChannelSlack, domain.ChannelTeams, domain.ChannelMattermost} {
+		t.Run(channel, func(t *testing.T) {
+			rc := newReceiver(t, http.StatusTooManyRequests)
+			repo := &failedRepo{}
+			uc := NewDeliverNotifications(repo, 1, 8, "http://localhost:3003", observability.NewLogger("test"))
+
+			before := time.Now()
+			uc.deliver(context.Background(), &domain.Notification{
+				ID:      "n-1",
+				Channel: channel,
+				Target:  rc.URL,
+				Event:   domain.AlertEvent{ID: "evt-1", Status: domain.AlertStateFiring},
+			})
+			if repo.attempts != 1 || repo.dead {
+				t.Fatalf("attempts = %d, dead = %v; want a first failed attempt to retry", repo.attempts, repo.dead)
+			}
+			if !repo.next.After(before) {
+				t.Errorf("next attempt %s is not scheduled after the failure", repo.next)
+			}
+			if !strings.Contains(repo.lastErr, "429") {
+				t.Errorf("last error = %q, want the 429 status", repo.lastErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,141 @@
+package usecase
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// cronSchedule is a parsed five-field cron expression
+// (minute hour day-of-month month day-of-week). Each field is a bitset of
+// the values it allows.
+type cronSchedule struct {
+	minute, hour, dom, month, dow uint64
+	// As in Vixie cron, when both day fields are restricted a day matches
+	// if either does.
+	domAny, dowAny bool
+}
+
+// cronField bounds one field of a cron expression.
+type cronField struct {
+	name     string
+	min, max int
+}
+
+var cronFields = [5]cronField{
+	{"minute", 0, 59},
+	{"hour", 0, 23},
+	{"day-of-month", 1, 31},
+	{"month", 1, 12},
+	{"day-of-week", 0, 7}, // 0 and 7 are both Sunday
+}
+
+// parseCron parses expressions such as "0 2 * * 6" or "*/15 8-18 * * 1-5".
+// Each field accepts *, numbers, ranges (a-b), lists (a,b) and steps (/n).
+func parseCron(expr string) (*cronSchedule, error) {
+	parts := strings.Fields(expr)
+	if len(parts) != len(cronFields) {
+		return nil, fmt.Errorf("cron expression must have 5 fields, got %d", len(parts))
+	}
+
+	var bits [5]uint64
+	for i, part := range parts {
+		b, err := parseCronField(part, cronFields[i])
+		if err != nil {
+			return nil, err
+		}
+		bits[i] = b
+	}
+	// Fold Sunday=7 onto 0
+	if bits[4]&(1<<7) != 0 {
+		bits[4] = bits[4]&^(1<<7) | 1
+	}
+
+	return &cronSchedule{
+		minute: bits[0],
+		hour:   bits[1],
+		dom:    bits[2],
+		month:  bits[3],
+		dow:    bits[4],
+		domAny: parts[2] == "*",
+		dowAny: parts[4] == "*",
+	}, nil
+}
+
+func parseCronField(s string, f cronField) (uint64, error) {
+	var bits uint64
+	for _, item := range strings.Split(s, ",") {
+		rng, stepStr, hasStep := strings.Cut(item, "/")
+		step := 1
+		if hasStep {
+			n, err := strconv.Atoi(stepStr)
+			if err != nil || n < 1 {
+				return 0, fmt.Errorf("invalid step %q in %s field", stepStr, f.name)
+			}
+			step = n
+		}
+
+		lo, hi := f.min, f.max
+		if rng != "*" {
+			a, b, isRange := strings.Cut(rng, "-")
+			var err error
+			if lo, err = strconv.Atoi(a); err != nil {
+				return 0, fmt.Errorf("invalid value %q in %s field", a, f.name)
+			}
+			hi = lo
+			if isRange {
+				if hi, err = strconv.Atoi(b); err != nil {
+					return 0, fmt.Errorf("invalid value %q in %s field", b, f.name)
+				}
+			} else if hasStep {
+				hi = f.max // "5/15" means from 5 to the end in steps of 15
+			}
+		}
+		if lo < f.min || hi > f.max || lo > hi {
+			return 0, fmt.Errorf("%s field %q out of range %d-%d", f.name, item, f.min, f.max)
+		}
+		for v := lo; v <= hi; v += step {
+			bits |= 1 << uint(v)
+		}
+	}
+	return bits, nil
+}
+
+func (c *cronSchedule) dayMatches(t time.Time) bool {
+	domOK := c.dom&(1<<uint(t.Day())) != 0
+	dowOK := c.dow&(1<<uint(t.Weekday())) != 0
+	switch {
+	case c.domAny && c.dowAny:
+		return true
+	case c.domAny:
+		return dowOK
+	case c.dowAny:
+		return domOK
+	default:
+		return domOK || dowOK
+	}
+}
+
+// lastActivation returns the latest minute at or before t at which the
+// schedule fires, looking back no further than within. Times are
+// evaluated in t's location.
+func (c *cronSchedule) lastActivation(t time.Time, within time.Duration) (time.Time, bool) {
+	loc := t.Location()
+	earliest := t.Add(-within)
+	m := t.Truncate(time.Minute)
+	for !m.Before(earliest) {
+		y, mon, d := m.Date()
+		switch {
+		case c.month&(1<<uint(mon)) == 0 || !c.dayMatches(m):
+			m = time.Date(y, mon, d, 0, 0, 0, 0, loc).Add(-time.Minute) // previous day, 23:59
+		case c.hour&(1<<uint(m.Hour())) == 0:
+			m = time.Date(y, mon, d, m.Hour(), 0, 0, 0, loc).Add(-time.Minute)
+		case c.minute&(1<<uint(m.Minute())) == 0:
+			m = m.Add(-time.Minute)
+		default:
+			return m, true
+		}
+	}
+	return time.Time{}, false
+}
@@ -0,0 +1,130 @@
+package usecase
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lightwatch/monitoring-platform/services/api-go/internal/domain"
+)
+
+func TestParseCron(t *testing.T) {
+	tests := []struct {
+		expr    string
+		wantErr string
+	}{
+		{expr: "0 2 * * 6"},
+		{expr: "*/15 8-18 * * 1-5"},
+		{expr: "5/15 0 1,15 * *"},
+		{expr: "0 0 * * 7"},
+		{expr: "0 0 * * 0-7/7"},
+		{expr: "0 2 * *", wantErr: "must have 5 fields"},
+		{expr: "60 * * * *", wantErr: "minute field"},
+		{expr: "* 24 * * *", wantErr: "hour field"},
+		{expr: "* * 0 * *", wantErr: "day-of-month field"},
+		{expr: "* * * 13 *", wantErr: "month field"},
+		{expr: "* * * * 8", wantErr: "day-of-week field"},
+		{expr: "5-1 * * * *", wantErr: "out of range"},
+		{expr: "*/0 * * * *", wantErr: "invalid step"},
+		{expr: "a * * * *", wantErr: "invalid value"},
+		{expr: "1-b * * * *", wantErr: "invalid value"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			_, err := parseCron(tt.expr)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("parseCron: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("parseCron error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCronSundayAliases(t *testing.T) {
+	sunday := time.Date(2024, 11, 24, 12, 0, 0, 0, time.UTC)
+	for _, expr := range []string{"0 12 * * 0", "0 12 * * 7", "0 12 * * 6-7"} {
+		c, err := parseCron(expr)
+		if err != nil {
+			t.Fatalf("parseCron(%q): %v", expr, err)
+		}
+		if !c.dayMatches(sunday) {
+			t.Errorf("%q does not match Sunday", expr)
+		}
+	}
+}
+
+func TestCronLastActivation(t *testing.T) {
+	// 2024-11-23 is a Saturday
+	at := func(day, hour, min int) time.Time {
+		return time.Date(2024, 11, day, hour, min, 0, 0, time.UTC)
+	}
+	tests := []struct {
+		name   string
+		expr   string
+		now    time.Time
+		within time.Duration
+		want   time.Time // zero if none
+	}{
+		{name: "exact minute", expr: "0 2 * * 6", now: at(23, 2, 0), within: time.Hour, want: at(23, 2, 0)},
+		{name: "seconds are ignored", expr: "0 2 * * 6", now: at(23, 2, 0).Add(59 * time.Second), within: time.Hour, want: at(23, 2, 0)},
+		{name: "later the same day", expr: "0 2 * * 6", now: at(23, 3, 30), within: 2 * time.Hour, want: at(23, 2, 0)},
+		{name: "beyond the look-back", expr: "0 2 * * 6", now: at(23, 3, 30), within: time.Hour},
+		{name: "previous week", expr: "0 2 * * 6", now: at(22, 12, 0), within: 7 * 24 * time.Hour, want: at(16, 2, 0)},
+		{name: "steps within hours", expr: "*/15 8-18 * * 1-5", now: at(22, 12, 7), within: time.Hour, want: at(22, 12, 0)},
+		{name: "across the weekend", expr: "*/15 8-18 * * 1-5", now: at(25, 7, 0), within: 72 * time.Hour, want: at(22, 18, 45)},
+		{name: "day of month or week", expr: "30 1 1 * 0", now: at(30, 23, 0), within: 7 * 24 * time.Hour, want: at(24, 1, 30)},
+		{name: "month boundary", expr: "0 0 1 * *", now: at(1, 0, 30), within: time.Hour, want: at(1, 0, 0)},
+		{name: "restricted month", expr: "0 0 * 12 *", now: at(30, 23, 59), within: 31 * 24 * time.Hour},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := parseCron(tt.expr)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, ok := c.lastActivation(tt.now, tt.within)
+			if ok != !tt.want.IsZero() || !got.Equal(tt.want) {
+				t.Errorf("lastActivation(%s, %s) = %s, %v; want %s", tt.now, tt.within, got, ok, tt.want)
+			}
+		})
+	}
+}
+
+func TestInMaintenanceWindow(t *testing.T) {
+	saturday := func(hour, min int, loc *time.Location) time.Time {
+		return time.Date(2024, 11, 23, hour, min, 0, 0, loc)
+	}
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Skip("no time zone data")
+	}
+	tests := []struct {
+		name string
+		sch  domain.MaintenanceSchedule
+		now  time.Time
+		want bool
+	}{
+		{name: "start", sch: domain.MaintenanceSchedule{Cron: "0 2 * * 6", Duration: "2h"}, now: saturday(2, 0, time.UTC), want: true},
+		{name: "inside", sch: domain.MaintenanceSchedule{Cron: "0 2 * * 6", Duration: "2h"}, now: saturday(3, 59, time.UTC), want: true},
+		{name: "end is exclusive", sch: domain.MaintenanceSchedule{Cron: "0 2 * * 6", Duration: "2h"}, now: saturday(4, 0, time.UTC)},
+		{name: "before", sch: domain.MaintenanceSchedule{Cron: "0 2 * * 6", Duration: "2h"}, now: saturday(1, 59, time.UTC)},
+		{name: "spans midnight", sch: domain.MaintenanceSchedule{Cron: "0 23 * * 5", Duration: "3h"}, now: saturday(1, 30, time.UTC), want: true},
+		{name: "time zone", sch: domain.MaintenanceSchedule{Cron: "0 2 * * 6", Duration: "1h", Timezone: "Europe/Berlin"}, now: saturday(1, 30, time.UTC), want: true},
+		{name: "time zone, UTC hours", sch: domain.MaintenanceSchedule{Cron: "0 2 * * 6", Duration: "1h", Timezone: "Europe/Berlin"}, now: saturday(2, 30, berlin).Add(time.Hour)},
+		{name: "invalid cron", sch: domain.MaintenanceSchedule{Cron: "0 2 * *", Duration: "2h"}, now: saturday(2, 30, time.UTC)},
+		{name: "invalid duration", sch: domain.MaintenanceSchedule{Cron: "0 2 * * 6", Duration: "soon"}, now: saturday(2, 30, time.UTC)},
+		{name: "unknown time zone", sch: domain.MaintenanceSchedule{Cron: "0 2 * * 6", Duration: "2h", Timezone: "Mars/Olympus"}, now: saturday(2, 30, time.UTC)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := inMaintenanceWindow(tt.sch, tt.now); got != tt.want {
+				t.Errorf("inMaintenanceWindow(%+v, %s) = %v, want %v", tt.sch, tt.now, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,296 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"math/rand/v2"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/lightwatch/monitoring-platform/services/api-go/internal/domain"
+	"github.com/lightwatch/monitoring-platform/services/api-go/internal/observability"
+	"github.com/lightwatch/monitoring-platform/services/api-go/pkg/webhook"
+)
+
+const (
+	notifyPollInterval = 2 * time.Second  // idle wait when the outbox is empty
+	notifyLease        = 30 * time.Second // must exceed the HTTP client timeout
+	notifyBaseBackoff  = 5 * time.Second
+	notifyMaxBackoff   = 10 * time.Minute
+	notifyBatchLimit   = 100 // notifications combined into one message at most
+)
+
+// DeliverNotifications drains the notification outbox with a pool of
+// workers. Each worker leases one due notification at a time, so several
+// api-go replicas can share the outbox safely. Failed deliveries are
+// retried with exponential backoff and jitter; once maxAttempts is reached
+// the notification is dead-lettered until retried through the API.
+type DeliverNotifications struct {
+	repo        domain.NotificationsRepository
+	notifiers   map[string]Notifier
+	publicURL   string
+	logger      *observability.Logger
+	workers     int
+	maxAttempts int
+}
+
+// NewDeliverNotifications creates the delivery worker pool with notifiers
+// for the built-in HTTP channels; others are added with Register.
+func NewDeliverNotifications(
+	repo domain.NotificationsRepository,
+	workers int,
+	maxAttempts int,
+	publicURL string,
+	logger *observability.Logger,
+) *DeliverNotifications {
+	if workers < 1 {
+		workers = 1
+	}
+	if maxAttempts < 1 {
+		maxAttempts = 1
+	}
+	client := &http.Client{Timeout: 10 * time.Second}
+	return &DeliverNotifications{
+		repo: repo,
+		notifiers: map[string]Notifier{
+			domain.ChannelWebhook:    NewWebhookNotifier(client),
+			domain.ChannelSlack:      NewSlackNotifier(client),
+			domain.ChannelTeams:      NewTeamsNotifier(client),
+			domain.ChannelMattermost: NewMattermostNotifier(client),
+			domain.ChannelPagerDuty:  NewPagerDutyNotifier(client),
+			domain.ChannelOpsgenie:   NewOpsgenieNotifier(client),
+		},
+		publicURL:   publicURL,
+		logger:      logger,
+		workers:     workers,
+		maxAttempts: maxAttempts,
+	}
+}
+
+// Register adds or replaces the notifier for a channel. It must be called
+// before Start.
+func (uc *DeliverNotifications) Register(channel string, notifier Notifier) {
+	uc.notifiers[channel] = notifier
+}
+
+// Start launches the workers. They stop when ctx is cancelled; a delivery
+// interrupted by shutdown is picked up again once its lease expires.
+func (uc *DeliverNotifications) Start(ctx context.Context) {
+	uc.logger.Info("notification workers started", map[string]interface{}{
+		"workers":      uc.workers,
+		"max_attempts": uc.maxAttempts,
+	})
+	for i := 0; i < uc.workers; i++ {
+		go uc.work(ctx)
+	}
+}
+
+func (uc *DeliverNotifications) work(ctx context.Context) {
+	for {
+		n, err := uc.repo.ClaimDue(ctx, time.Now(), notifyLease)
+		if err != nil && ctx.Err() == nil {
+			uc.logger.Error("notification claim failed", map[string]interface{}{"error": err.Error()})
+		}
+		if n == nil {
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(notifyPollInterval):
+			}
+			continue
+		}
+		uc.deliver(ctx, n)
+	}
+}
+
+// deliver attempts one delivery and records the outcome.
+func (uc *DeliverNotifications) deliver(ctx context.Context, n *domain.Notification) {
+	// Transitions reach each target in order, so a resolve cannot overtake
+	// a trigger that is waiting for a retry and leave an incident open.
+	waiting, err := uc.repo.HasEarlierUndelivered(ctx, n)
+	if err != nil && ctx.Err() == nil {
+		uc.logger.Warn("notification order check failed", map[string]interface{}{
+			"notification_id": n.ID,
+			"error":           err.Error(),
+		})
+	}
+	if waiting {
+		uc.reschedule(ctx, n, time.Now().Add(notifyBaseBackoff))
+		return
+	}
+
+	notifier, ok := uc.notifiers[n.Channel]
+	if !ok {
+		uc.record(ctx, n, fmt.Errorf("unsupported channel %q", n.Channel))
+		return
+	}
+	if grouper, ok := notifier.(GroupNotifier); ok && n.Group != nil {
+		uc.deliverGroup(ctx, grouper, n)
+		return
+	}
+	if batcher, ok := notifier.(BatchNotifier); ok {
+		uc.deliverBatch(ctx, batcher, n)
+		return
+	}
+
+	err = notifier.Notify(ctx, n, webhookPayload(n, uc.eventsLink(n)))
+	if err != nil && ctx.Err() != nil {
+		return // shutting down — leave the lease to expire and retry later
+	}
+	uc.record(ctx, n, err)
+}
+
+// deliverBatch sends n together with the other notifications due for the
+// same target. A first attempt inside an open batch window is held until
+// the window closes, so everything queued meanwhile goes out together.
+func (uc *DeliverNotifications) deliverBatch(ctx context.Context, batcher BatchNotifier, n *domain.Notification) {
+	if w := batcher.BatchWindow(); w > 0 && n.Attempts == 0 {
+		if end := n.CreatedAt.Truncate(w).Add(w); time.Now().Before(end) {
+			uc.reschedule(ctx, n, end)
+			return
+		}
+	}
+	uc.sendBatch(ctx, batcher, n, "")
+}
+
+// deliverGroup sends n with the rest of its alert group. A first attempt
+// is held for group_wait, so alerts firing together are collected, and
+// until group_interval has passed since the group's previous message.
+func (uc *DeliverNotifications) deliverGroup(ctx context.Context, grouper GroupNotifier, n *domain.Notification) {
+	if n.Attempts == 0 {
+		due := n.CreatedAt.Add(groupDuration(n.Group.Wait))
+		last, err := uc.repo.LastDelivered(ctx, n.Channel, n.Target, n.Group.Key)
+		if err != nil && ctx.Err() == nil {
+			uc.logger.Warn("notification group lookup failed", map[string]interface{}{
+				"notification_id": n.ID,
+				"error":           err.Error(),
+			})
+		}
+		if last != nil {
+			if next := last.Add(groupDuration(n.Group.Interval)); next.After(due) {
+				due = next
+			}
+		}
+		if time.Now().Before(due) {
+			uc.reschedule(ctx, n, due)
+			return
+		}
+	}
+	uc.sendBatch(ctx, grouper, n, n.Group.Key)
+}
+
+// sendBatch claims the other notifications due for n's target, and group
+// if one is given, and sends them all through one NotifyBatch call.
+func (uc *DeliverNotifications) sendBatch(ctx context.Context, grouper GroupNotifier, n *domain.Notification, group string) {
+	batch := []*domain.Notification{n}
+	more, err := uc.repo.ClaimBatch(ctx, n, group, time.Now(), notifyLease, notifyBatchLimit-1)
+	if err != nil && ctx.Err() == nil {
+		// Whatever was claimed is still sent; the rest waits for the next batch
+		uc.logger.Error("notification batch claim failed", map[string]interface{}{"error": err.Error()})
+	}
+	for i := range more {
+		batch = append(batch, &more[i])
+	}
+	sort.SliceStable(batch, func(i, j int) bool {
+		return batch[i].CreatedAt.Before(batch[j].CreatedAt)
+	})
+
+	payloads := make([]webhook.Payload, len(batch))
+	for i, bn := range batch {
+		payloads[i] = webhookPayload(bn, uc.eventsLink(bn))
+	}
+	err = grouper.NotifyBatch(ctx, batch, payloads)
+	if err != nil && ctx.Err() != nil {
+		return
+	}
+	for _, bn := range batch {
+		uc.record(ctx, bn, err)
+	}
+}
+
+// reschedule returns a claimed notification to the outbox until at.
+func (uc *DeliverNotifications) reschedule(ctx context.Context, n *domain.Notification, at time.Time) {
+	if err := uc.repo.Reschedule(ctx, n.ID, at); err != nil {
+		uc.logger.Error("notification update failed", map[string]interface{}{
+			"notification_id": n.ID,
+			"error":           err.Error(),
+		})
+	}
+}
+
+// record stores the outcome of a delivery attempt: delivered, scheduled
+// for a retry with backoff, or dead-lettered once attempts run out.
+func (uc *DeliverNotifications) record(ctx context.Context, n *domain.Notification, err error) {
+	now := time.Now()
+	if err == nil {
+		if err := uc.repo.MarkDelivered(ctx, n.ID, now); err != nil {
+			uc.logger.Error("notification update failed", map[string]interface{}{
+				"notification_id": n.ID,
+				"error":           err.Error(),
+			})
+			return
+		}
+		uc.logger.Info("notification delivered", map[string]interface{}{
+			"notification_id": n.ID,
+			"channel":         n.Channel,
+			"target":          n.Target,
+			"attempt":         n.Attempts + 1,
+		})
+		return
+	}
+
+	attempts := n.Attempts + 1
+	dead := attempts >= uc.maxAttempts
+	next := now.Add(backoff(attempts))
+	if err := uc.repo.MarkFailed(ctx, n.ID, attempts, next, err.Error(), dead); err != nil {
+		uc.logger.Error("notification update failed", map[string]interface{}{
+			"notification_id": n.ID,
+			"error":           err.Error(),
+		})
+		return
+	}
+
+	fields := map[string]interface{}{
+		"notification_id": n.ID,
+		"channel":         n.Channel,
+		"target":          n.Target,
+		"attempt":         attempts,
+		"error":           err.Error(),
+	}
+	if dead {
+		uc.logger.Error("notification dead-lettered", fields)
+		return
+	}
+	fields["next_attempt_at"] = next.UTC().Format(time.RFC3339)
+	uc.logger.Warn("notification delivery failed", fields)
+}
+
+// eventsLink points at the notification's alert event in the API, or at
+// the rule's event history if it has no event.
+func (uc *DeliverNotifications) eventsLink(n *domain.Notification) string {
+	if uc.publicURL == "" {
+		return ""
+	}
+	link := uc.publicURL + "/api/alerts/" + n.AlertID + "/events"
+	if n.EventID != "" {
+		link += "/" + n.EventID
+	}
+	return link
+}
+
+// backoff returns the delay before retrying after the given number of
+// failed attempts: exponential from notifyBaseBackoff, capped at
+// notifyMaxBackoff, with equal jitter (half fixed, half random) so a
+// receiver coming back up is not hit by synchronized retry waves.
+func backoff(attempts int) time.Duration {
+	d := notifyMaxBackoff
+	if attempts < 20 {
+		d = notifyBaseBackoff << (attempts - 1)
+	}
+	if d > notifyMaxBackoff {
+		d = notifyMaxBackoff
+	}
+	half := d / 2
+	return half + time.Duration(rand.Int64N(int64(half)+1))
+}
@@ -0,0 +1,141 @@
+package usecase
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/lightwatch/monitoring-platform/services/api-go/internal/domain"
+	"github.com/lightwatch/monitoring-platform/services/api-go/internal/observability"
+)
+
+func TestEventsLink(t *testing.T) {
+	tests := []struct {
+		name      string
+		publicURL string
+		n         domain.Notification
+		want      string
+	}{
+		{name: "event", publicURL: "https://lightwatch.example.com", n: domain.Notification{AlertID: "rule-1", EventID: "evt-1"}, want: "https://lightwatch.example.com/api/alerts/rule-1/events/evt-1"},
+		{name: "no event", publicURL: "https://lightwatch.example.com", n: domain.Notification{AlertID: "rule-1"}, want: "https://lightwatch.example.com/api/alerts/rule-1/events"},
+		{name: "no public URL", n: domain.Notification{AlertID: "rule-1", EventID: "evt-1"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			uc := NewDeliverNotifications(nil, 1, 1, tt.publicURL, observability.Discard())
+			if got := uc.eventsLink(&tt.n); got != tt.want {
+				t.Fatalf("eventsLink = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// outbox records what DeliverNotifications does with one claimed
+// notification.
+type outbox struct {
+	domain.NotificationsRepository
+	waiting     bool // an earlier transition for the target is undelivered
+	delivered   bool
+	rescheduled time.Time
+	attempts    int
+	next        time.Time
+	dead        bool
+}
+
+func (o *outbox) HasEarlierUndelivered(context.Context, *domain.Notification) (bool, error) {
+	return o.waiting, nil
+}
+
+func (o *outbox) Reschedule(_ context.Context, _ string, at time.Time) error {
+	o.rescheduled = at
+	return nil
+}
+
+func (o *outbox) MarkDelivered(context.Context, string, time.Time) error {
+	o.delivered = true
+	return nil
+}
+
+func (o *outbox) MarkFailed(_ context.Context, _ string, attempts int, next time.Time, _ string, dead bool) error {
+	o.attempts, o.next, o.dead = attempts, next, dead
+	return nil
+}
+
+func TestDeliver(t *testing.T) {
+	tests := []struct {
+		name         string
+		status       int
+		channel      string
+		attempts     int // before this one
+		waiting      bool
+		wantRequests int
+		wantAttempts int // recorded failed attempts; 0 if none was recorded
+		wantDead     bool
+	}{
+		{name: "delivered", status: http.StatusOK, wantRequests: 1},
+		{name: "retried", status: http.StatusServiceUnavailable, wantRequests: 1, wantAttempts: 1},
+		{name: "retried again", status: http.StatusServiceUnavailable, attempts: 1, wantRequests: 1, wantAttempts: 2},
+		{name: "dead-lettered", status: http.StatusServiceUnavailable, attempts: 2, wantRequests: 1, wantAttempts: 3, wantDead: true},
+		{name: "unsupported channel", channel: "pager", wantAttempts: 1},
+		{name: "earlier transition undelivered", status: http.StatusOK, waiting: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rc := newReceiver(t, tt.status)
+			repo := &outbox{waiting: tt.waiting}
+			uc := NewDeliverNotifications(repo, 1, 3, "", observability.Discard())
+			channel := tt.channel
+			if channel == "" {
+				channel = domain.ChannelWebhook
+			}
+
+			before := time.Now()
+			uc.deliver(context.Background(), &domain.Notification{
+				ID: "n-1", Channel: channel, Target: rc.URL, Attempts: tt.attempts,
+				Event: domain.AlertEvent{ID: "evt-1", Status: domain.AlertStateFiring},
+			})
+			rc.mu.Lock()
+			requests := len(rc.requests)
+			rc.mu.Unlock()
+			if requests != tt.wantRequests {
+				t.Fatalf("receiver got %d requests, want %d", requests, tt.wantRequests)
+			}
+			if repo.attempts != tt.wantAttempts || repo.dead != tt.wantDead {
+				t.Fatalf("attempts = %d, dead = %v; want %d, %v", repo.attempts, repo.dead, tt.wantAttempts, tt.wantDead)
+			}
+			if want := tt.status == http.StatusOK && !tt.waiting; repo.delivered != want {
+				t.Errorf("delivered = %v, want %v", repo.delivered, want)
+			}
+			if tt.wantAttempts > 0 && !tt.wantDead {
+				longest := notifyBaseBackoff << (tt.wantAttempts - 1)
+				if wait := repo.next.Sub(before); wait < longest/2 || wait > longest+time.Second {
+					t.Errorf("next attempt in %s, want within [%s, %s]", wait, longest/2, longest)
+				}
+			}
+			if tt.waiting && !repo.rescheduled.After(before) {
+				t.Errorf("rescheduled to %s, want later", repo.rescheduled)
+			}
+		})
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	tests := []struct {
+		attempts int
+		max      time.Duration
+	}{
+		{attempts: 1, max: notifyBaseBackoff},
+		{attempts: 2, max: 2 * notifyBaseBackoff},
+		{attempts: 4, max: 8 * notifyBaseBackoff},
+		{attempts: 12, max: notifyMaxBackoff},
+		{attempts: 70, max: notifyMaxBackoff},
+	}
+	for _, tt := range tests {
+		for i := 0; i < 100; i++ {
+			if d := backoff(tt.attempts); d < tt.max/2 || d > tt.max {
+				t.Fatalf("backoff(%d) = %s, want within [%s, %s]", tt.attempts, d, tt.max/2, tt.max)
+			}
+		}
+	}
+}
@@ -2,9 +2,11 @@ package usecase
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"net/http"
+	"math"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/lightwatch/monitoring-platform/services/api-go/internal/domain"
@@ -16,34 +18,129 @@ import (
 //
 // Architecture:
 //   - Runs periodically (caller invokes Tick in a goroutine loop)
-//   - Reads enabled alerts → queries recent metrics per rule
+//   - Reads enabled alerts that are due → evaluates them concurrently on a
+//     bounded worker pool, each within a per-rule timeout
 //   - Evaluates condition (operator + threshold)
-//   - Creates AlertEvent, optionally calls webhook and/or publishes to Redis
+//   - Advances the rule's persisted state (inactive → pending → firing →
+//     resolved); only transitions create or resolve an AlertEvent, queue
+//     notifications in the outbox, and publish to Redis stream:alerts
+//   - Rules with grouping re-queue notifications for an alert that is still
+//     firing once their repeat_interval has passed
 //
 // Supported detection strategies:
-//   - threshold:   value <operator> threshold (single point)
-//   - rate_change: (future) percentage change over duration window
-//   - anomaly:     (future) statistical deviation detection
+//   - threshold:   value <operator> threshold — the latest point, or every
+//     sample / an aggregate covering the Duration window ("for" clause)
+//   - rate_change: percent or absolute change between the start and end of
+//     the duration window (rising, falling or either direction)
+//   - anomaly:     deviation from a rolling per-series baseline built from
+//     metric history (z-score, EWMA or median/MAD)
+//   - log:         number of logs matching a level, message pattern and
+//     tags over the duration window, compared against the threshold
+//   - security:    number of matching security events over the duration
+//     window, optionally per source IP, service or type; each offending
+//     key fires as its own alert
+//   - heartbeat:   services whose heartbeats are overdue by their grace
+//     period (dead man's switch); each service fires as its own alert
+//   - composite:   several threshold, rate_change, anomaly, log and
+//     security conditions combined with all / any
+//
+// Threshold, rate_change and anomaly rules with a selector evaluate each
+// matching series (service and tag set) separately, and each series fires
+// as its own alert with the series labels attached.
 type DetectAnomaly struct {
-	alerts      domain.AlertsRepository
-	alertEvents domain.AlertEventsRepository
-	metrics     domain.MetricsRepository
-	logger      *observability.Logger
+	alerts        domain.AlertsRepository
+	alertEvents   domain.AlertEventsRepository
+	states        domain.AlertStatesRepository
+	metrics       domain.MetricsRepository
+	logs          domain.LogsRepository
+	security      domain.SecurityRepository
+	notifications domain.NotificationsRepository
+	silences      domain.SilencesRepository
+	policies      domain.EscalationPoliciesRepository
+	schedules     domain.SchedulesRepository
+	heartbeats    *MonitorHeartbeats
+	fence         domain.Fence          // optional; set when replicas elect a leader
+	publisher     domain.AlertPublisher // optional
+	logger        *observability.Logger
+	baselines     *baselineCache
+	clock         func() time.Time // time the rules are evaluated at; backtests replay the past
+	workers       int              // concurrent rule evaluations per tick
+	ruleTimeout   time.Duration
+	stats         *engineStats
 }
 
-// NewDetectAnomaly creates a fully-wired alert engine.
+// Defaults for SetConcurrency.
+const (
+	defaultEngineWorkers = 8
+	defaultRuleTimeout   = 20 * time.Second
+)
+
+// NewDetectAnomaly creates a fully-wired alert engine. fence may be nil when
+// a single replica runs the engine; publisher may be nil to disable
+// realtime fan-out.
 func NewDetectAnomaly(
 	alerts domain.AlertsRepository,
 	alertEvents domain.AlertEventsRepository,
+	states domain.AlertStatesRepository,
 	metrics domain.MetricsRepository,
+	logs domain.LogsRepository,
+	security domain.SecurityRepository,
+	notifications domain.NotificationsRepository,
+	silences domain.SilencesRepository,
+	policies domain.EscalationPoliciesRepository,
+	schedules domain.SchedulesRepository,
+	heartbeats *MonitorHeartbeats,
+	fence domain.Fence,
+	publisher domain.AlertPublisher,
 	logger *observability.Logger,
 ) *DetectAnomaly {
 	return &DetectAnomaly{
-		alerts:      alerts,
-		alertEvents: alertEvents,
-		metrics:     metrics,
-		logger:      logger,
+		alerts:        alerts,
+		alertEvents:   alertEvents,
+		states:        states,
+		metrics:       metrics,
+		logs:          logs,
+		security:      security,
+		notifications: notifications,
+		silences:      silences,
+		policies:      policies,
+		schedules:     schedules,
+		heartbeats:    heartbeats,
+		fence:         fence,
+		publisher:     publisher,
+		logger:        logger,
+		baselines:     newBaselineCache(),
+		clock:         time.Now,
+		workers:       defaultEngineWorkers,
+		ruleTimeout:   defaultRuleTimeout,
+		stats:         newEngineStats(),
+	}
+}
+
+// SetConcurrency sets how many rules a tick evaluates at once and how long
+// one evaluation may take. Call it before Start.
+func (d *DetectAnomaly) SetConcurrency(workers int, ruleTimeout time.Duration) {
+	if workers < 1 {
+		workers = 1
+	}
+	if ruleTimeout <= 0 {
+		ruleTimeout = defaultRuleTimeout
 	}
+	d.workers, d.ruleTimeout = workers, ruleTimeout
+}
+
+// now returns the current time of the engine clock.
+func (d *DetectAnomaly) now() time.Time {
+	return d.clock()
+}
+
+// fenceToken returns the fencing token writes are made under, or
+// domain.ErrNotLeader once this replica no longer leads.
+func (d *DetectAnomaly) fenceToken() (int64, error) {
+	if d.fence == nil {
+		return 0, nil
+	}
+	return d.fence.Token()
 }
 
 // Start runs the detection loop in a background goroutine.
@@ -54,7 +151,9 @@ func (d *DetectAnomaly) Start(ctx context.Context, interval time.Duration) {
 		defer ticker.Stop()
 
 		d.logger.Info("alert engine started", map[string]interface{}{
-			"interval": interval.String(),
+			"interval":     interval.String(),
+			"workers":      d.workers,
+			"rule_timeout": d.ruleTimeout.String(),
 		})
 
 		for {
@@ -63,106 +162,1372 @@ func (d *DetectAnomaly) Start(ctx context.Context, interval time.Duration) {
 				d.logger.Info("alert engine stopped")
 				return
 			case <-ticker.C:
-				if err := d.Tick(ctx); err != nil {
+				start := time.Now()
+				if err := d.Tick(ctx, interval); err != nil {
 					d.logger.Error("alert engine tick failed", map[string]interface{}{
 						"error": err.Error(),
 					})
 				}
+				// The ticker drops the ticks that fell due meanwhile
+				if took := time.Since(start); took >= interval {
+					skipped := int64(took / interval)
+					d.stats.skip(skipped)
+					d.logger.Warn("alert engine tick overran the interval", map[string]interface{}{
+						"took":    took.String(),
+						"skipped": skipped,
+					})
+				}
 			}
 		}
 	}()
 }
 
-// Tick runs a single evaluation cycle across all enabled alert rules.
-func (d *DetectAnomaly) Tick(ctx context.Context) error {
+// Tick runs a single evaluation cycle across the enabled alert rules that
+// are due, evaluating up to the configured number of rules at once.
+// interval is the time between ticks, which rules with a longer
+// evaluation_interval are scheduled against.
+func (d *DetectAnomaly) Tick(ctx context.Context, interval time.Duration) error {
+	if _, err := d.fenceToken(); err != nil {
+		return err
+	}
+	start, now := time.Now(), d.now()
 	rules, err := d.alerts.FindEnabled(ctx, "")
 	if err != nil {
 		return fmt.Errorf("fetch enabled alerts: %w", err)
 	}
+	d.baselines.prune(now)
+	due := d.stats.due(rules, now, interval)
 
-	for _, rule := range rules {
-		if err := d.evaluate(ctx, rule); err != nil {
-			d.logger.Warn("rule evaluation failed", map[string]interface{}{
-				"alert_id": rule.ID,
-				"name":     rule.Name,
-				"error":    err.Error(),
-			})
+	jobs := make(chan domain.Alert)
+	var wg sync.WaitGroup
+	for i := 0; i < min(d.workers, len(due)); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for rule := range jobs {
+				if ctx.Err() != nil {
+					continue // left due for the next leader or tick
+				}
+				d.stats.started(rule.ID, now)
+				d.run(ctx, rule)
+			}
+		}()
+	}
+	for _, rule := range due {
+		if ctx.Err() != nil {
+			break // shutting down or no longer the leader
 		}
+		jobs <- rule
 	}
+	close(jobs)
+	wg.Wait()
+
+	d.stats.observeTick(now, time.Since(start), len(due))
 	return nil
 }
 
+// run evaluates one rule within the rule timeout and records its latency.
+func (d *DetectAnomaly) run(ctx context.Context, rule domain.Alert) {
+	ctx, cancel := context.WithTimeout(ctx, d.ruleTimeout)
+	defer cancel()
+
+	start := time.Now()
+	err := d.evaluate(ctx, rule)
+	timedOut := errors.Is(ctx.Err(), context.DeadlineExceeded)
+	d.stats.observeRule(rule.ID, time.Since(start), err != nil, timedOut)
+	if err != nil {
+		fields := map[string]interface{}{
+			"alert_id": rule.ID,
+			"name":     rule.Name,
+			"error":    err.Error(),
+		}
+		if timedOut {
+			fields["timeout"] = d.ruleTimeout.String()
+		}
+		d.logger.Warn("rule evaluation failed", fields)
+	}
+}
+
+// Stats reports the evaluation loop of this replica's engine.
+func (d *DetectAnomaly) Stats() domain.EngineStats {
+	stats := d.stats.snapshot()
+	stats.Workers = d.workers
+	stats.RuleTimeout = d.ruleTimeout.String()
+	return stats
+}
+
+// evaluation is the outcome of checking one rule against recent data.
+type evaluation struct {
+	value     float64
+	threshold float64
+	breached  bool
+	meta      map[string]interface{}
+	labels    map[string]string // series tags, when the rule evaluates per series
+	truncated bool              // the metrics query hit maxMetricEvents, so series may be missing
+}
+
 func (d *DetectAnomaly) evaluate(ctx context.Context, rule domain.Alert) error {
-	// Query recent metrics matching the alert's target metric and service
-	window := "5m"
-	if rule.Condition.Duration != "" {
-		window = rule.Condition.Duration
+	evs, perKey, err := d.check(ctx, rule)
+	if err != nil {
+		return err
+	}
+	if perKey {
+		return d.applyPerKey(ctx, rule, evs)
 	}
-	dur, err := parseDuration(window)
+
+	st, err := d.states.Find(ctx, rule.ID)
 	if err != nil {
-		dur = 5 * time.Minute
+		return fmt.Errorf("load alert state: %w", err)
+	}
+	if len(evs) == 0 {
+		if st == nil {
+			return nil
+		}
+		return d.hold(ctx, rule, st, d.now()) // no data to evaluate
 	}
+	if st == nil {
+		st = &domain.AlertState{ID: rule.ID, State: domain.AlertStateInactive}
+	}
+	return d.apply(ctx, rule, st, evs[0], d.now())
+}
 
-	from := time.Now().Add(-dur).Format(time.RFC3339)
-	filter := domain.MetricsFilter{
-		Service: rule.Service,
-		Name:    rule.Condition.Metric,
-		From:    from,
-		Limit:   100,
+// check evaluates a rule as of the engine clock, without side effects.
+// With perKey, each evaluation is for one series or key with a state of
+// its own; otherwise evs holds the rule's evaluation, or nothing if there
+// was no data.
+func (d *DetectAnomaly) check(ctx context.Context, rule domain.Alert) (evs []*evaluation, perKey bool, err error) {
+	// Rules with a selector fire separately for each series
+	if len(rule.Selector) > 0 {
+		evs, err = d.evaluateSeries(ctx, rule)
+		return evs, true, err
+	}
+
+	var ev *evaluation
+	switch rule.Type {
+	case "", domain.AlertTypeThreshold:
+		ev, err = d.evaluateThreshold(ctx, rule)
+	case domain.AlertTypeRateChange:
+		ev, err = d.evaluateRateChange(ctx, rule)
+	case domain.AlertTypeAnomaly:
+		ev, err = d.evaluateAnomaly(ctx, rule)
+	case domain.AlertTypeLog:
+		ev, err = d.evaluateLogs(ctx, rule)
+	case domain.AlertTypeComposite:
+		ev, err = d.evaluateComposite(ctx, rule)
+	case domain.AlertTypeSecurity:
+		evs, err = d.evaluateSecurity(ctx, rule)
+		return evs, true, err
+	case domain.AlertTypeHeartbeat:
+		evs, err = d.evaluateHeartbeats(ctx, rule)
+		return evs, true, err
+	default:
+		return nil, false, fmt.Errorf("unsupported alert type %q", rule.Type)
+	}
+	if err != nil || ev == nil {
+		return nil, false, err
+	}
+	return []*evaluation{ev}, false, nil
+}
+
+// evaluateSeries evaluates a rule with a selector once for each matching
+// series.
+func (d *DetectAnomaly) evaluateSeries(ctx context.Context, rule domain.Alert) ([]*evaluation, error) {
+	switch rule.Type {
+	case "", domain.AlertTypeThreshold:
+		return d.evaluateThresholdSeries(ctx, rule)
+	case domain.AlertTypeRateChange:
+		return d.evaluateRateChangeSeries(ctx, rule)
+	case domain.AlertTypeAnomaly:
+		return d.evaluateAnomalySeries(ctx, rule)
+	default:
+		return nil, fmt.Errorf("alert type %q does not support selectors", rule.Type)
+	}
+}
+
+// applyPerKey advances a rule that fires separately per key. Each key in
+// evs has its own state; active keys that evs no longer reports are
+// evaluated as no longer breached, so they resolve. They are held instead
+// if a rule with a selector reports no series at all, as there was no
+// data, or if its metrics query was truncated, as they may have been cut
+// off.
+func (d *DetectAnomaly) applyPerKey(ctx context.Context, rule domain.Alert, evs []*evaluation) error {
+	states, err := d.states.FindByAlert(ctx, rule.ID)
+	if err != nil {
+		return fmt.Errorf("load alert states: %w", err)
+	}
+	active := make(map[string]*domain.AlertState, len(states))
+	for i := range states {
+		active[states[i].ID] = &states[i]
 	}
 
-	events, _, err := d.metrics.Find(ctx, filter)
+	now := d.now()
+	// Whether keys evs does not report are unknown rather than recovered
+	unknown := len(evs) == 0 && len(rule.Selector) > 0
+	var errs []error
+	for _, ev := range evs {
+		unknown = unknown || ev.truncated
+		id := keyStateID(rule.ID, ev.labels)
+		st, ok := active[id]
+		if !ok && !ev.breached {
+			continue // below threshold and never active
+		}
+		if !ok {
+			st = &domain.AlertState{ID: id, State: domain.AlertStateInactive}
+			if id != rule.ID {
+				st.AlertID = rule.ID
+				st.Labels = ev.labels
+			}
+		}
+		delete(active, id)
+		if err := d.apply(ctx, rule, st, ev, now); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	for _, st := range active {
+		if unknown {
+			err = d.hold(ctx, rule, st, now)
+		} else {
+			err = d.apply(ctx, rule, st, &evaluation{threshold: rule.Condition.Threshold, labels: st.Labels}, now)
+		}
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// keyStateID identifies the state of one key of a per-key rule, e.g.
+// "67a1…{source_ip=203.0.113.7}". Without a key it is the rule ID.
+func keyStateID(alertID string, key map[string]string) string {
+	if len(key) == 0 {
+		return alertID
+	}
+	return alertID + "{" + seriesKey(key) + "}"
+}
+
+// apply advances st with one evaluation, performs the resulting transition
+// and persists the state. Per-key states are removed once their key is no
+// longer active. Nothing is written once this replica has lost leadership.
+func (d *DetectAnomaly) apply(ctx context.Context, rule domain.Alert, st *domain.AlertState, ev *evaluation, now time.Time) error {
+	fence, err := d.fenceToken()
 	if err != nil {
-		return fmt.Errorf("query metrics: %w", err)
+		return err
+	}
+	st.Fence = fence
+	prev := *st
+	st.LastValue = ev.value
+	t := advance(st, ev.breached, pendingFor(rule), now)
+	if t != transitionNone || st.State == domain.AlertStateFiring {
+		if err := d.claim(ctx, &prev); err != nil {
+			return err
+		}
+	}
+	switch t {
+	case transitionFire:
+		if err := d.fire(ctx, rule, ev, st, now); err != nil {
+			return err
+		}
+	case transitionResolve:
+		if err := d.resolve(ctx, rule, st, now); err != nil {
+			return err
+		}
+	default:
+		if st.State == domain.AlertStateFiring {
+			if err := d.renotify(ctx, rule, st, now); err != nil {
+				return err
+			}
+		}
+	}
+	if st.State == domain.AlertStateFiring {
+		d.escalate(ctx, rule, st, now)
 	}
 
-	if len(events) == 0 {
-		return nil // no data to evaluate
+	if st.AlertID != "" && (st.State == domain.AlertStateInactive || st.State == domain.AlertStateResolved) {
+		if err := d.states.Delete(ctx, st.ID, st.Fence); err != nil {
+			return fmt.Errorf("delete alert state: %w", err)
+		}
+		return nil
 	}
+	if err := d.states.Save(ctx, st); err != nil {
+		return fmt.Errorf("save alert state: %w", err)
+	}
+	return nil
+}
 
-	// Evaluate using latest value (threshold strategy)
-	latest := events[0]
-	if !d.breached(latest.Value, rule.Condition.Operator, rule.Condition.Threshold) {
+// claim writes st, as it was before this evaluation, under this replica's
+// fencing token, ahead of the events and notifications of a transition,
+// renotification or escalation. Only the states are fenced by the store:
+// once a newer leader has written st, the claim fails with ErrNotLeader
+// and none of them follow.
+func (d *DetectAnomaly) claim(ctx context.Context, st *domain.AlertState) error {
+	if st.Fence == 0 {
+		return nil // no leader election
+	}
+	if err := d.states.Save(ctx, st); err != nil {
+		return fmt.Errorf("claim alert state: %w", err)
+	}
+	return nil
+}
+
+// hold keeps st as it is when there is no data to evaluate it against.
+// A firing alert still repeats its notifications and escalates.
+func (d *DetectAnomaly) hold(ctx context.Context, rule domain.Alert, st *domain.AlertState, now time.Time) error {
+	if st.State != domain.AlertStateFiring {
 		return nil
 	}
+	fence, err := d.fenceToken()
+	if err != nil {
+		return err
+	}
+	st.Fence = fence
+	if err := d.claim(ctx, st); err != nil {
+		return err
+	}
+	if err := d.renotify(ctx, rule, st, now); err != nil {
+		return err
+	}
+	d.escalate(ctx, rule, st, now)
+	if err := d.states.Save(ctx, st); err != nil {
+		return fmt.Errorf("save alert state: %w", err)
+	}
+	return nil
+}
 
-	// Threshold breached — create alert event
+// fire records a new firing AlertEvent for the rule and notifies.
+func (d *DetectAnomaly) fire(ctx context.Context, rule domain.Alert, ev *evaluation, st *domain.AlertState, now time.Time) error {
+	meta := map[string]interface{}{
+		"operator": rule.Condition.Operator,
+	}
+	if rule.Condition.Metric != "" {
+		meta["metric"] = rule.Condition.Metric
+	}
+	for k, v := range ev.meta {
+		meta[k] = v
+	}
+	if ev.truncated {
+		meta["truncated"] = true
+	}
+
+	labels := eventLabels(rule, ev)
 	alertEvt := &domain.AlertEvent{
 		AlertID:     rule.ID,
 		AlertName:   rule.Name,
-		Service:     rule.Service,
-		Value:       latest.Value,
-		Threshold:   rule.Condition.Threshold,
-		Status:      "firing",
-		TriggeredAt: time.Now(),
-		Meta: map[string]interface{}{
-			"metric":   rule.Condition.Metric,
-			"operator": rule.Condition.Operator,
-			"unit":     latest.Unit,
-		},
+		Service:     labels["service"],
+		Value:       ev.value,
+		Threshold:   ev.threshold,
+		Status:      domain.AlertStateFiring,
+		Labels:      labels,
+		SilencedBy:  d.silencedBy(ctx, labels, now),
+		TriggeredAt: now,
+		Meta:        meta,
 	}
 
 	id, err := d.alertEvents.Create(ctx, alertEvt)
 	if err != nil {
 		return fmt.Errorf("create alert event: %w", err)
 	}
+	st.EventID = id
 
-	d.logger.Warn("alert triggered", map[string]interface{}{
+	fields := map[string]interface{}{
 		"alert_event_id": id,
 		"alert_name":     rule.Name,
+		"service":        alertEvt.Service,
+		"value":          ev.value,
+		"threshold":      ev.threshold,
+	}
+	if len(alertEvt.SilencedBy) > 0 {
+		fields["silenced_by"] = alertEvt.SilencedBy
+	}
+	d.logger.Warn("alert triggered", fields)
+
+	d.publish(ctx, alertEvt)
+	st.LastNotifiedAt = nil
+	st.EscalationStep = 0
+	st.Escalated = nil
+	if len(alertEvt.SilencedBy) == 0 {
+		d.notify(ctx, rule, alertEvt, ruleTargets(rule))
+		st.LastNotifiedAt = &now
+	}
+	return nil
+}
+
+// resolve marks the rule's firing AlertEvent as resolved and notifies.
+func (d *DetectAnomaly) resolve(ctx context.Context, rule domain.Alert, st *domain.AlertState, now time.Time) error {
+	if st.EventID == "" {
+		return nil // state predates event tracking; nothing to resolve
+	}
+
+	alertEvt, err := d.alertEvents.Resolve(ctx, st.EventID, now)
+	if err != nil {
+		return fmt.Errorf("resolve alert event: %w", err)
+	}
+
+	d.logger.Info("alert resolved", map[string]interface{}{
+		"alert_event_id": alertEvt.ID,
+		"alert_name":     rule.Name,
 		"service":        rule.Service,
-		"value":          latest.Value,
-		"threshold":      rule.Condition.Threshold,
+		"value":          st.LastValue,
 	})
 
-	// Fire webhook if configured
-	if rule.Webhook != "" {
-		go d.fireWebhook(rule.Webhook, alertEvt)
+	d.publish(ctx, alertEvt)
+	// Receivers that never saw the alert open are not told it resolved.
+	// Events from before LastNotifiedAt was tracked were notified unless
+	// silenced. Everyone paged by escalation is told.
+	var targets []domain.NotificationTarget
+	if st.LastNotifiedAt != nil || len(alertEvt.SilencedBy) == 0 {
+		targets = ruleTargets(rule)
+	}
+	d.notify(ctx, rule, alertEvt, addTargets(targets, st.Escalated))
+	st.LastNotifiedAt = nil
+	st.EscalationStep = 0
+	st.Escalated = nil
+	return nil
+}
+
+// renotify repeats the notifications of a grouped rule that is still
+// firing and unacknowledged once its repeat_interval has passed. An alert
+// silenced when it fired is notified as soon as no silence matches it any
+// more.
+func (d *DetectAnomaly) renotify(ctx context.Context, rule domain.Alert, st *domain.AlertState, now time.Time) error {
+	g, ok := groupingPolicy(rule)
+	if !ok || st.EventID == "" {
+		return nil
+	}
+	if st.LastNotifiedAt != nil && now.Sub(*st.LastNotifiedAt) < groupDuration(g.RepeatInterval) {
+		return nil
+	}
+
+	alertEvt, err := d.alertEvents.FindByID(ctx, st.EventID)
+	if err != nil {
+		return fmt.Errorf("load alert event: %w", err)
+	}
+	if alertEvt.Status != domain.AlertStateFiring {
+		return nil // resolved, or acknowledged by a responder
+	}
+	if d.silencedBy(ctx, alertEvt.Labels, now) != nil {
+		return nil
 	}
 
+	alertEvt.SilencedBy = nil // only for the notification snapshot
+	d.notify(ctx, rule, alertEvt, addTargets(ruleTargets(rule), st.Escalated))
+	st.LastNotifiedAt = &now
 	return nil
 }
 
+// publish fans a transition out to realtime consumers.
+func (d *DetectAnomaly) publish(ctx context.Context, evt *domain.AlertEvent) {
+	publishAlert(ctx, d.publisher, d.logger, evt)
+}
+
+// publishAlert fans a transition out to realtime consumers; publisher may
+// be nil. The event is already persisted, so a failure is logged rather
+// than failing the transition.
+func publishAlert(ctx context.Context, publisher domain.AlertPublisher, logger *observability.Logger, evt *domain.AlertEvent) {
+	if publisher == nil {
+		return
+	}
+	if err := publisher.PublishAlert(ctx, evt); err != nil {
+		logger.Warn("alert publish failed", map[string]interface{}{
+			"alert_event_id": evt.ID,
+			"error":          err.Error(),
+		})
+	}
+}
+
+// silencedBy returns the IDs of the silences currently matching an alert's
+// labels. If silences cannot be loaded the alert is not silenced: a
+// spurious page is better than a missed one.
+func (d *DetectAnomaly) silencedBy(ctx context.Context, labels map[string]string, now time.Time) []string {
+	silences, err := d.silences.FindCurrent(ctx, now)
+	if err != nil {
+		d.logger.Error("silence lookup failed", map[string]interface{}{"error": err.Error()})
+		return nil
+	}
+
+	var ids []string
+	for _, s := range silences {
+		if silenceStatus(s, now) != domain.SilenceActive {
+			continue // between maintenance windows
+		}
+		matchers, err := compileMatchers(s.Matchers)
+		if err != nil {
+			continue
+		}
+		if matchAll(matchers, labels) {
+			ids = append(ids, s.ID)
+		}
+	}
+	return ids
+}
+
+// notify queues the transition in the notification outbox for each of the
+// targets; delivery and retries are handled by DeliverNotifications.
+// Callers pick the targets — the rule's own, escalation steps or both —
+// and decide whether silences suppress the notification.
+func (d *DetectAnomaly) notify(ctx context.Context, rule domain.Alert, evt *domain.AlertEvent, targets []domain.NotificationTarget) {
+	enqueueNotifications(ctx, d.notifications, d.logger, rule, evt, targets)
+}
+
+// enqueueNotifications writes one outbox notification of evt per target.
+// Enqueue failures are logged: the transition has already been recorded.
+func enqueueNotifications(
+	ctx context.Context,
+	notifications domain.NotificationsRepository,
+	logger *observability.Logger,
+	rule domain.Alert,
+	evt *domain.AlertEvent,
+	targets []domain.NotificationTarget,
+) {
+	info := domain.RuleInfo{
+		ID:        rule.ID,
+		Name:      rule.Name,
+		Type:      rule.Type,
+		Severity:  ruleSeverity(rule),
+		Condition: rule.Condition,
+	}
+	var group *domain.NotificationGroup
+	if g, ok := groupingPolicy(rule); ok {
+		group = notificationGroup(g, evt.Labels)
+	}
+	for _, t := range targets {
+		n := &domain.Notification{
+			AlertID: rule.ID,
+			EventID: evt.ID,
+			Channel: t.Type,
+			Target:  t.URL,
+			Event:   *evt,
+			Rule:    info,
+			Group:   group,
+		}
+		n.Template = t.Body
+		switch t.Type {
+		case domain.ChannelWebhook:
+			n.Secrets = rule.WebhookSecrets
+		case domain.ChannelEmail:
+			n.Target = strings.Join(t.To, ",")
+		case domain.ChannelPagerDuty, domain.ChannelOpsgenie:
+			n.Target = incidentEndpoint(t)
+			n.Secrets = []string{t.Key}
+		}
+		if _, err := notifications.Create(ctx, n); err != nil {
+			logger.Error("notification enqueue failed", map[string]interface{}{
+				"alert_event_id": evt.ID,
+				"channel":        n.Channel,
+				"error":          err.Error(),
+			})
+		}
+	}
+}
+
+func ruleSeverity(rule domain.Alert) string {
+	if rule.Severity == "" {
+		return domain.SeverityCritical
+	}
+	return rule.Severity
+}
+
+// eventLabels identifies the alert for receivers: the rule and service,
+// plus the tags of the offending series for per-series rules.
+func eventLabels(rule domain.Alert, ev *evaluation) map[string]string {
+	labels := make(map[string]string, len(ev.labels)+2)
+	for k, v := range ev.labels {
+		labels[k] = v
+	}
+	labels["alertname"] = rule.Name
+	if rule.Service != "" {
+		labels["service"] = rule.Service
+	}
+	return labels
+}
+
+// evaluateThreshold compares the rule's window against the threshold.
+//
+// Without a Duration only the latest sample is checked. With a Duration the
+// window acts as a "for" clause: the samples covering it — including the
+// last sample before the window, which was still current at its start —
+// are reduced with the rule's aggregate (default: every sample must breach).
+// If no sample precedes the window, the data does not yet span it and the
+// condition is not considered breached.
+func (d *DetectAnomaly) evaluateThreshold(ctx context.Context, rule domain.Alert) (*evaluation, error) {
+	window := ruleWindow(rule)
+	agg := thresholdAggregate(rule)
+	now := d.now()
+	from := now.Add(-window)
+
+	if agg == domain.AggregateLast {
+		f := metricsFilter(rule, from, now)
+		f.Limit = 1
+		events, _, err := d.metrics.Scan(ctx, f)
+		if err != nil {
+			return nil, fmt.Errorf("query metrics: %w", err)
+		}
+		if len(events) == 0 {
+			return nil, nil // no data to evaluate
+		}
+		return d.thresholdLast(rule, events[0]), nil
+	}
+
+	events, truncated, err := d.findAllMetrics(ctx, metricsFilter(rule, from, now))
+	if err != nil {
+		return nil, fmt.Errorf("query metrics: %w", err)
+	}
+	if len(events) == 0 {
+		return nil, nil // no data to evaluate
+	}
+
+	// The last sample before the window proves the series covers its start.
+	// Only look back one more window so a long gap doesn't count as coverage.
+	f := metricsFilter(rule, from.Add(-window), from)
+	f.Limit = 1
+	anchor, _, err := d.metrics.Scan(ctx, f)
+	if err != nil {
+		return nil, fmt.Errorf("query window anchor: %w", err)
+	}
+	ev := d.thresholdWindow(rule, agg, window, events, anchor)
+	ev.truncated = truncated
+	return ev, nil
+}
+
+// evaluateThresholdSeries is evaluateThreshold for each series matching
+// the rule's selector.
+func (d *DetectAnomaly) evaluateThresholdSeries(ctx context.Context, rule domain.Alert) ([]*evaluation, error) {
+	window := ruleWindow(rule)
+	agg := thresholdAggregate(rule)
+	now := d.now()
+	from := now.Add(-window)
+
+	recent, truncated, err := d.findAllMetrics(ctx, metricsFilter(rule, from, now))
+	if err != nil {
+		return nil, fmt.Errorf("query metrics: %w", err)
+	}
+
+	var anchors map[string]domain.MetricEvent
+	if agg != domain.AggregateLast {
+		older, cut, err := d.findAllMetrics(ctx, metricsFilter(rule, from.Add(-window), from))
+		if err != nil {
+			return nil, fmt.Errorf("query window anchors: %w", err)
+		}
+		truncated = truncated || cut
+		anchors = make(map[string]domain.MetricEvent)
+		for _, m := range older { // newest first, so the first hit is the anchor
+			key := seriesKey(metricLabels(m))
+			if _, ok := anchors[key]; !ok {
+				anchors[key] = m
+			}
+		}
+	}
+
+	series := groupSeries(recent)
+	evs := make([]*evaluation, len(series))
+	for i, s := range series {
+		if agg == domain.AggregateLast {
+			evs[i] = d.thresholdLast(rule, s.events[0])
+		} else {
+			var anchor []domain.MetricEvent
+			if m, ok := anchors[s.key]; ok {
+				anchor = []domain.MetricEvent{m}
+			}
+			evs[i] = d.thresholdWindow(rule, agg, window, s.events, anchor)
+		}
+		evs[i].labels = s.labels
+		evs[i].truncated = truncated
+	}
+	return evs, nil
+}
+
+// thresholdLast compares the latest sample against the threshold.
+func (d *DetectAnomaly) thresholdLast(rule domain.Alert, latest domain.MetricEvent) *evaluation {
+	return &evaluation{
+		value:     latest.Value,
+		threshold: rule.Condition.Threshold,
+		breached:  d.breached(latest.Value, rule.Condition.Operator, rule.Condition.Threshold),
+		meta:      map[string]interface{}{"unit": latest.Unit},
+	}
+}
+
+// thresholdWindow reduces the samples of the window, newest first, and its
+// anchor (at most one sample) with agg and compares the result.
+func (d *DetectAnomaly) thresholdWindow(rule domain.Alert, agg string, window time.Duration, events, anchor []domain.MetricEvent) *evaluation {
+	meta := map[string]interface{}{
+		"unit":      events[0].Unit,
+		"aggregate": agg,
+		"window":    window.String(),
+		"samples":   len(events) + len(anchor),
+	}
+	if len(anchor) == 0 {
+		meta["insufficient_data"] = true
+		return &evaluation{
+			value:     events[0].Value,
+			threshold: rule.Condition.Threshold,
+			meta:      meta,
+		}
+	}
+
+	values := make([]float64, 0, len(events)+1)
+	for _, m := range events {
+		values = append(values, m.Value)
+	}
+	values = append(values, anchor[0].Value)
+
+	value, breached := d.aggregateBreach(values, agg, rule.Condition.Operator, rule.Condition.Threshold)
+	return &evaluation{
+		value:     value,
+		threshold: rule.Condition.Threshold,
+		breached:  breached,
+		meta:      meta,
+	}
+}
+
+// aggregateBreach reduces window values with agg and compares the result.
+// For AggregateAll the reported value is the sample closest to not
+// breaching (e.g. the minimum for gt), which is what proves the condition.
+func (d *DetectAnomaly) aggregateBreach(values []float64, agg, operator string, threshold float64) (float64, bool) {
+	if agg != domain.AggregateAll {
+		value := aggregate(values, agg)
+		return value, d.breached(value, operator, threshold)
+	}
+
+	weakest := values[0] // newest sample
+	switch operator {
+	case "gt", "gte":
+		weakest = aggregate(values, domain.AggregateMin)
+	case "lt", "lte":
+		weakest = aggregate(values, domain.AggregateMax)
+	}
+	for _, v := range values {
+		if !d.breached(v, operator, threshold) {
+			return weakest, false
+		}
+	}
+	return weakest, true
+}
+
+// evaluateRateChange compares the metric's value at the start and end of the
+// window. The change is expressed in percent of the start value (default) or
+// as an absolute delta, signed according to Condition.Direction, and then
+// compared against the threshold with the rule's operator.
+func (d *DetectAnomaly) evaluateRateChange(ctx context.Context, rule domain.Alert) (*evaluation, error) {
+	window := ruleWindow(rule)
+	now := d.now()
+	filter := metricsFilter(rule, now.Add(-window), now)
+	filter.Limit = 1
+
+	latest, _, err := d.metrics.Scan(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("query latest metric: %w", err)
+	}
+	filter.Sort = "asc"
+	earliest, _, err := d.metrics.Scan(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("query earliest metric: %w", err)
+	}
+
+	// Need two distinct points to measure a change
+	if len(latest) == 0 || len(earliest) == 0 || latest[0].ID == earliest[0].ID {
+		return nil, nil
+	}
+	return d.rateChange(rule, window, earliest[0], latest[0]), nil
+}
+
+// evaluateRateChangeSeries is evaluateRateChange for each series matching
+// the rule's selector.
+func (d *DetectAnomaly) evaluateRateChangeSeries(ctx context.Context, rule domain.Alert) ([]*evaluation, error) {
+	window := ruleWindow(rule)
+	now := d.now()
+	recent, truncated, err := d.findAllMetrics(ctx, metricsFilter(rule, now.Add(-window), now))
+	if err != nil {
+		return nil, fmt.Errorf("query metrics: %w", err)
+	}
+
+	var evs []*evaluation
+	for _, s := range groupSeries(recent) {
+		if len(s.events) < 2 {
+			continue // need two points to measure a change
+		}
+		ev := d.rateChange(rule, window, s.events[len(s.events)-1], s.events[0])
+		if ev == nil {
+			continue
+		}
+		ev.labels = s.labels
+		ev.truncated = truncated
+		evs = append(evs, ev)
+	}
+	return evs, nil
+}
+
+// rateChange measures the change from earliest to latest. It returns nil
+// for a percentage change from zero, which is undefined.
+func (d *DetectAnomaly) rateChange(rule domain.Alert, window time.Duration, earliest, latest domain.MetricEvent) *evaluation {
+	start, end := earliest.Value, latest.Value
+	change := end - start
+	mode := rule.Condition.Change
+	if mode == "" {
+		mode = domain.ChangePercent
+	}
+	if mode == domain.ChangePercent {
+		if start == 0 {
+			return nil
+		}
+		change = change / math.Abs(start) * 100
+	}
+
+	direction := rule.Condition.Direction
+	if direction == "" {
+		direction = domain.DirectionEither
+	}
+	value := change
+	switch direction {
+	case domain.DirectionDown:
+		value = -change
+	case domain.DirectionEither:
+		value = math.Abs(change)
+	}
+
+	return &evaluation{
+		value:     value,
+		threshold: rule.Condition.Threshold,
+		breached:  d.breached(value, rule.Condition.Operator, rule.Condition.Threshold),
+		meta: map[string]interface{}{
+			"unit":        latest.Unit,
+			"change":      mode,
+			"direction":   direction,
+			"start_value": start,
+			"end_value":   end,
+			"window":      window.String(),
+		},
+	}
+}
+
+// evaluateAnomaly reports the series with the largest deviation from its
+// baseline, as computed by evaluateAnomalySeries.
+func (d *DetectAnomaly) evaluateAnomaly(ctx context.Context, rule domain.Alert) (*evaluation, error) {
+	evs, err := d.evaluateAnomalySeries(ctx, rule)
+	if err != nil {
+		return nil, err
+	}
+	var worst *evaluation
+	for _, ev := range evs {
+		if worst == nil || ev.value > worst.value {
+			worst = ev
+		}
+	}
+	return worst, nil
+}
+
+// evaluateAnomalySeries compares the latest value of every series (distinct
+// service and tag set) in the evaluation window against that series'
+// baseline. Series without enough history are skipped. The value is the
+// deviation score and the threshold is the configured sensitivity.
+func (d *DetectAnomaly) evaluateAnomalySeries(ctx context.Context, rule domain.Alert) ([]*evaluation, error) {
+	cfg := anomalyConfig(rule)
+	window := ruleWindow(rule)
+	now := d.now()
+
+	recent, truncated, err := d.findAllMetrics(ctx, metricsFilter(rule, now.Add(-window), now))
+	if err != nil {
+		return nil, fmt.Errorf("query recent metrics: %w", err)
+	}
+	if len(recent) == 0 {
+		return nil, nil
+	}
+
+	baselines, ok := d.baselines.get(rule, now)
+	if !ok {
+		baselines, err = d.buildBaselines(ctx, rule, cfg, now.Add(-window))
+		if err != nil {
+			return nil, err
+		}
+		d.baselines.set(rule, now, baselines)
+	}
+
+	var evs []*evaluation
+	for _, s := range groupSeries(recent) {
+		b, ok := baselines[s.key]
+		if !ok || b.samples < cfg.MinSamples {
+			continue // not enough history to judge this series yet
+		}
+
+		// Signed score: above baseline for "up", below for "down"
+		m := s.events[0]
+		score := b.score(m.Value)
+		switch rule.Condition.Direction {
+		case domain.DirectionUp:
+		case domain.DirectionDown:
+			score = -score
+		default:
+			score = math.Abs(score)
+		}
+
+		evs = append(evs, &evaluation{
+			value:     score,
+			threshold: cfg.Sensitivity,
+			breached:  score >= cfg.Sensitivity,
+			meta: map[string]interface{}{
+				"unit":          m.Unit,
+				"method":        cfg.Method,
+				"current_value": m.Value,
+				"baseline":      b.center,
+				"deviation":     b.scale,
+				"samples":       b.samples,
+				"tags":          m.Tags,
+			},
+			labels:    s.labels,
+			truncated: truncated,
+		})
+	}
+	return evs, nil
+}
+
+// maxSecurityKeys caps how many keys a security rule tracks per
+// evaluation; the keys with the most events are kept.
+const maxSecurityKeys = 500
+
+// evaluateSecurity counts the security events matching the rule's query
+// within the window, per distinct value of its group_by fields, and
+// compares each count against the threshold. Without group_by the rule
+// has a single key covering all matching events.
+func (d *DetectAnomaly) evaluateSecurity(ctx context.Context, rule domain.Alert) ([]*evaluation, error) {
+	var q domain.SecurityQuery
+	if rule.Condition.Security != nil {
+		q = *rule.Condition.Security
+	}
+	window := ruleWindow(rule)
+	now := d.now()
+
+	counts, err := d.security.Count(ctx, domain.SecurityFilter{
+		Service:  rule.Service,
+		IP:       q.SourceIP,
+		Type:     q.Type,
+		Severity: q.Severity,
+		From:     now.Add(-window).Format(time.RFC3339),
+		To:       now.Format(time.RFC3339),
+		Limit:    maxSecurityKeys,
+	}, q.GroupBy)
+	if err != nil {
+		return nil, fmt.Errorf("count security events: %w", err)
+	}
+	if len(counts) == 0 && len(q.GroupBy) == 0 {
+		counts = []domain.SecurityCount{{}} // no events is a count of zero
+	}
+
+	evs := make([]*evaluation, len(counts))
+	for i, c := range counts {
+		meta := map[string]interface{}{
+			"source": "security",
+			"window": window.String(),
+		}
+		if len(q.GroupBy) > 0 {
+			meta["key"] = c.Key
+		}
+		if q.Type != "" {
+			meta["event_type"] = q.Type
+		}
+		if q.Severity != "" {
+			meta["severity"] = q.Severity
+		}
+		if c.Count > 0 {
+			meta["first_seen"] = c.FirstSeen
+			meta["last_seen"] = c.LastSeen
+		}
+
+		var labels map[string]string
+		if len(q.GroupBy) > 0 {
+			labels = c.Key
+		}
+		count := float64(c.Count)
+		evs[i] = &evaluation{
+			value:     count,
+			threshold: rule.Condition.Threshold,
+			breached:  d.breached(count, rule.Condition.Operator, rule.Condition.Threshold),
+			meta:      meta,
+			labels:    labels,
+		}
+	}
+	return evs, nil
+}
+
+// evaluateHeartbeats checks the heartbeat age of the rule's service, or of
+// every service if the rule has none, against the grace period of the
+// rule's status. The value is the age in seconds and the threshold the
+// grace period.
+func (d *DetectAnomaly) evaluateHeartbeats(ctx context.Context, rule domain.Alert) ([]*evaluation, error) {
+	level := heartbeatLevel(rule)
+	services, err := d.heartbeats.findServices(ctx, rule.Service)
+	if err != nil {
+		return nil, fmt.Errorf("query services: %w", err)
+	}
+
+	now := d.now()
+	evs := make([]*evaluation, 0, len(services))
+	for _, svc := range services {
+		if svc.LastHeartbeat.IsZero() {
+			continue // never reported, so its heartbeat cannot be overdue
+		}
+		status := d.heartbeats.heartbeatStatus(svc, now)
+		grace, unhealthy := d.heartbeats.grace(svc)
+		if level == domain.ServiceUnhealthy {
+			grace = unhealthy
+		}
+		age := now.Sub(svc.LastHeartbeat)
+		evs = append(evs, &evaluation{
+			value:     math.Round(age.Seconds()),
+			threshold: grace.Seconds(),
+			breached:  statusRank(status) >= statusRank(level),
+			meta: map[string]interface{}{
+				"unit":           "s",
+				"source":         "heartbeat",
+				"status":         status,
+				"last_heartbeat": svc.LastHeartbeat,
+				"host":           svc.Host,
+			},
+			labels: map[string]string{"service": svc.Name},
+		})
+	}
+	return evs, nil
+}
+
+// heartbeatLevel is the service status from which a heartbeat rule fires.
+func heartbeatLevel(rule domain.Alert) string {
+	if hb := rule.Condition.Heartbeat; hb != nil && hb.Status != "" {
+		return hb.Status
+	}
+	return domain.ServiceDegraded
+}
+
+// maxLogSamples is how many matching log lines a log rule attaches to its
+// event.
+const maxLogSamples = 5
+
+// evaluateLogs counts the logs matching the rule's log query within the
+// window and compares the count against the threshold. The newest matches
+// are attached to the event as samples. A window without matches is a
+// count of zero, so the rule resolves once the logs stop.
+func (d *DetectAnomaly) evaluateLogs(ctx context.Context, rule domain.Alert) (*evaluation, error) {
+	var q domain.LogQuery
+	if rule.Condition.Logs != nil {
+		q = *rule.Condition.Logs
+	}
+	window := ruleWindow(rule)
+	now := d.now()
+
+	logs, total, err := d.logs.Find(ctx, domain.LogsFilter{
+		Service: rule.Service,
+		Level:   q.Level,
+		Query:   q.Pattern,
+		Tags:    q.Tags,
+		From:    now.Add(-window).Format(time.RFC3339),
+		To:      now.Format(time.RFC3339),
+		Limit:   maxLogSamples,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("query logs: %w", err)
+	}
+
+	samples := make([]map[string]interface{}, len(logs))
+	for i, l := range logs {
+		sample := map[string]interface{}{
+			"timestamp": l.Timestamp,
+			"level":     l.Level,
+			"message":   l.Message,
+		}
+		if l.TraceID != "" {
+			sample["trace_id"] = l.TraceID
+		}
+		samples[i] = sample
+	}
+
+	meta := map[string]interface{}{
+		"source": "logs",
+		"window": window.String(),
+	}
+	if q.Level != "" {
+		meta["level"] = q.Level
+	}
+	if q.Pattern != "" {
+		meta["pattern"] = q.Pattern
+	}
+	if len(samples) > 0 {
+		meta["samples"] = samples
+	}
+
+	count := float64(total)
+	return &evaluation{
+		value:     count,
+		threshold: rule.Condition.Threshold,
+		breached:  d.breached(count, rule.Condition.Operator, rule.Condition.Threshold),
+		meta:      meta,
+	}, nil
+}
+
+// evaluateComposite evaluates every condition of a composite rule and
+// combines the results with the rule's all/any groups. A condition without
+// data counts as not breached; the rule has no data only if none of its
+// conditions has. The value and threshold are those of the first breached
+// condition, and each condition's result is recorded in meta.conditions.
+func (d *DetectAnomaly) evaluateComposite(ctx context.Context, rule domain.Alert) (*evaluation, error) {
+	var results []map[string]interface{}
+	root := domain.ConditionNode{All: rule.Condition.All, Any: rule.Condition.Any}
+	breached, err := d.evaluateNode(ctx, rule, root, "", &results)
+	if err != nil {
+		return nil, err
+	}
+
+	ev := &evaluation{
+		breached: breached,
+		meta:     map[string]interface{}{"conditions": results},
+	}
+	hasData := false
+	for _, r := range results {
+		if r["no_data"] == true {
+			continue
+		}
+		hasData = true
+		if r["breached"] == true {
+			ev.value, ev.threshold = r["value"].(float64), r["threshold"].(float64)
+			break
+		}
+	}
+	if !hasData {
+		return nil, nil
+	}
+	return ev, nil
+}
+
+// evaluateNode evaluates one node of a composite rule's condition tree,
+// appending the result of each condition to results. Every child of a
+// group is evaluated, so all results are recorded.
+func (d *DetectAnomaly) evaluateNode(ctx context.Context, rule domain.Alert, n domain.ConditionNode, path string, results *[]map[string]interface{}) (bool, error) {
+	if n.Condition == nil {
+		group, op := n.All, "all"
+		if len(n.Any) > 0 {
+			group, op = n.Any, "any"
+		}
+		if path != "" {
+			path += "."
+		}
+		breached := op == "all"
+		for i, child := range group {
+			b, err := d.evaluateNode(ctx, rule, child, fmt.Sprintf("%s%s[%d]", path, op, i), results)
+			if err != nil {
+				return false, err
+			}
+			if op == "all" {
+				breached = breached && b
+			} else {
+				breached = breached || b
+			}
+		}
+		return breached, nil
+	}
+
+	// The condition is evaluated as a rule of its own, with an ID of its
+	// own so anomaly baselines are cached per condition
+	leaf := rule
+	leaf.ID = rule.ID + "/" + path
+	leaf.Type = n.Type
+	if leaf.Type == "" {
+		leaf.Type = domain.AlertTypeThreshold
+	}
+	leaf.Condition = *n.Condition
+
+	ev, err := d.evaluateLeaf(ctx, leaf)
+	if err != nil {
+		return false, fmt.Errorf("condition %s: %w", path, err)
+	}
+
+	name := n.Name
+	if name == "" {
+		name = path
+	}
+	result := map[string]interface{}{"name": name, "type": leaf.Type}
+	if leaf.Condition.Metric != "" {
+		result["metric"] = leaf.Condition.Metric
+	}
+	if ev == nil {
+		result["no_data"] = true
+		*results = append(*results, result)
+		return false, nil
+	}
+	result["value"] = ev.value
+	result["threshold"] = ev.threshold
+	result["breached"] = ev.breached
+	if len(ev.labels) > 0 {
+		result["tags"] = ev.labels
+	}
+	*results = append(*results, result)
+	return ev.breached, nil
+}
+
+// evaluateLeaf evaluates one condition of a composite rule. Security
+// conditions are reduced to their worst key, so they hold if any key
+// breaches.
+func (d *DetectAnomaly) evaluateLeaf(ctx context.Context, leaf domain.Alert) (*evaluation, error) {
+	switch leaf.Type {
+	case domain.AlertTypeThreshold:
+		return d.evaluateThreshold(ctx, leaf)
+	case domain.AlertTypeRateChange:
+		return d.evaluateRateChange(ctx, leaf)
+	case domain.AlertTypeAnomaly:
+		return d.evaluateAnomaly(ctx, leaf)
+	case domain.AlertTypeLog:
+		return d.evaluateLogs(ctx, leaf)
+	case domain.AlertTypeSecurity:
+		evs, err := d.evaluateSecurity(ctx, leaf)
+		if err != nil {
+			return nil, err
+		}
+		var worst *evaluation
+		for _, ev := range evs {
+			if worst == nil || ev.breached && !worst.breached ||
+				ev.breached == worst.breached && ev.value > worst.value {
+				worst = ev
+			}
+		}
+		return worst, nil
+	default:
+		return nil, fmt.Errorf("unsupported condition type %q", leaf.Type)
+	}
+}
+
+// buildBaselines reads the history window that precedes the evaluation
+// window and computes one baseline per series.
+func (d *DetectAnomaly) buildBaselines(ctx context.Context, rule domain.Alert, cfg domain.AnomalyConfig, until time.Time) (map[string]baseline, error) {
+	history, err := parseDuration(cfg.History)
+	if err != nil || history <= 0 {
+		history = time.Hour
+	}
+
+	f := metricsFilter(rule, until.Add(-history), until)
+	f.Sort = "asc"
+	events, _, err := d.findAllMetrics(ctx, f)
+	if err != nil {
+		return nil, fmt.Errorf("query metric history: %w", err)
+	}
+
+	values := make(map[string][]float64)
+	for _, m := range events {
+		key := seriesKey(metricLabels(m))
+		values[key] = append(values[key], m.Value)
+	}
+
+	result := make(map[string]baseline, len(values))
+	for key, v := range values {
+		result[key] = computeBaseline(cfg.Method, cfg.Alpha, v)
+	}
+	return result, nil
+}
+
+// maxMetricEvents caps how many samples the engine reads for one query.
+const maxMetricEvents = 10000
+
+// findAllMetrics reads the samples matching f in one query, up to
+// maxMetricEvents. truncated reports that more matched, so the result
+// lacks the oldest samples (the newest, sorting ascending); this is
+// logged, and callers mark their evaluations.
+func (d *DetectAnomaly) findAllMetrics(ctx context.Context, f domain.MetricsFilter) (events []domain.MetricEvent, truncated bool, err error) {
+	f.Limit = maxMetricEvents
+	events, truncated, err = d.metrics.Scan(ctx, f)
+	if err != nil {
+		return nil, false, err
+	}
+	if truncated {
+		d.logger.Warn("metrics query truncated", map[string]interface{}{
+			"metric":  f.Name,
+			"service": f.Service,
+			"from":    f.From,
+			"to":      f.To,
+			"limit":   maxMetricEvents,
+		})
+	}
+	return events, truncated, nil
+}
+
+// metricsFilter selects the samples of the rule's metric from its service
+// and selector between from and to. Bounding every query at the engine
+// clock lets backtests see only the data that existed at the time.
+func metricsFilter(rule domain.Alert, from, to time.Time) domain.MetricsFilter {
+	return domain.MetricsFilter{
+		Service:  rule.Service,
+		Name:     rule.Condition.Metric,
+		Matchers: rule.Selector,
+		From:     from.Format(time.RFC3339),
+		To:       to.Format(time.RFC3339),
+	}
+}
+
+// metricSeries holds the samples of one series, newest first.
+type metricSeries struct {
+	key    string
+	labels map[string]string
+	events []domain.MetricEvent
+}
+
+// groupSeries splits samples sorted newest first into series, ordered by
+// their latest sample.
+func groupSeries(events []domain.MetricEvent) []*metricSeries {
+	var out []*metricSeries
+	index := make(map[string]*metricSeries)
+	for _, m := range events {
+		labels := metricLabels(m)
+		key := seriesKey(labels)
+		s, ok := index[key]
+		if !ok {
+			s = &metricSeries{key: key, labels: labels}
+			index[key] = s
+			out = append(out, s)
+		}
+		s.events = append(s.events, m)
+	}
+	return out
+}
+
+// metricLabels identifies the series of a sample: its tags plus its
+// service.
+func metricLabels(m domain.MetricEvent) map[string]string {
+	labels := make(map[string]string, len(m.Tags)+1)
+	for k, v := range m.Tags {
+		labels[k] = v
+	}
+	labels["service"] = m.Service
+	return labels
+}
+
+// thresholdAggregate returns the aggregation for a threshold rule: the
+// configured one, "all" when the rule has a Duration, otherwise "last".
+func thresholdAggregate(rule domain.Alert) string {
+	if rule.Condition.Aggregate != "" {
+		return rule.Condition.Aggregate
+	}
+	if rule.Condition.Duration != "" {
+		return domain.AggregateAll
+	}
+	return domain.AggregateLast
+}
+
+// anomalyConfig returns the rule's anomaly settings with defaults applied.
+func anomalyConfig(rule domain.Alert) domain.AnomalyConfig {
+	var cfg domain.AnomalyConfig
+	if rule.Condition.Anomaly != nil {
+		cfg = *rule.Condition.Anomaly
+	}
+	if cfg.Method == "" {
+		cfg.Method = domain.BaselineZScore
+	}
+	if cfg.Sensitivity <= 0 {
+		cfg.Sensitivity = 3
+	}
+	if cfg.MinSamples <= 0 {
+		cfg.MinSamples = 30
+	}
+	if cfg.History == "" {
+		cfg.History = "1h"
+	}
+	if cfg.Alpha <= 0 || cfg.Alpha > 1 {
+		cfg.Alpha = 0.3
+	}
+	return cfg
+}
+
 func (d *DetectAnomaly) breached(value float64, operator string, threshold float64) bool {
 	switch operator {
 	case "gt":
@@ -180,37 +1545,16 @@ func (d *DetectAnomaly) breached(value float64, operator string, threshold float
 	}
 }
 
-func (d *DetectAnomaly) fireWebhook(url string, evt *domain.AlertEvent) {
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
-
-	body := fmt.Sprintf(
-		`{"alert_name":"%s","service":"%s","value":%f,"threshold":%f,"status":"%s","triggered_at":"%s"}`,
-		evt.AlertName, evt.Service, evt.Value, evt.Threshold, evt.Status,
-		evt.TriggeredAt.Format(time.RFC3339),
-	)
-
-	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, strings.NewReader(body))
-	if err != nil {
-		d.logger.Error("webhook request build failed", map[string]interface{}{"error": err.Error()})
-		return
+// ruleWindow returns the rule's evaluation window, defaulting to 5 minutes.
+func ruleWindow(rule domain.Alert) time.Duration {
+	if rule.Condition.Duration == "" {
+		return 5 * time.Minute
 	}
-	req.Header.Set("Content-Type", "application/json")
-
-	resp, err := http.DefaultClient.Do(req)
-	if err != nil {
-		d.logger.Error("webhook delivery failed", map[string]interface{}{
-			"url":   url,
-			"error": err.Error(),
-		})
-		return
+	dur, err := parseDuration(rule.Condition.Duration)
+	if err != nil || dur <= 0 {
+		return 5 * time.Minute
 	}
-	resp.Body.Close()
-
-	d.logger.Info("webhook delivered", map[string]interface{}{
-		"url":    url,
-		"status": resp.StatusCode,
-	})
+	return dur
 }
 
 // parseDuration parses duration strings like "5m", "1h", "30s".
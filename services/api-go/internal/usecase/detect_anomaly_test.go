@@ -0,0 +1,681 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"math"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/lightwatch/monitoring-platform/services/api-go/internal/domain"
+	"github.com/lightwatch/monitoring-platform/services/api-go/internal/observability"
+)
+
+// engineStore holds what the engine writes, for engines built by
+// newEngine. Unlike a backtest run it is safe for concurrent workers.
+type engineStore struct {
+	mu            sync.Mutex
+	states        map[string]domain.AlertState
+	events        []domain.AlertEvent
+	notifications []domain.Notification
+}
+
+// memStates implements domain.AlertStatesRepository over an engineStore.
+type memStates struct{ *engineStore }
+
+func (s memStates) Find(_ context.Context, alertID string) (*domain.AlertState, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	st, ok := s.states[alertID]
+	if !ok {
+		return nil, nil
+	}
+	return &st, nil
+}
+
+func (s memStates) FindByAlert(_ context.Context, alertID string) ([]domain.AlertState, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var out []domain.AlertState
+	for _, st := range s.states {
+		if st.ID == alertID || st.AlertID == alertID {
+			out = append(out, st)
+		}
+	}
+	return out, nil
+}
+
+func (s memStates) Save(_ context.Context, st *domain.AlertState) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.states[st.ID] = *st
+	return nil
+}
+
+func (s memStates) Delete(_ context.Context, id string, _ int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.states, id)
+	return nil
+}
+
+// memEvents implements the alert event methods the engine uses; event IDs
+// are indexes into engineStore.events.
+type memEvents struct {
+	domain.AlertEventsRepository
+	*engineStore
+}
+
+func (e memEvents) Create(_ context.Context, evt *domain.AlertEvent) (string, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	evt.ID = strconv.Itoa(len(e.events))
+	e.events = append(e.events, *evt)
+	return evt.ID, nil
+}
+
+func (e memEvents) FindByID(_ context.Context, id string) (*domain.AlertEvent, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	i, err := strconv.Atoi(id)
+	if err != nil || i >= len(e.events) {
+		return nil, domain.ErrNotFound
+	}
+	evt := e.events[i]
+	return &evt, nil
+}
+
+func (e memEvents) Resolve(_ context.Context, id string, at time.Time) (*domain.AlertEvent, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	i, err := strconv.Atoi(id)
+	if err != nil || i >= len(e.events) {
+		return nil, domain.ErrNotFound
+	}
+	e.events[i].Status = domain.AlertStateResolved
+	e.events[i].ResolvedAt = &at
+	evt := e.events[i]
+	return &evt, nil
+}
+
+// memOutbox records the notifications the engine queues.
+type memOutbox struct {
+	domain.NotificationsRepository
+	*engineStore
+}
+
+func (o memOutbox) Create(_ context.Context, n *domain.Notification) (string, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	n.ID = strconv.Itoa(len(o.notifications))
+	o.notifications = append(o.notifications, *n)
+	return n.ID, nil
+}
+
+// staticRules serves a fixed list of enabled rules.
+type staticRules struct {
+	domain.AlertsRepository
+	rules []domain.Alert
+}
+
+func (r staticRules) FindEnabled(context.Context, string) ([]domain.Alert, error) {
+	return r.rules, nil
+}
+
+// newEngine returns an engine over metrics and rules that writes to the
+// returned store, with its clock at *now.
+func newEngine(metrics domain.MetricsRepository, now *time.Time, rules ...domain.Alert) (*DetectAnomaly, *engineStore) {
+	store := &engineStore{states: make(map[string]domain.AlertState)}
+	d := NewDetectAnomaly(staticRules{rules: rules}, memEvents{engineStore: store}, memStates{store}, metrics, nil, nil,
+		memOutbox{engineStore: store}, noSilences{}, nil, nil, nil, nil, nil, observability.Discard())
+	d.clock = func() time.Time { return *now }
+	return d, store
+}
+
+type fixedFence int64
+
+func (f fixedFence) Token() (int64, error) { return int64(f), nil }
+
+// fencedStates refuses writes under a token lower than the stored one, as
+// the Mongo repository does.
+type fencedStates struct {
+	backtestStates
+	stored int64
+}
+
+func (s fencedStates) Save(ctx context.Context, st *domain.AlertState) error {
+	if st.Fence < s.stored {
+		return domain.ErrNotLeader
+	}
+	return s.backtestStates.Save(ctx, st)
+}
+
+func TestApplyStaleLeaderHasNoSideEffects(t *testing.T) {
+	now := time.Date(2026, 10, 17, 9, 0, 0, 0, time.UTC)
+	firing := domain.AlertState{ID: "rule-1", State: domain.AlertStateFiring, EventID: "0", UpdatedAt: now.Add(-time.Hour)}
+	rule := domain.Alert{
+		ID: "rule-1", Name: "CPU",
+		Targets:   []domain.NotificationTarget{{Type: domain.ChannelSlack, URL: "https://hooks.slack.example/1"}},
+		Grouping:  &domain.AlertGrouping{RepeatInterval: "1m"},
+		Condition: domain.AlertCondition{Metric: "cpu", Operator: "gt", Threshold: 90},
+	}
+
+	tests := []struct {
+		name     string
+		state    *domain.AlertState
+		breached bool
+	}{
+		{name: "fire", state: &domain.AlertState{ID: "rule-1", State: domain.AlertStateInactive}, breached: true},
+		{name: "resolve", state: &firing},
+		{name: "renotify", state: &firing, breached: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			run := newBacktestRun()
+			events := backtestEvents{run: run}
+			events.Create(context.Background(), &domain.AlertEvent{Status: domain.AlertStateFiring})
+			d := NewDetectAnomaly(nil, events, fencedStates{backtestStates{run}, 8}, nil, nil, nil,
+				backtestNotifications{run: run}, noSilences{}, nil, nil, nil, fixedFence(7), nil, observability.Discard())
+
+			st := *tt.state
+			err := d.apply(context.Background(), rule, &st, &evaluation{value: 95, threshold: 90, breached: tt.breached}, now)
+			if !errors.Is(err, domain.ErrNotLeader) {
+				t.Fatalf("apply error = %v, want ErrNotLeader", err)
+			}
+			if len(run.events) != 1 || run.events[0].Status != domain.AlertStateFiring {
+				t.Errorf("events changed: %+v", run.events)
+			}
+			if run.notifications != 0 {
+				t.Errorf("%d notifications queued by a stale leader", run.notifications)
+			}
+		})
+	}
+}
+
+func TestRateChangeRule(t *testing.T) {
+	now := time.Date(2026, 10, 17, 9, 0, 0, 0, time.UTC)
+	// A sample a minute over the 10m window, from first to last linearly
+	ramp := func(first, last float64) []domain.MetricEvent {
+		return samples("errors", now.Add(-10*time.Minute), 11, []string{"srv-1"}, func(_ string, i int) float64 {
+			return first + (last-first)*float64(i)/10
+		})
+	}
+	cond := func(change, direction string, threshold float64) domain.AlertCondition {
+		return domain.AlertCondition{Metric: "errors", Operator: "gte", Threshold: threshold, Duration: "10m", Change: change, Direction: direction}
+	}
+
+	tests := []struct {
+		name      string
+		cond      domain.AlertCondition
+		events    []domain.MetricEvent
+		wantValue float64
+		wantFire  bool
+	}{
+		{name: "grew 300%", cond: cond("", domain.DirectionUp, 300), events: ramp(10, 40), wantValue: 300, wantFire: true},
+		{name: "grew 100%", cond: cond("", domain.DirectionUp, 300), events: ramp(10, 20), wantValue: 100},
+		{name: "fell, watching growth", cond: cond("", domain.DirectionUp, 50), events: ramp(40, 10), wantValue: -75},
+		{name: "fell 75%", cond: cond(domain.ChangePercent, domain.DirectionDown, 50), events: ramp(40, 10), wantValue: 75, wantFire: true},
+		{name: "fell, either direction", cond: cond("", "", 50), events: ramp(40, 10), wantValue: 75, wantFire: true},
+		{name: "absolute", cond: cond(domain.ChangeAbsolute, domain.DirectionUp, 25), events: ramp(10, 40), wantValue: 30, wantFire: true},
+		{name: "absolute below threshold", cond: cond(domain.ChangeAbsolute, domain.DirectionUp, 25), events: ramp(10, 30), wantValue: 20},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rule := domain.Alert{ID: "rule-1", Name: "Errors", Type: domain.AlertTypeRateChange, Service: "api", Condition: tt.cond,
+				Targets: []domain.NotificationTarget{{Type: domain.ChannelSlack, URL: "https://hooks.slack.example/1"}}}
+			d, store := newEngine(&memMetrics{events: tt.events}, &now, rule)
+			if err := d.Tick(context.Background(), 30*time.Second); err != nil {
+				t.Fatal(err)
+			}
+
+			st := store.states[rule.ID]
+			if math.Abs(st.LastValue-tt.wantValue) > 1e-9 {
+				t.Errorf("value = %v, want %v", st.LastValue, tt.wantValue)
+			}
+			if fired := st.State == domain.AlertStateFiring; fired != tt.wantFire {
+				t.Fatalf("state = %q, want firing %v", st.State, tt.wantFire)
+			}
+			if !tt.wantFire {
+				return
+			}
+			if len(store.events) != 1 || len(store.notifications) != 1 {
+				t.Fatalf("%d events and %d notifications, want one each", len(store.events), len(store.notifications))
+			}
+			meta := store.events[0].Meta
+			if meta["start_value"] != tt.events[0].Value || meta["end_value"] != tt.events[len(tt.events)-1].Value {
+				t.Errorf("event meta = %v", meta)
+			}
+		})
+	}
+}
+
+func TestRateChangeRuleResolves(t *testing.T) {
+	now := time.Date(2026, 10, 17, 9, 0, 0, 0, time.UTC)
+	start := now.Add(-10 * time.Minute)
+	metrics := &memMetrics{events: samples("errors", start, 21, []string{"srv-1"}, func(_ string, i int) float64 {
+		return 10 * math.Pow(2, math.Min(float64(i), 10)/5) // 10 to 40 over the first 10m, then flat
+	})}
+	rule := domain.Alert{
+		ID: "rule-1", Name: "Errors", Type: domain.AlertTypeRateChange, Service: "api",
+		Targets:   []domain.NotificationTarget{{Type: domain.ChannelSlack, URL: "https://hooks.slack.example/1"}},
+		Condition: domain.AlertCondition{Metric: "errors", Operator: "gte", Threshold: 300, Duration: "10m", Direction: domain.DirectionUp},
+	}
+	d, store := newEngine(metrics, &now, rule)
+
+	for i, want := range []string{domain.AlertStateFiring, domain.AlertStateResolved} {
+		if err := d.Tick(context.Background(), 10*time.Minute); err != nil {
+			t.Fatal(err)
+		}
+		if got := store.states[rule.ID].State; got != want {
+			t.Fatalf("tick %d: state = %q, want %q", i, got, want)
+		}
+		now = now.Add(10 * time.Minute)
+	}
+	if len(store.events) != 1 || store.events[0].ResolvedAt == nil {
+		t.Fatalf("events = %+v, want one resolved", store.events)
+	}
+	if len(store.notifications) != 2 {
+		t.Errorf("%d notifications, want firing and resolved", len(store.notifications))
+	}
+}
+
+func TestThresholdForClause(t *testing.T) {
+	now := time.Date(2026, 10, 17, 9, 0, 0, 0, time.UTC)
+	// series has one cpu sample a minute from oldest minutes ago to now,
+	// valued by value(minutes ago)
+	series := func(oldest int, value func(ago int) float64) []domain.MetricEvent {
+		return samples("cpu", now.Add(-time.Duration(oldest)*time.Minute), oldest+1, []string{"srv-1"}, func(_ string, i int) float64 {
+			return value(oldest - i)
+		})
+	}
+	flat := series(10, func(int) float64 { return 95 })
+	spike := series(10, func(ago int) float64 {
+		if ago == 0 {
+			return 99
+		}
+		return 40
+	})
+	dip := series(10, func(ago int) float64 {
+		if ago == 2 {
+			return 85
+		}
+		return 95
+	})
+
+	tests := []struct {
+		name      string
+		events    []domain.MetricEvent
+		cond      domain.AlertCondition
+		wantValue float64
+		wantFire  bool
+	}{
+		{name: "latest sample", events: spike, cond: domain.AlertCondition{Operator: "gt", Threshold: 90}, wantValue: 99, wantFire: true},
+		{name: "every sample breaches", events: flat, cond: domain.AlertCondition{Operator: "gt", Threshold: 90, Duration: "5m"}, wantValue: 95, wantFire: true},
+		{name: "spike is not sustained", events: spike, cond: domain.AlertCondition{Operator: "gt", Threshold: 90, Duration: "5m"}, wantValue: 40},
+		{name: "one sample dips", events: dip, cond: domain.AlertCondition{Operator: "gt", Threshold: 90, Duration: "5m"}, wantValue: 85},
+		{name: "below, one sample above", events: dip, cond: domain.AlertCondition{Operator: "lt", Threshold: 90, Duration: "5m"}, wantValue: 95},
+		{name: "avg over a dip", events: dip, cond: domain.AlertCondition{Operator: "gt", Threshold: 90, Duration: "5m", Aggregate: domain.AggregateAvg}, wantValue: 655.0 / 7, wantFire: true},
+		{name: "min over a dip", events: dip, cond: domain.AlertCondition{Operator: "gt", Threshold: 90, Duration: "5m", Aggregate: domain.AggregateMin}, wantValue: 85},
+		{name: "max over a spike", events: spike, cond: domain.AlertCondition{Operator: "gt", Threshold: 90, Duration: "5m", Aggregate: domain.AggregateMax}, wantValue: 99, wantFire: true},
+		{name: "window not covered", events: series(3, func(int) float64 { return 95 }), cond: domain.AlertCondition{Operator: "gt", Threshold: 90, Duration: "5m"}, wantValue: 95},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.cond.Metric = "cpu"
+			rule := domain.Alert{ID: "rule-1", Name: "CPU", Service: "api", Condition: tt.cond}
+			d, _ := newEngine(&memMetrics{events: tt.events}, &now, rule)
+
+			evs, _, err := d.check(context.Background(), rule)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(evs) != 1 {
+				t.Fatalf("%d evaluations, want 1", len(evs))
+			}
+			if math.Abs(evs[0].value-tt.wantValue) > 1e-9 || evs[0].breached != tt.wantFire {
+				t.Fatalf("value = %v, breached = %v; want %v, %v", evs[0].value, evs[0].breached, tt.wantValue, tt.wantFire)
+			}
+		})
+	}
+}
+
+// memLogs serves log queries from a slice, newest first, as the Mongo
+// repository would.
+type memLogs struct {
+	logs []domain.LogEvent
+}
+
+func (m memLogs) Find(_ context.Context, f domain.LogsFilter) ([]domain.LogEvent, int64, error) {
+	from, _ := time.Parse(time.RFC3339, f.From)
+	to, _ := time.Parse(time.RFC3339, f.To)
+	pattern := regexp.MustCompile("(?i)" + f.Query)
+	var out []domain.LogEvent
+	for _, l := range m.logs {
+		if (f.Service != "" && l.Service != f.Service) || (f.Level != "" && l.Level != f.Level) || !pattern.MatchString(l.Message) {
+			continue
+		}
+		if l.Timestamp.Before(from) || l.Timestamp.After(to) || !matchTags(f.Tags, l.Tags) {
+			continue
+		}
+		out = append(out, l)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Timestamp.After(out[j].Timestamp) })
+	total := int64(len(out))
+	return out[:min(f.Limit, len(out))], total, nil
+}
+
+func matchTags(want, tags map[string]string) bool {
+	for k, v := range want {
+		if tags[k] != v {
+			return false
+		}
+	}
+	return true
+}
+
+func TestLogRule(t *testing.T) {
+	now := time.Date(2026, 10, 17, 9, 0, 0, 0, time.UTC)
+	entry := func(ago time.Duration, service, level, message, region string) domain.LogEvent {
+		return domain.LogEvent{Service: service, Level: level, Message: message, Tags: map[string]string{"region": region}, Timestamp: now.Add(-ago)}
+	}
+	logs := memLogs{logs: []domain.LogEvent{
+		entry(time.Minute, "payment", "error", "upstream TIMEOUT after 30s", "eu"),
+		entry(2*time.Minute, "payment", "error", "upstream timeout after 30s", "us"),
+		entry(3*time.Minute, "payment", "error", "db timeout", "us"),
+		entry(3*time.Minute, "payment", "warn", "upstream timeout after 30s", "us"),
+		entry(4*time.Minute, "payment", "error", "card declined", "us"),
+		entry(4*time.Minute, "checkout", "error", "upstream timeout after 30s", "us"),
+		entry(10*time.Minute, "payment", "error", "upstream timeout after 30s", "us"),
+		entry(30*time.Second, "payment", "fatal", "java.lang.OutOfMemoryError: Java heap space", "us"),
+	}}
+
+	tests := []struct {
+		name        string
+		operator    string
+		threshold   float64
+		query       domain.LogQuery
+		wantCount   float64
+		wantSamples []string
+	}{
+		{name: "error count over threshold", operator: "gt", threshold: 2, query: domain.LogQuery{Level: "error", Pattern: "timeout"}, wantCount: 3,
+			wantSamples: []string{"upstream TIMEOUT after 30s", "upstream timeout after 30s", "db timeout"}},
+		{name: "count within threshold", operator: "gt", threshold: 2, query: domain.LogQuery{Level: "error", Pattern: "timeout", Tags: map[string]string{"region": "us"}}, wantCount: 2},
+		{name: "any match", operator: "gte", threshold: 1, query: domain.LogQuery{Pattern: "OutOfMemory"}, wantCount: 1,
+			wantSamples: []string{"java.lang.OutOfMemoryError: Java heap space"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			now := now
+			rule := domain.Alert{
+				ID: "rule-1", Name: "Payment errors", Type: domain.AlertTypeLog, Service: "payment",
+				Condition: domain.AlertCondition{Operator: tt.operator, Threshold: tt.threshold, Duration: "5m", Logs: &tt.query},
+			}
+			d, store := newEngine(&memMetrics{}, &now, rule)
+			d.logs = logs
+			if err := d.Tick(context.Background(), time.Minute); err != nil {
+				t.Fatal(err)
+			}
+
+			st := store.states[rule.ID]
+			if st.LastValue != tt.wantCount {
+				t.Errorf("count = %v, want %v", st.LastValue, tt.wantCount)
+			}
+			if fired := st.State == domain.AlertStateFiring; fired != (tt.wantSamples != nil) {
+				t.Fatalf("state = %q", st.State)
+			}
+			if tt.wantSamples == nil {
+				return
+			}
+			samples, _ := store.events[0].Meta["samples"].([]map[string]interface{})
+			var got []string
+			for _, s := range samples {
+				got = append(got, s["message"].(string))
+			}
+			if !reflect.DeepEqual(got, tt.wantSamples) {
+				t.Errorf("samples = %q, want %q", got, tt.wantSamples)
+			}
+
+			// Once the logs stop, the count drops to zero and the rule resolves
+			now = now.Add(10 * time.Minute)
+			if err := d.Tick(context.Background(), time.Minute); err != nil {
+				t.Fatal(err)
+			}
+			if st := store.states[rule.ID]; st.State != domain.AlertStateResolved || st.LastValue != 0 {
+				t.Errorf("after the logs stop: state = %q, count = %v", st.State, st.LastValue)
+			}
+		})
+	}
+}
+
+// memSecurity counts security events from a slice, as the Mongo
+// repository's aggregation would.
+type memSecurity struct {
+	domain.SecurityRepository
+	events []domain.SecurityEvent
+}
+
+func (m memSecurity) Count(_ context.Context, f domain.SecurityFilter, groupBy []string) ([]domain.SecurityCount, error) {
+	from, _ := time.Parse(time.RFC3339, f.From)
+	to, _ := time.Parse(time.RFC3339, f.To)
+	counts := make(map[string]*domain.SecurityCount)
+	var order []string
+	for _, e := range m.events {
+		if (f.Service != "" && e.Service != f.Service) || (f.IP != "" && e.SourceIP != f.IP) ||
+			(f.Type != "" && e.Type != f.Type) || (f.Severity != "" && e.Severity != f.Severity) ||
+			e.Timestamp.Before(from) || e.Timestamp.After(to) {
+			continue
+		}
+		key := make(map[string]string)
+		for _, field := range groupBy {
+			key[field] = map[string]string{
+				domain.SecurityKeySourceIP: e.SourceIP,
+				domain.SecurityKeyService:  e.Service,
+				domain.SecurityKeyType:     e.Type,
+			}[field]
+		}
+		id := seriesKey(key)
+		c, ok := counts[id]
+		if !ok {
+			c = &domain.SecurityCount{Key: key, FirstSeen: e.Timestamp, LastSeen: e.Timestamp}
+			counts[id] = c
+			order = append(order, id)
+		}
+		c.Count++
+		if e.Timestamp.Before(c.FirstSeen) {
+			c.FirstSeen = e.Timestamp
+		}
+		if e.Timestamp.After(c.LastSeen) {
+			c.LastSeen = e.Timestamp
+		}
+	}
+	out := make([]domain.SecurityCount, 0, len(order))
+	for _, id := range order {
+		out = append(out, *counts[id])
+	}
+	sort.SliceStable(out, func(i, j int) bool { return out[i].Count > out[j].Count })
+	return out[:min(f.Limit, len(out))], nil
+}
+
+func TestSecurityRulePerIP(t *testing.T) {
+	now := time.Date(2026, 10, 17, 9, 0, 0, 0, time.UTC)
+	security := &memSecurity{}
+	attempts := func(ip, typ string, n int, at time.Time) {
+		for i := 0; i < n; i++ {
+			security.events = append(security.events, domain.SecurityEvent{
+				Service: "auth", Type: typ, SourceIP: ip, Severity: "medium", Timestamp: at.Add(-time.Duration(i) * time.Second),
+			})
+		}
+	}
+	rule := domain.Alert{
+		ID: "rule-1", Name: "Brute force", Type: domain.AlertTypeSecurity, Service: "auth",
+		Targets: []domain.NotificationTarget{{Type: domain.ChannelSlack, URL: "https://hooks.slack.example/1"}},
+		Condition: domain.AlertCondition{Operator: "gte", Threshold: 20, Duration: "2m", Security: &domain.SecurityQuery{
+			Type: "auth_failure", GroupBy: []string{domain.SecurityKeySourceIP},
+		}},
+	}
+	d, store := newEngine(&memMetrics{}, &now, rule)
+	d.security = security
+	tick := func() {
+		t.Helper()
+		if err := d.Tick(context.Background(), time.Minute); err != nil {
+			t.Fatal(err)
+		}
+	}
+	state := func(ip string) domain.AlertState {
+		return store.states[keyStateID(rule.ID, map[string]string{domain.SecurityKeySourceIP: ip})]
+	}
+
+	// One address crosses the threshold; another fails too rarely, and
+	// logins that succeed do not count
+	attempts("203.0.113.7", "auth_failure", 25, now)
+	attempts("198.51.100.4", "auth_failure", 5, now)
+	attempts("198.51.100.4", "login_success", 30, now)
+	tick()
+	if st := state("203.0.113.7"); st.State != domain.AlertStateFiring || st.LastValue != 25 {
+		t.Fatalf("203.0.113.7: state = %q, count = %v", st.State, st.LastValue)
+	}
+	if st, ok := store.states[keyStateID(rule.ID, map[string]string{domain.SecurityKeySourceIP: "198.51.100.4"})]; ok {
+		t.Fatalf("198.51.100.4 has a state below the threshold: %+v", st)
+	}
+	if len(store.events) != 1 {
+		t.Fatalf("%d events, want 1", len(store.events))
+	}
+	evt := store.events[0]
+	if evt.Labels[domain.SecurityKeySourceIP] != "203.0.113.7" {
+		t.Errorf("event labels = %v", evt.Labels)
+	}
+	if key, _ := evt.Meta["key"].(map[string]string); key[domain.SecurityKeySourceIP] != "203.0.113.7" {
+		t.Errorf("event meta key = %v", evt.Meta["key"])
+	}
+
+	// A second address fires on its own, without touching the first
+	now = now.Add(time.Minute)
+	attempts("198.51.100.4", "auth_failure", 20, now)
+	tick()
+	if st := state("198.51.100.4"); st.State != domain.AlertStateFiring {
+		t.Fatalf("198.51.100.4: state = %q", st.State)
+	}
+	if len(store.events) != 2 || len(store.notifications) != 2 {
+		t.Fatalf("%d events and %d notifications, want one firing each per address", len(store.events), len(store.notifications))
+	}
+
+	// Both resolve once their attempts leave the window
+	now = now.Add(5 * time.Minute)
+	tick()
+	if len(store.states) != 0 {
+		t.Errorf("states left after resolving: %v", store.states)
+	}
+	for _, evt := range store.events {
+		if evt.Status != domain.AlertStateResolved {
+			t.Errorf("event for %v is %s", evt.Labels, evt.Status)
+		}
+	}
+}
+
+func TestCompositeRule(t *testing.T) {
+	now := time.Date(2026, 10, 17, 9, 0, 0, 0, time.UTC)
+	metric := func(name string, value float64) []domain.MetricEvent {
+		return samples(name, now.Add(-time.Minute), 1, []string{"srv-1"}, func(string, int) float64 { return value })
+	}
+	leaf := func(name, metric string, threshold float64) domain.ConditionNode {
+		return domain.ConditionNode{Name: name, Condition: &domain.AlertCondition{Metric: metric, Operator: "gt", Threshold: threshold}}
+	}
+	cpu, memory := leaf("cpu", "cpu", 90), leaf("memory", "memory", 85)
+	errorLogs := domain.ConditionNode{Type: domain.AlertTypeLog, Condition: &domain.AlertCondition{
+		Operator: "gte", Threshold: 1, Logs: &domain.LogQuery{Level: "error"},
+	}}
+
+	tests := []struct {
+		name      string
+		cond      domain.AlertCondition
+		metrics   []domain.MetricEvent
+		logs      []domain.LogEvent
+		wantState string          // "" if the rule had no data
+		wantValue float64         // of the first breached condition
+		want      map[string]bool // breached per condition; absent if without data
+	}{
+		{
+			name:      "and, both breach",
+			cond:      domain.AlertCondition{All: []domain.ConditionNode{cpu, memory}},
+			metrics:   append(metric("cpu", 95), metric("memory", 90)...),
+			wantState: domain.AlertStateFiring, wantValue: 95,
+			want: map[string]bool{"cpu": true, "memory": true},
+		},
+		{
+			name:      "and, one breaches",
+			cond:      domain.AlertCondition{All: []domain.ConditionNode{cpu, memory}},
+			metrics:   append(metric("cpu", 95), metric("memory", 50)...),
+			wantState: domain.AlertStateInactive, wantValue: 95,
+			want: map[string]bool{"cpu": true, "memory": false},
+		},
+		{
+			name:      "or, one breaches",
+			cond:      domain.AlertCondition{Any: []domain.ConditionNode{cpu, memory}},
+			metrics:   append(metric("cpu", 50), metric("memory", 90)...),
+			wantState: domain.AlertStateFiring, wantValue: 90,
+			want: map[string]bool{"cpu": false, "memory": true},
+		},
+		{
+			name:      "nested, with a log condition",
+			cond:      domain.AlertCondition{All: []domain.ConditionNode{cpu, {Any: []domain.ConditionNode{memory, errorLogs}}}},
+			metrics:   append(metric("cpu", 95), metric("memory", 50)...),
+			logs:      []domain.LogEvent{{Service: "api", Level: "error", Message: "out of memory", Timestamp: now.Add(-time.Minute)}},
+			wantState: domain.AlertStateFiring, wantValue: 95,
+			want: map[string]bool{"cpu": true, "memory": false, "all[1].any[1]": true},
+		},
+		{
+			name:      "condition without data",
+			cond:      domain.AlertCondition{All: []domain.ConditionNode{cpu, memory}},
+			metrics:   metric("cpu", 95),
+			wantState: domain.AlertStateInactive, wantValue: 95,
+			want: map[string]bool{"cpu": true},
+		},
+		{
+			name: "no data",
+			cond: domain.AlertCondition{Any: []domain.ConditionNode{cpu, memory}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rule := domain.Alert{ID: "rule-1", Name: "Host pressure", Type: domain.AlertTypeComposite, Service: "api", Condition: tt.cond}
+			d, store := newEngine(&memMetrics{events: tt.metrics}, &now, rule)
+			d.logs = memLogs{logs: tt.logs}
+
+			evs, _, err := d.check(context.Background(), rule)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.wantState == "" {
+				if len(evs) != 0 {
+					t.Fatalf("evaluation without data: %+v", evs[0])
+				}
+				return
+			}
+			got := make(map[string]bool)
+			for _, r := range evs[0].meta["conditions"].([]map[string]interface{}) {
+				if r["no_data"] != true {
+					got[r["name"].(string)] = r["breached"].(bool)
+				}
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("conditions = %v, want %v", got, tt.want)
+			}
+
+			if err := d.Tick(context.Background(), time.Minute); err != nil {
+				t.Fatal(err)
+			}
+			st := store.states[rule.ID]
+			if st.State != tt.wantState || st.LastValue != tt.wantValue {
+				t.Fatalf("state = %q, value = %v; want %q, %v", st.State, st.LastValue, tt.wantState, tt.wantValue)
+			}
+			if tt.wantState == domain.AlertStateFiring && store.events[0].Meta["conditions"] == nil {
+				t.Errorf("event meta lacks the conditions: %v", store.events[0].Meta)
+			}
+		})
+	}
+}
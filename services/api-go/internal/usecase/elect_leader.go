@@ -0,0 +1,220 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/lightwatch/monitoring-platform/services/api-go/internal/domain"
+	"github.com/lightwatch/monitoring-platform/services/api-go/internal/observability"
+)
+
+// EngineLease is the lease whose holder runs the alert engine.
+const EngineLease = "alert-engine"
+
+// minLeaseTTL bounds the lease TTL from below, so the renewal period of a
+// third of it is at least a second.
+const minLeaseTTL = 3 * time.Second
+
+// ElectLeader makes sure only one replica runs work that must not be
+// duplicated, such as the alert engine. Replicas compete for a lease; the
+// holder renews it every third of its TTL and leads until it fails to.
+//
+// Leadership is judged on the local clock with a safety margin: a leader
+// whose last renewal is older than nine tenths of the TTL stops and its
+// fencing token is refused, before the store can hand the lease to another
+// replica. Writes carrying the token are refused by the store once a newer
+// leader has written, covering a leader that stalls past that point.
+type ElectLeader struct {
+	leases   domain.LeasesRepository // nil: every replica leads
+	backend  string
+	name     string
+	instance string
+	ttl      time.Duration
+	logger   *observability.Logger
+
+	mu       sync.Mutex
+	token    int64
+	deadline time.Time // leadership is void after this
+	done     chan struct{}
+}
+
+// NewElectLeader creates leader election for the named lease. leases may
+// be nil to disable election, making every replica a leader. A ttl below
+// three seconds is raised to three seconds.
+func NewElectLeader(leases domain.LeasesRepository, backend, name, instance string, ttl time.Duration, logger *observability.Logger) *ElectLeader {
+	if leases == nil {
+		backend = domain.LeaderElectionNone
+	}
+	if ttl < minLeaseTTL {
+		ttl = minLeaseTTL
+	}
+	return &ElectLeader{
+		leases:   leases,
+		backend:  backend,
+		name:     name,
+		instance: instance,
+		ttl:      ttl,
+		logger:   logger,
+		done:     make(chan struct{}),
+	}
+}
+
+// Run campaigns for the lease in a background goroutine until ctx is
+// cancelled. Whenever this replica becomes leader, lead is started with a
+// context that is cancelled when leadership is lost or ctx ends. On
+// shutdown the lease is released so another replica takes over at once;
+// Wait blocks until that is done.
+func (e *ElectLeader) Run(ctx context.Context, lead func(ctx context.Context)) {
+	if e.leases == nil {
+		go func() {
+			defer close(e.done)
+			lead(ctx)
+			<-ctx.Done()
+		}()
+		return
+	}
+
+	go func() {
+		defer close(e.done)
+		ticker := time.NewTicker(e.ttl / 3)
+		defer ticker.Stop()
+
+		e.logger.Info("leader election started", map[string]interface{}{
+			"backend":  e.backend,
+			"lease":    e.name,
+			"instance": e.instance,
+			"ttl":      e.ttl.String(),
+		})
+
+		var stop context.CancelFunc
+		for {
+			leading := e.campaign(ctx)
+			switch {
+			case leading && stop == nil:
+				var leadCtx context.Context
+				leadCtx, stop = context.WithCancel(ctx)
+				lead(leadCtx)
+			case !leading && stop != nil:
+				stop()
+				stop = nil
+			}
+
+			select {
+			case <-ctx.Done():
+				if stop != nil {
+					stop()
+				}
+				e.stepDown()
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// Wait blocks until Run has stepped down after its context was cancelled,
+// or until ctx ends.
+func (e *ElectLeader) Wait(ctx context.Context) {
+	select {
+	case <-e.done:
+	case <-ctx.Done():
+	}
+}
+
+// campaign acquires or renews the lease once and reports whether this
+// replica leads.
+func (e *ElectLeader) campaign(ctx context.Context) bool {
+	start := time.Now()
+	lease, err := e.leases.Acquire(ctx, e.name, e.instance, e.ttl)
+	if err != nil && !errors.Is(err, domain.ErrNotFound) {
+		if ctx.Err() == nil {
+			e.logger.Warn("leader lease renewal failed", map[string]interface{}{
+				"lease": e.name,
+				"error": err.Error(),
+			})
+		}
+		// Keep leading until the deadline; the next attempt may succeed
+		e.mu.Lock()
+		defer e.mu.Unlock()
+		return e.token > 0 && time.Now().Before(e.deadline)
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if lease == nil || lease.Holder != e.instance {
+		if e.token > 0 {
+			e.logger.Warn("lost leadership", map[string]interface{}{"lease": e.name})
+		}
+		e.token, e.deadline = 0, time.Time{}
+		return false
+	}
+	if e.token != lease.Token {
+		e.logger.Info("became leader", map[string]interface{}{
+			"lease": e.name,
+			"token": lease.Token,
+		})
+	}
+	// Measured from before the request, as the store's TTL may have
+	// started any time after it was sent
+	e.token, e.deadline = lease.Token, start.Add(e.ttl*9/10)
+	return true
+}
+
+// stepDown releases the lease if this replica holds it.
+func (e *ElectLeader) stepDown() {
+	e.mu.Lock()
+	token := e.token
+	e.token, e.deadline = 0, time.Time{}
+	e.mu.Unlock()
+	if token == 0 {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := e.leases.Release(ctx, e.name, e.instance, token); err != nil {
+		e.logger.Warn("leader lease release failed", map[string]interface{}{
+			"lease": e.name,
+			"error": err.Error(),
+		})
+		return
+	}
+	e.logger.Info("stepped down as leader", map[string]interface{}{"lease": e.name})
+}
+
+// Token implements domain.Fence. It returns domain.ErrNotLeader unless this
+// replica holds the lease and renewed it in time.
+func (e *ElectLeader) Token() (int64, error) {
+	if e.leases == nil {
+		return 0, nil
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.token == 0 || !time.Now().Before(e.deadline) {
+		return 0, fmt.Errorf("%w: %s lease not held by %s", domain.ErrNotLeader, e.name, e.instance)
+	}
+	return e.token, nil
+}
+
+// Status reports the lease holder as stored, and whether this replica
+// leads.
+func (e *ElectLeader) Status(ctx context.Context) (*domain.LeaderStatus, error) {
+	status := &domain.LeaderStatus{Backend: e.backend, InstanceID: e.instance}
+	_, err := e.Token()
+	status.Leader = err == nil
+	if e.leases == nil {
+		return status, nil
+	}
+
+	lease, err := e.leases.Find(ctx, e.name)
+	if err != nil && !errors.Is(err, domain.ErrNotFound) {
+		return nil, err
+	}
+	if lease != nil && lease.ExpiresAt.After(time.Now()) {
+		status.Lease = lease
+	}
+	return status, nil
+}
@@ -0,0 +1,68 @@
+package usecase
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/lightwatch/monitoring-platform/services/api-go/internal/domain"
+	"github.com/lightwatch/monitoring-platform/services/api-go/internal/observability"
+)
+
+// soleLeases grants the lease to whoever asks, as a store with one replica
+// would.
+type soleLeases struct {
+	mu    sync.Mutex
+	lease *domain.Lease
+}
+
+func (l *soleLeases) Acquire(_ context.Context, name, holder string, ttl time.Duration) (*domain.Lease, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	now := time.Now()
+	if l.lease == nil || l.lease.Holder != holder {
+		l.lease = &domain.Lease{Name: name, Holder: holder, Token: 1, AcquiredAt: now}
+	}
+	l.lease.RenewedAt, l.lease.ExpiresAt = now, now.Add(ttl)
+	return l.lease, nil
+}
+
+func (l *soleLeases) Release(context.Context, string, string, int64) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.lease = nil
+	return nil
+}
+
+func (l *soleLeases) Find(context.Context, string) (*domain.Lease, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.lease == nil {
+		return nil, domain.ErrNotFound
+	}
+	return l.lease, nil
+}
+
+func TestElectLeaderShortTTL(t *testing.T) {
+	for _, ttl := range []time.Duration{0, time.Second, 2 * time.Second} {
+		e := NewElectLeader(&soleLeases{}, domain.LeaderElectionMongo, EngineLease, "replica-1", ttl, observability.Discard())
+		if e.ttl != minLeaseTTL {
+			t.Fatalf("ttl %s raised to %s, want %s", ttl, e.ttl, minLeaseTTL)
+		}
+
+		ctx, cancel := context.WithCancel(context.Background())
+		led := make(chan struct{})
+		e.Run(ctx, func(context.Context) { close(led) })
+		select {
+		case <-led:
+		case <-time.After(time.Second):
+			t.Fatalf("ttl %s: did not lead", ttl)
+		}
+		if token, err := e.Token(); err != nil || token != 1 {
+			t.Fatalf("Token = %d, %v", token, err)
+		}
+		cancel()
+		e.Wait(context.Background())
+	}
+}
@@ -0,0 +1,256 @@
+package usecase
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	htmltemplate "html/template"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/smtp"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/lightwatch/monitoring-platform/services/api-go/internal/domain"
+	"github.com/lightwatch/monitoring-platform/services/api-go/pkg/webhook"
+)
+
+// smtpTimeout bounds one SMTP conversation; it must stay below notifyLease.
+const smtpTimeout = 20 * time.Second
+
+// SMTPSettings configures the email channel.
+type SMTPSettings struct {
+	Host     string
+	Port     int
+	Username string // no AUTH when empty
+	Password string
+	From     string
+	StartTLS bool          // upgrade the connection before AUTH; fail if the relay cannot
+	Digest   time.Duration // collect emails per recipient list this long; 0 sends immediately
+}
+
+// EmailNotifier sends alert emails through an SMTP relay as multipart
+// plaintext + HTML messages. As a BatchNotifier it combines transitions
+// queued for the same recipients — all of them within a digest interval,
+// or just those due at once (e.g. after a relay outage) without one.
+type EmailNotifier struct {
+	cfg SMTPSettings
+}
+
+// NewEmailNotifier creates the email channel.
+func NewEmailNotifier(cfg SMTPSettings) *EmailNotifier {
+	return &EmailNotifier{cfg: cfg}
+}
+
+// Notify implements Notifier.
+func (en *EmailNotifier) Notify(ctx context.Context, n *domain.Notification, p webhook.Payload) error {
+	return en.NotifyBatch(ctx, []*domain.Notification{n}, []webhook.Payload{p})
+}
+
+// BatchWindow implements BatchNotifier.
+func (en *EmailNotifier) BatchWindow() time.Duration {
+	return en.cfg.Digest
+}
+
+// NotifyBatch implements GroupNotifier. All notifications share one
+// target, so they are sent as a single email: a digest, or an alert group
+// headed by its group labels.
+func (en *EmailNotifier) NotifyBatch(ctx context.Context, ns []*domain.Notification, ps []webhook.Payload) error {
+	to := strings.Split(ns[0].Target, ",")
+
+	subject := emailSubject(ps)
+	if g := ns[0].Group; g != nil {
+		ps = latestPerEvent(ns, ps)
+		subject = groupTitle(g, ps)
+	}
+	data := emailData{Subject: subject, Alerts: ps}
+	var text, html bytes.Buffer
+	if err := emailTextTemplate.Execute(&text, data); err != nil {
+		return fmt.Errorf("render email: %w", err)
+	}
+	if err := emailHTMLTemplate.Execute(&html, data); err != nil {
+		return fmt.Errorf("render email: %w", err)
+	}
+
+	msg, err := buildEmail(en.cfg.From, to, subject, text.Bytes(), html.Bytes(), time.Now())
+	if err != nil {
+		return err
+	}
+	return en.send(ctx, to, msg)
+}
+
+// send delivers msg in one SMTP session. net/smtp has no context support,
+// so the connection deadline is derived from ctx and smtpTimeout.
+func (en *EmailNotifier) send(ctx context.Context, to []string, msg []byte) error {
+	addr := net.JoinHostPort(en.cfg.Host, strconv.Itoa(en.cfg.Port))
+	dialer := net.Dialer{Timeout: smtpTimeout}
+	conn, err := dialer.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return err
+	}
+	deadline := time.Now().Add(smtpTimeout)
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+		deadline = d
+	}
+	conn.SetDeadline(deadline)
+
+	c, err := smtp.NewClient(conn, en.cfg.Host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer c.Close()
+
+	if en.cfg.StartTLS {
+		if ok, _ := c.Extension("STARTTLS"); !ok {
+			return errors.New("smtp relay does not support STARTTLS")
+		}
+		if err := c.StartTLS(&tls.Config{ServerName: en.cfg.Host}); err != nil {
+			return fmt.Errorf("starttls: %w", err)
+		}
+	}
+	// PlainAuth refuses to send credentials over an unencrypted
+	// connection, except to localhost.
+	if en.cfg.Username != "" {
+		if err := c.Auth(smtp.PlainAuth("", en.cfg.Username, en.cfg.Password, en.cfg.Host)); err != nil {
+			return fmt.Errorf("auth: %w", err)
+		}
+	}
+
+	if err := c.Mail(en.cfg.From); err != nil {
+		return err
+	}
+	for _, rcpt := range to {
+		if err := c.Rcpt(rcpt); err != nil {
+			return err
+		}
+	}
+	w, err := c.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(msg); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return c.Quit()
+}
+
+func emailSubject(ps []webhook.Payload) string {
+	if len(ps) == 1 {
+		p := ps[0]
+		return "[" + strings.ToUpper(p.Status) + "] " + p.AlertName + " (" + p.Service + ")"
+	}
+	var open int
+	for _, p := range ps {
+		if firing(p) {
+			open++
+		}
+	}
+	return fmt.Sprintf("Lightwatch digest: %d alert updates (%d firing)", len(ps), open)
+}
+
+// buildEmail assembles a multipart/alternative message. The subject is
+// Q-encoded, which also neutralises line breaks in rule names.
+func buildEmail(from string, to []string, subject string, text, html []byte, now time.Time) ([]byte, error) {
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	for _, part := range []struct {
+		contentType string
+		content     []byte
+	}{
+		{"text/plain; charset=utf-8", text},
+		{"text/html; charset=utf-8", html},
+	} {
+		pw, err := mw.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {part.contentType},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		})
+		if err != nil {
+			return nil, err
+		}
+		qw := quotedprintable.NewWriter(pw)
+		if _, err := qw.Write(part.content); err != nil {
+			return nil, err
+		}
+		if err := qw.Close(); err != nil {
+			return nil, err
+		}
+	}
+	if err := mw.Close(); err != nil {
+		return nil, err
+	}
+
+	var msg bytes.Buffer
+	fmt.Fprintf(&msg, "From: %s\r\n", from)
+	fmt.Fprintf(&msg, "To: %s\r\n", strings.Join(to, ", "))
+	fmt.Fprintf(&msg, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", subject))
+	fmt.Fprintf(&msg, "Date: %s\r\n", now.Format(time.RFC1123Z))
+	msg.WriteString("MIME-Version: 1.0\r\n")
+	fmt.Fprintf(&msg, "Content-Type: multipart/alternative; boundary=%q\r\n\r\n", mw.Boundary())
+	msg.Write(body.Bytes())
+	return msg.Bytes(), nil
+}
+
+// emailData is the input of the email templates. Alerts holds one entry
+// per transition; more than one means a digest.
+type emailData struct {
+	Subject string
+	Alerts  []webhook.Payload
+}
+
+var emailFuncs = map[string]interface{}{
+	"upper": strings.ToUpper,
+	"value": formatValue,
+	"rfc3339": func(t time.Time) string {
+		return t.UTC().Format(time.RFC3339)
+	},
+	"labels": seriesLabels,
+}
+
+var emailTextTemplate = template.Must(template.New("text").Funcs(emailFuncs).Parse(
+	`{{.Subject}}
+{{range .Alerts}}
+[{{upper .Status}}] {{.AlertName}}
+  Service:   {{.Service}}
+  Value:     {{value .Value}} (threshold {{value .Threshold}})
+  Triggered: {{rfc3339 .TriggeredAt}}
+{{- with .ResolvedAt}}
+  Resolved:  {{rfc3339 .}}{{end}}
+{{- with labels .Labels}}
+  Labels:    {{.}}{{end}}
+{{- with .Link}}
+  Details:   {{.}}{{end}}
+{{end}}`))
+
+var emailHTMLTemplate = htmltemplate.Must(htmltemplate.New("html").Funcs(emailFuncs).Parse(
+	`<!DOCTYPE html>
+<html>
+<body style="font-family: sans-serif; color: #222;">
+<h2>{{.Subject}}</h2>
+{{range .Alerts}}
+<table style="border-collapse: collapse; margin-bottom: 16px; border-left: 4px solid {{if eq .Status "firing"}}#d00000{{else}}#2eb886{{end}}; padding-left: 8px;">
+<tr><td colspan="2"><strong>[{{upper .Status}}] {{.AlertName}}</strong></td></tr>
+<tr><td style="padding-right: 12px;">Service</td><td>{{.Service}}</td></tr>
+<tr><td style="padding-right: 12px;">Value</td><td>{{value .Value}} (threshold {{value .Threshold}})</td></tr>
+<tr><td style="padding-right: 12px;">Triggered</td><td>{{rfc3339 .TriggeredAt}}</td></tr>
+{{- with .ResolvedAt}}
+<tr><td style="padding-right: 12px;">Resolved</td><td>{{rfc3339 .}}</td></tr>{{end}}
+{{- with labels .Labels}}
+<tr><td style="padding-right: 12px;">Labels</td><td>{{.}}</td></tr>{{end}}
+{{- with .Link}}
+<tr><td colspan="2"><a href="{{.}}">View event</a></td></tr>{{end}}
+</table>
+{{end}}
+</body>
+</html>
+`))
@@ -0,0 +1,267 @@
+package usecase
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/lightwatch/monitoring-platform/services/api-go/internal/domain"
+	"github.com/lightwatch/monitoring-platform/services/api-go/pkg/webhook"
+)
+
+// smtpSink is a minimal in-process SMTP server that stores every message
+// it accepts. It advertises STARTTLS only when startTLS is set, and never
+// completes the handshake.
+type smtpSink struct {
+	ln       net.Listener
+	startTLS bool
+
+	mu   sync.Mutex
+	mail []sunkMail
+}
+
+type sunkMail struct {
+	from string
+	to   []string
+	msg  *mail.Message
+}
+
+func newSMTPSink(t *testing.T, startTLS bool) *smtpSink {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &smtpSink{ln: ln, startTLS: startTLS}
+	t.Cleanup(func() { ln.Close() })
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go s.serve(conn)
+		}
+	}()
+	return s
+}
+
+func (s *smtpSink) settings() SMTPSettings {
+	host, port, _ := net.SplitHostPort(s.ln.Addr().String())
+	p, _ := strconv.Atoi(port)
+	return SMTPSettings{Host: host, Port: p, From: "lightwatch@example.com"}
+}
+
+func (s *smtpSink) serve(conn net.Conn) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	reply := func(line string) { io.WriteString(conn, line+"\r\n") }
+
+	var cur sunkMail
+	reply("220 sink ESMTP")
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		cmd := strings.TrimSpace(line)
+		verb := strings.ToUpper(strings.SplitN(cmd, " ", 2)[0])
+		switch verb {
+		case "EHLO", "HELO":
+			if s.startTLS {
+				reply("250-sink")
+				reply("250 STARTTLS")
+			} else {
+				reply("250 sink")
+			}
+		case "STARTTLS":
+			reply("454 TLS not available")
+		case "MAIL":
+			cur = sunkMail{from: strings.Trim(cmd[len("MAIL FROM:"):], "<>")}
+			reply("250 OK")
+		case "RCPT":
+			cur.to = append(cur.to, strings.Trim(cmd[len("RCPT TO:"):], "<>"))
+			reply("250 OK")
+		case "DATA":
+			reply("354 go ahead")
+			data, err := io.ReadAll(textproto.NewReader(r).DotReader())
+			if err != nil {
+				return
+			}
+			msg, err := mail.ReadMessage(bytes.NewReader(data))
+			if err != nil {
+				return
+			}
+			cur.msg = msg
+			s.mu.Lock()
+			s.mail = append(s.mail, cur)
+			s.mu.Unlock()
+			reply("250 OK")
+		case "QUIT":
+			reply("221 bye")
+			return
+		default:
+			reply("502 unknown command")
+		}
+	}
+}
+
+func (s *smtpSink) received() []sunkMail {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]sunkMail(nil), s.mail...)
+}
+
+// plainPart returns the decoded text/plain alternative of msg.
+func plainPart(t *testing.T, msg *mail.Message) string {
+	t.Helper()
+	_, params, err := mime.ParseMediaType(msg.Header.Get("Content-Type"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	mr := multipart.NewReader(msg.Body, params["boundary"])
+	for {
+		part, err := mr.NextPart()
+		if err != nil {
+			t.Fatalf("no text/plain part: %v", err)
+		}
+		if strings.HasPrefix(part.Header.Get("Content-Type"), "text/plain") {
+			b, err := io.ReadAll(quotedprintable.NewReader(part))
+			if err != nil {
+				t.Fatal(err)
+			}
+			return string(b)
+		}
+	}
+}
+
+func subject(t *testing.T, msg *mail.Message) string {
+	t.Helper()
+	s, err := new(mime.WordDecoder).DecodeHeader(msg.Header.Get("Subject"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func emailNotification(target string) *domain.Notification {
+	return &domain.Notification{Channel: domain.ChannelEmail, Target: target}
+}
+
+func TestEmailNotify(t *testing.T) {
+	sink := newSMTPSink(t, false)
+	en := NewEmailNotifier(sink.settings())
+
+	err := en.Notify(context.Background(), emailNotification("oncall@example.com,sre@example.com"), testPayload(domain.AlertStateFiring))
+	if err != nil {
+		t.Fatalf("Notify: %v", err)
+	}
+
+	got := sink.received()
+	if len(got) != 1 {
+		t.Fatalf("received %d emails, want 1", len(got))
+	}
+	m := got[0]
+	if m.from != "lightwatch@example.com" {
+		t.Errorf("MAIL FROM = %q", m.from)
+	}
+	if strings.Join(m.to, ",") != "oncall@example.com,sre@example.com" {
+		t.Errorf("RCPT TO = %v", m.to)
+	}
+	if s := subject(t, m.msg); s != "[FIRING] High CPU (api-gateway)" {
+		t.Errorf("Subject = %q", s)
+	}
+	text := plainPart(t, m.msg)
+	for _, want := range []string{"Service:   api-gateway", "Value:     97.46 (threshold 90)", "Details:   http://localhost:3003/api/alerts/rule-1/events/evt-1"} {
+		if !strings.Contains(text, want) {
+			t.Errorf("body lacks %q:\n%s", want, text)
+		}
+	}
+}
+
+func TestEmailDigest(t *testing.T) {
+	sink := newSMTPSink(t, false)
+	cfg := sink.settings()
+	cfg.Digest = 5 * time.Minute
+	en := NewEmailNotifier(cfg)
+	if en.BatchWindow() != 5*time.Minute {
+		t.Fatalf("BatchWindow = %s, want the digest interval", en.BatchWindow())
+	}
+
+	var ns []*domain.Notification
+	var ps []webhook.Payload
+	for i, status := range []string{domain.AlertStateFiring, domain.AlertStateFiring, domain.AlertStateResolved} {
+		p := testPayload(status)
+		p.EventID = "evt-" + strconv.Itoa(i)
+		p.AlertName = "Rule " + strconv.Itoa(i)
+		ns = append(ns, emailNotification("oncall@example.com"))
+		ps = append(ps, p)
+	}
+	if err := en.NotifyBatch(context.Background(), ns, ps); err != nil {
+		t.Fatalf("NotifyBatch: %v", err)
+	}
+
+	got := sink.received()
+	if len(got) != 1 {
+		t.Fatalf("received %d emails, want one digest", len(got))
+	}
+	if s := subject(t, got[0].msg); s != "Lightwatch digest: 3 alert updates (2 firing)" {
+		t.Errorf("Subject = %q", s)
+	}
+	text := plainPart(t, got[0].msg)
+	for _, want := range []string{"[FIRING] Rule 0", "[FIRING] Rule 1", "[RESOLVED] Rule 2"} {
+		if !strings.Contains(text, want) {
+			t.Errorf("digest lacks %q:\n%s", want, text)
+		}
+	}
+}
+
+func TestEmailStartTLS(t *testing.T) {
+	tests := []struct {
+		name      string
+		advertise bool // relay offers STARTTLS
+		startTLS  bool // notifier requires it
+		wantErr   string
+	}{
+		{name: "off, plain relay", advertise: false, startTLS: false},
+		{name: "off, relay offers it", advertise: true, startTLS: false},
+		{name: "required, plain relay", advertise: false, startTLS: true, wantErr: "does not support STARTTLS"},
+		{name: "required, handshake refused", advertise: true, startTLS: true, wantErr: "starttls"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sink := newSMTPSink(t, tt.advertise)
+			cfg := sink.settings()
+			cfg.StartTLS = tt.startTLS
+			err := NewEmailNotifier(cfg).Notify(context.Background(), emailNotification("oncall@example.com"), testPayload(domain.AlertStateFiring))
+
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Notify error = %v, want %q", err, tt.wantErr)
+				}
+				if n := len(sink.received()); n != 0 {
+					t.Errorf("relay accepted %d emails without TLS", n)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Notify: %v", err)
+			}
+			if n := len(sink.received()); n != 1 {
+				t.Errorf("received %d emails, want 1", n)
+			}
+		})
+	}
+}
@@ -0,0 +1,192 @@
+package usecase
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/lightwatch/monitoring-platform/services/api-go/internal/domain"
+)
+
+// timing accumulates durations.
+type timing struct {
+	count            int64
+	total, max, last time.Duration
+}
+
+func (t *timing) observe(d time.Duration) {
+	t.count++
+	t.total += d
+	t.last = d
+	if d > t.max {
+		t.max = d
+	}
+}
+
+func (t *timing) avg() time.Duration {
+	if t.count == 0 {
+		return 0
+	}
+	return t.total / time.Duration(t.count)
+}
+
+func ms(d time.Duration) float64 {
+	return float64(d.Microseconds()) / 1000
+}
+
+// ruleRuns is what the engine tracks about one rule between ticks.
+type ruleRuns struct {
+	name     string
+	interval string
+	lastRun  time.Time // engine time of the tick that last evaluated it
+	latency  timing
+	failures int64
+	timeouts int64
+}
+
+// engineStats records the evaluation loop for GET /api/engine/stats and
+// remembers when each rule last ran, for rules with their own interval.
+type engineStats struct {
+	mu         sync.Mutex
+	ticks      int64
+	skipped    int64
+	tick       timing
+	lastTickAt time.Time
+	tickRules  int
+	rules      map[string]*ruleRuns
+}
+
+func newEngineStats() *engineStats {
+	return &engineStats{rules: make(map[string]*ruleRuns)}
+}
+
+// due returns the rules to evaluate in the tick at now. A rule with its own
+// interval is due once that interval has passed since it last ran (see
+// started), less half an engine tick so ticker jitter does not delay it by
+// a whole tick. Rules that are gone are forgotten.
+func (s *engineStats) due(rules []domain.Alert, now time.Time, tick time.Duration) []domain.Alert {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	seen := make(map[string]bool, len(rules))
+	var due []domain.Alert
+	for _, rule := range rules {
+		seen[rule.ID] = true
+		r, ok := s.rules[rule.ID]
+		if !ok {
+			r = &ruleRuns{}
+			s.rules[rule.ID] = r
+		}
+		r.name, r.interval = rule.Name, rule.EvaluationInterval
+		if every := evaluationInterval(rule); !r.lastRun.IsZero() && now.Sub(r.lastRun) < every-tick/2 {
+			continue
+		}
+		due = append(due, rule)
+	}
+	for id := range s.rules {
+		if !seen[id] {
+			delete(s.rules, id)
+		}
+	}
+	return due
+}
+
+// started records that the tick at now began evaluating a rule. Rules a
+// tick did not get to stay due.
+func (s *engineStats) started(id string, now time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if r, ok := s.rules[id]; ok {
+		r.lastRun = now
+	}
+}
+
+// observeRule records one evaluation of a rule.
+func (s *engineStats) observeRule(id string, took time.Duration, failed, timedOut bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	r, ok := s.rules[id]
+	if !ok {
+		return
+	}
+	r.latency.observe(took)
+	if failed {
+		r.failures++
+	}
+	if timedOut {
+		r.timeouts++
+	}
+}
+
+// observeTick records a completed tick that evaluated n rules.
+func (s *engineStats) observeTick(at time.Time, took time.Duration, n int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.ticks++
+	s.tick.observe(took)
+	s.lastTickAt = at
+	s.tickRules = n
+}
+
+// skip records ticks missed because a tick overran the interval.
+func (s *engineStats) skip(n int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.skipped += n
+}
+
+func (s *engineStats) snapshot() domain.EngineStats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	out := domain.EngineStats{
+		Ticks:         s.ticks,
+		SkippedTicks:  s.skipped,
+		LastTickRules: s.tickRules,
+		LastTickMs:    ms(s.tick.last),
+		AvgTickMs:     ms(s.tick.avg()),
+		MaxTickMs:     ms(s.tick.max),
+		Rules:         make([]domain.RuleStats, 0, len(s.rules)),
+	}
+	if !s.lastTickAt.IsZero() {
+		at := s.lastTickAt.UTC()
+		out.LastTickAt = &at
+	}
+	for id, r := range s.rules {
+		if r.latency.count == 0 {
+			continue
+		}
+		out.Rules = append(out.Rules, domain.RuleStats{
+			AlertID:         id,
+			Name:            r.name,
+			Interval:        r.interval,
+			Evaluations:     r.latency.count,
+			Failures:        r.failures,
+			Timeouts:        r.timeouts,
+			LastEvaluatedAt: r.lastRun.UTC(),
+			LastMs:          ms(r.latency.last),
+			AvgMs:           ms(r.latency.avg()),
+			MaxMs:           ms(r.latency.max),
+		})
+	}
+	sort.Slice(out.Rules, func(i, j int) bool {
+		return out.Rules[i].AvgMs > out.Rules[j].AvgMs
+	})
+	return out
+}
+
+// evaluationInterval returns the rule's own evaluation interval; zero
+// evaluates it every tick.
+func evaluationInterval(rule domain.Alert) time.Duration {
+	if rule.EvaluationInterval == "" {
+		return 0
+	}
+	d, err := parseDuration(rule.EvaluationInterval)
+	if err != nil || d < 0 {
+		return 0
+	}
+	return d
+}
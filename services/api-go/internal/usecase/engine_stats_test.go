@@ -0,0 +1,176 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/lightwatch/monitoring-platform/services/api-go/internal/domain"
+)
+
+func TestDue(t *testing.T) {
+	now := time.Date(2026, 10, 17, 9, 0, 0, 0, time.UTC)
+	tick := 30 * time.Second
+	every := domain.Alert{ID: "every-tick"}
+	slow := domain.Alert{ID: "every-5m", EvaluationInterval: "5m"}
+
+	tests := []struct {
+		name    string
+		lastRun time.Duration // before now; 0 if the rule never started
+		rule    domain.Alert
+		want    bool
+	}{
+		{name: "never run", rule: slow, want: true},
+		{name: "every tick", rule: every, lastRun: tick, want: true},
+		{name: "interval not passed", rule: slow, lastRun: 4 * time.Minute},
+		{name: "interval passed", rule: slow, lastRun: 5 * time.Minute, want: true},
+		{name: "within half a tick", rule: slow, lastRun: 5*time.Minute - 10*time.Second, want: true},
+		{name: "more than half a tick early", rule: slow, lastRun: 5*time.Minute - 20*time.Second},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newEngineStats()
+			if tt.lastRun > 0 {
+				s.due([]domain.Alert{tt.rule}, now.Add(-tt.lastRun), tick)
+				s.started(tt.rule.ID, now.Add(-tt.lastRun))
+			}
+			due := s.due([]domain.Alert{tt.rule}, now, tick)
+			if got := len(due) == 1; got != tt.want {
+				t.Fatalf("due = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDueUntilStarted(t *testing.T) {
+	now := time.Date(2026, 10, 17, 9, 0, 0, 0, time.UTC)
+	rule := domain.Alert{ID: "every-5m", EvaluationInterval: "5m"}
+	s := newEngineStats()
+
+	if len(s.due([]domain.Alert{rule}, now, 30*time.Second)) != 1 {
+		t.Fatal("new rule not due")
+	}
+	// Not started by that tick, so still due at the next
+	if len(s.due([]domain.Alert{rule}, now.Add(30*time.Second), 30*time.Second)) != 1 {
+		t.Fatal("rule that never started is no longer due")
+	}
+	s.started(rule.ID, now.Add(30*time.Second))
+	if len(s.due([]domain.Alert{rule}, now.Add(time.Minute), 30*time.Second)) != 0 {
+		t.Fatal("rule due again right after it started")
+	}
+}
+
+// cancellingMetrics cancels a tick while its first rule is evaluated.
+type cancellingMetrics struct {
+	memMetrics
+	once   sync.Once
+	cancel context.CancelFunc
+}
+
+func (m *cancellingMetrics) Scan(ctx context.Context, f domain.MetricsFilter) ([]domain.MetricEvent, bool, error) {
+	m.once.Do(m.cancel)
+	return m.memMetrics.Scan(ctx, f)
+}
+
+func TestTickLeavesUndispatchedRulesDue(t *testing.T) {
+	now := time.Date(2026, 10, 17, 9, 0, 0, 0, time.UTC)
+	var rules []domain.Alert
+	for _, id := range []string{"rule-1", "rule-2", "rule-3"} {
+		rules = append(rules, domain.Alert{
+			ID: id, Name: id, Service: "api", EvaluationInterval: "5m",
+			Condition: domain.AlertCondition{Metric: "cpu", Operator: "gt", Threshold: 90},
+		})
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	d, _ := newEngine(&cancellingMetrics{cancel: cancel}, &now, rules...)
+	d.SetConcurrency(1, time.Second)
+
+	if err := d.Tick(ctx, 30*time.Second); err != nil {
+		t.Fatal(err)
+	}
+	due := d.stats.due(rules, now.Add(30*time.Second), 30*time.Second)
+	if len(due) != 2 || due[0].ID != "rule-2" || due[1].ID != "rule-3" {
+		t.Fatalf("due after a cancelled tick = %v, want rule-2 and rule-3", due)
+	}
+}
+
+// poolMetrics serves cpu samples slowly enough for evaluations to overlap,
+// counting how many run at once. Queries for "stuck" block until their
+// context ends.
+type poolMetrics struct {
+	memMetrics
+	mu                sync.Mutex
+	inFlight, maxSeen int
+}
+
+func (m *poolMetrics) Scan(ctx context.Context, f domain.MetricsFilter) ([]domain.MetricEvent, bool, error) {
+	if f.Name == "stuck" {
+		<-ctx.Done()
+		return nil, false, ctx.Err()
+	}
+	m.mu.Lock()
+	m.inFlight++
+	m.maxSeen = max(m.maxSeen, m.inFlight)
+	m.mu.Unlock()
+	defer func() {
+		m.mu.Lock()
+		m.inFlight--
+		m.mu.Unlock()
+	}()
+	time.Sleep(20 * time.Millisecond)
+	return m.memMetrics.Scan(ctx, f)
+}
+
+func TestTickWorkerPool(t *testing.T) {
+	now := time.Date(2026, 10, 17, 9, 0, 0, 0, time.UTC)
+	metrics := &poolMetrics{memMetrics: memMetrics{events: samples("cpu", now.Add(-time.Minute), 1, []string{"srv-1"}, func(string, int) float64 { return 95 })}}
+	rule := func(id, metric string) domain.Alert {
+		return domain.Alert{ID: id, Name: id, Service: "api", Condition: domain.AlertCondition{Metric: metric, Operator: "gt", Threshold: 90}}
+	}
+	rules := []domain.Alert{rule("stuck", "stuck")}
+	for i := 1; i <= 8; i++ {
+		rules = append(rules, rule(fmt.Sprintf("rule-%d", i), "cpu"))
+	}
+	d, store := newEngine(metrics, &now, rules...)
+	d.SetConcurrency(4, 100*time.Millisecond)
+
+	start := time.Now()
+	if err := d.Tick(context.Background(), 30*time.Second); err != nil {
+		t.Fatal(err)
+	}
+	if took := time.Since(start); took > time.Second {
+		t.Errorf("tick took %s; a stuck rule should only hold its worker for the rule timeout", took)
+	}
+	if metrics.maxSeen > 4 || metrics.maxSeen < 2 {
+		t.Errorf("%d evaluations ran at once, want 2 to 4", metrics.maxSeen)
+	}
+	for _, r := range rules[1:] {
+		if store.states[r.ID].State != domain.AlertStateFiring {
+			t.Errorf("%s: state = %q, want firing", r.ID, store.states[r.ID].State)
+		}
+	}
+
+	stats := d.Stats()
+	if stats.Ticks != 1 || stats.LastTickRules != len(rules) || stats.Workers != 4 || stats.RuleTimeout != "100ms" {
+		t.Errorf("stats = %+v", stats)
+	}
+	if len(stats.Rules) != len(rules) {
+		t.Fatalf("stats for %d rules, want %d", len(stats.Rules), len(rules))
+	}
+	for _, r := range stats.Rules {
+		want := domain.RuleStats{Evaluations: 1}
+		if r.AlertID == "stuck" {
+			want.Failures, want.Timeouts = 1, 1
+		}
+		if r.Evaluations != want.Evaluations || r.Failures != want.Failures || r.Timeouts != want.Timeouts {
+			t.Errorf("%s: %d evaluations, %d failures, %d timeouts; want %d, %d, %d",
+				r.AlertID, r.Evaluations, r.Failures, r.Timeouts, want.Evaluations, want.Failures, want.Timeouts)
+		}
+	}
+	if stats.Rules[0].AlertID != "stuck" {
+		t.Errorf("slowest rule = %s, want stuck", stats.Rules[0].AlertID)
+	}
+}
@@ -0,0 +1,137 @@
+package usecase
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/lightwatch/monitoring-platform/services/api-go/internal/domain"
+)
+
+// stepDelay returns how long after the alert fired a step is due.
+func stepDelay(step domain.EscalationStep) time.Duration {
+	if step.After == "" {
+		return 0
+	}
+	d, err := parseDuration(step.After)
+	if err != nil || d < 0 {
+		return 0
+	}
+	return d
+}
+
+// ruleTargets returns the destinations configured on the rule itself. The
+// legacy Webhook field is treated as one more webhook target.
+func ruleTargets(rule domain.Alert) []domain.NotificationTarget {
+	if rule.Webhook == "" {
+		return rule.Targets
+	}
+	return append([]domain.NotificationTarget{{Type: domain.ChannelWebhook, URL: rule.Webhook}}, rule.Targets...)
+}
+
+// addTargets appends the targets of more that are not in list yet, so a
+// responder reached through several steps is notified once.
+func addTargets(list, more []domain.NotificationTarget) []domain.NotificationTarget {
+	seen := make(map[string]bool, len(list)+len(more))
+	key := func(t domain.NotificationTarget) string {
+		return t.Type + "|" + t.URL + "|" + strings.Join(t.To, ",") + "|" + t.Key
+	}
+	for _, t := range list {
+		seen[key(t)] = true
+	}
+	for _, t := range more {
+		if k := key(t); !seen[k] {
+			seen[k] = true
+			list = append(list, t)
+		}
+	}
+	return list
+}
+
+// escalate notifies the steps of the rule's escalation policy that have
+// come due since the alert fired. Escalation stops once the alert is
+// acknowledged, and waits while a silence matches it. Failures are logged
+// rather than returned, so they never hold up the rule's state.
+func (d *DetectAnomaly) escalate(ctx context.Context, rule domain.Alert, st *domain.AlertState, now time.Time) {
+	if rule.EscalationPolicy == "" || st.EventID == "" {
+		return
+	}
+	fail := func(msg string, err error) {
+		d.logger.Warn(msg, map[string]interface{}{
+			"alert_id": rule.ID,
+			"policy":   rule.EscalationPolicy,
+			"error":    err.Error(),
+		})
+	}
+
+	policy, err := d.policies.FindByID(ctx, rule.EscalationPolicy)
+	if err != nil {
+		fail("escalation policy lookup failed", err)
+		return
+	}
+	// While firing, UpdatedAt is when the rule fired
+	due := func() bool {
+		return st.EscalationStep < len(policy.Steps) &&
+			now.Sub(st.UpdatedAt) >= stepDelay(policy.Steps[st.EscalationStep])
+	}
+	if !due() {
+		return
+	}
+
+	alertEvt, err := d.alertEvents.FindByID(ctx, st.EventID)
+	if err != nil {
+		fail("alert event lookup failed", err)
+		return
+	}
+	if alertEvt.Status != domain.AlertStateFiring {
+		return // acknowledged
+	}
+	if d.silencedBy(ctx, alertEvt.Labels, now) != nil {
+		return
+	}
+
+	var targets []domain.NotificationTarget
+	for due() {
+		targets = addTargets(targets, d.stepTargets(ctx, policy.Steps[st.EscalationStep], now))
+		st.EscalationStep++
+	}
+
+	d.logger.Warn("alert escalated", map[string]interface{}{
+		"alert_event_id": alertEvt.ID,
+		"alert_name":     rule.Name,
+		"policy":         policy.Name,
+		"step":           st.EscalationStep, // last step reached, counting from 1
+		"targets":        len(targets),
+	})
+	if len(targets) == 0 {
+		return
+	}
+
+	alertEvt.SilencedBy = nil // only for the notification snapshot
+	d.notify(ctx, rule, alertEvt, targets)
+	st.Escalated = addTargets(st.Escalated, targets)
+	st.LastNotifiedAt = &now
+}
+
+// stepTargets resolves a step to destinations: the targets of whoever is
+// on call for each of its schedules, plus its fixed targets.
+func (d *DetectAnomaly) stepTargets(ctx context.Context, step domain.EscalationStep, now time.Time) []domain.NotificationTarget {
+	var targets []domain.NotificationTarget
+	for _, id := range step.Schedules {
+		s, err := d.schedules.FindByID(ctx, id)
+		if err != nil {
+			d.logger.Warn("on-call schedule lookup failed", map[string]interface{}{
+				"schedule_id": id,
+				"error":       err.Error(),
+			})
+			continue
+		}
+		shift := onCallAt(*s, now)
+		if shift == nil {
+			d.logger.Warn("nobody on call", map[string]interface{}{"schedule": s.Name})
+			continue
+		}
+		targets = addTargets(targets, shift.Member.Targets)
+	}
+	return addTargets(targets, step.Targets)
+}
@@ -0,0 +1,130 @@
+package usecase
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/lightwatch/monitoring-platform/services/api-go/internal/domain"
+	"github.com/lightwatch/monitoring-platform/services/api-go/pkg/webhook"
+)
+
+// Grouping defaults, as in Alertmanager.
+const (
+	defaultGroupWait      = "30s"
+	defaultGroupInterval  = "5m"
+	defaultRepeatInterval = "4h"
+)
+
+// groupingPolicy returns the rule's grouping with defaults applied, and
+// whether the rule groups its notifications at all.
+func groupingPolicy(rule domain.Alert) (domain.AlertGrouping, bool) {
+	if rule.Grouping == nil {
+		return domain.AlertGrouping{}, false
+	}
+	g := *rule.Grouping
+	if g.GroupWait == "" {
+		g.GroupWait = defaultGroupWait
+	}
+	if g.GroupInterval == "" {
+		g.GroupInterval = defaultGroupInterval
+	}
+	if g.RepeatInterval == "" {
+		g.RepeatInterval = defaultRepeatInterval
+	}
+	return g, true
+}
+
+// notificationGroup places an alert with the given labels in its group.
+// Alerts missing a group_by label group under the empty value.
+func notificationGroup(g domain.AlertGrouping, labels map[string]string) *domain.NotificationGroup {
+	values := make(map[string]string, len(g.GroupBy))
+	for _, name := range g.GroupBy {
+		values[name] = labels[name]
+	}
+	return &domain.NotificationGroup{
+		Key:      "{" + seriesKey(values) + "}",
+		Labels:   values,
+		Wait:     g.GroupWait,
+		Interval: g.GroupInterval,
+	}
+}
+
+// groupDuration parses a duration copied into a notification group;
+// rules are validated on save, so failures fall back to zero.
+func groupDuration(s string) time.Duration {
+	d, err := parseDuration(s)
+	if err != nil || d < 0 {
+		return 0
+	}
+	return d
+}
+
+// latestPerEvent keeps the newest transition of each alert event, so a
+// group message shows an alert that fired and resolved in the same
+// interval once, as resolved. ns and ps are parallel and ordered by
+// creation.
+func latestPerEvent(ns []*domain.Notification, ps []webhook.Payload) []webhook.Payload {
+	index := make(map[string]int, len(ps))
+	var out []webhook.Payload
+	for i, p := range ps {
+		if j, ok := index[ns[i].EventID]; ok {
+			out[j] = p
+			continue
+		}
+		index[ns[i].EventID] = len(out)
+		out = append(out, p)
+	}
+	return out
+}
+
+// openAlerts counts the alerts of a group message that have not resolved.
+func openAlerts(ps []webhook.Payload) int {
+	var open int
+	for _, p := range ps {
+		if p.Status != domain.AlertStateResolved {
+			open++
+		}
+	}
+	return open
+}
+
+// groupPayload builds the webhook envelope for one group message.
+func groupPayload(g *domain.NotificationGroup, ps []webhook.Payload) webhook.GroupPayload {
+	status := domain.AlertStateResolved
+	if openAlerts(ps) > 0 {
+		status = domain.AlertStateFiring
+	}
+	return webhook.GroupPayload{
+		Version:     webhook.PayloadVersion,
+		GroupKey:    g.Key,
+		GroupLabels: g.Labels,
+		Status:      status,
+		Alerts:      ps,
+	}
+}
+
+// groupTitle headlines a group message, e.g. "[FIRING:3] service=api-gateway".
+// The count is of firing alerts, or of resolved ones once none fire.
+func groupTitle(g *domain.NotificationGroup, ps []webhook.Payload) string {
+	open := openAlerts(ps)
+	title := fmt.Sprintf("[FIRING:%d]", open)
+	if open == 0 {
+		title = fmt.Sprintf("[RESOLVED:%d]", len(ps))
+	}
+
+	names := make([]string, 0, len(g.Labels))
+	for k := range g.Labels {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	parts := make([]string, len(names))
+	for i, k := range names {
+		parts[i] = k + "=" + g.Labels[k]
+	}
+	if len(parts) > 0 {
+		title += " " + strings.Join(parts, ", ")
+	}
+	return title
+}
@@ -0,0 +1,144 @@
+package usecase
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/lightwatch/monitoring-platform/services/api-go/internal/domain"
+	"github.com/lightwatch/monitoring-platform/services/api-go/pkg/webhook"
+)
+
+func TestNotificationGroup(t *testing.T) {
+	labels := map[string]string{"alertname": "High CPU", "service": "api-gateway", "host": "srv-1"}
+	tests := []struct {
+		name       string
+		groupBy    []string
+		labels     map[string]string
+		wantKey    string
+		wantLabels map[string]string
+	}{
+		{
+			name:       "no group_by",
+			labels:     labels,
+			wantKey:    "{}",
+			wantLabels: map[string]string{},
+		},
+		{
+			name:       "one label",
+			groupBy:    []string{"service"},
+			labels:     labels,
+			wantKey:    "{service=api-gateway}",
+			wantLabels: map[string]string{"service": "api-gateway"},
+		},
+		{
+			name:       "labels are sorted",
+			groupBy:    []string{"service", "alertname"},
+			labels:     labels,
+			wantKey:    "{alertname=High CPU,service=api-gateway}",
+			wantLabels: map[string]string{"alertname": "High CPU", "service": "api-gateway"},
+		},
+		{
+			name:       "group_by order does not matter",
+			groupBy:    []string{"alertname", "service"},
+			labels:     labels,
+			wantKey:    "{alertname=High CPU,service=api-gateway}",
+			wantLabels: map[string]string{"alertname": "High CPU", "service": "api-gateway"},
+		},
+		{
+			name:       "missing label groups under empty value",
+			groupBy:    []string{"service", "region"},
+			labels:     labels,
+			wantKey:    "{region=,service=api-gateway}",
+			wantLabels: map[string]string{"region": "", "service": "api-gateway"},
+		},
+		{
+			name:       "other labels are ignored",
+			groupBy:    []string{"service"},
+			labels:     map[string]string{"service": "api-gateway", "host": "srv-2"},
+			wantKey:    "{service=api-gateway}",
+			wantLabels: map[string]string{"service": "api-gateway"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := domain.AlertGrouping{GroupBy: tt.groupBy, GroupWait: "10s", GroupInterval: "1m"}
+			got := notificationGroup(g, tt.labels)
+			if got.Key != tt.wantKey {
+				t.Errorf("Key = %q, want %q", got.Key, tt.wantKey)
+			}
+			if !reflect.DeepEqual(got.Labels, tt.wantLabels) {
+				t.Errorf("Labels = %v, want %v", got.Labels, tt.wantLabels)
+			}
+			if got.Wait != "10s" || got.Interval != "1m" {
+				t.Errorf("Wait/Interval = %s/%s, want 10s/1m", got.Wait, got.Interval)
+			}
+		})
+	}
+}
+
+func TestGroupingPolicy(t *testing.T) {
+	if _, ok := groupingPolicy(domain.Alert{}); ok {
+		t.Fatal("rule without grouping groups")
+	}
+
+	g, ok := groupingPolicy(domain.Alert{Grouping: &domain.AlertGrouping{GroupBy: []string{"service"}}})
+	want := domain.AlertGrouping{GroupBy: []string{"service"}, GroupWait: "30s", GroupInterval: "5m", RepeatInterval: "4h"}
+	if !ok || !reflect.DeepEqual(g, want) {
+		t.Fatalf("groupingPolicy = %+v, %v, want %+v", g, ok, want)
+	}
+
+	custom := domain.AlertGrouping{GroupWait: "1s", GroupInterval: "2m", RepeatInterval: "1h"}
+	if g, _ := groupingPolicy(domain.Alert{Grouping: &custom}); !reflect.DeepEqual(g, custom) {
+		t.Fatalf("groupingPolicy = %+v, want %+v", g, custom)
+	}
+}
+
+func TestLatestPerEvent(t *testing.T) {
+	n := func(event string) *domain.Notification { return &domain.Notification{EventID: event} }
+	p := func(id, status string) webhook.Payload { return webhook.Payload{AlertID: id, Status: status} }
+
+	ns := []*domain.Notification{n("ev-1"), n("ev-2"), n("ev-1"), n("ev-3")}
+	ps := []webhook.Payload{
+		p("a", domain.AlertStateFiring),
+		p("b", domain.AlertStateFiring),
+		p("a", domain.AlertStateResolved),
+		p("c", domain.AlertStateFiring),
+	}
+	got := latestPerEvent(ns, ps)
+	want := []webhook.Payload{
+		p("a", domain.AlertStateResolved),
+		p("b", domain.AlertStateFiring),
+		p("c", domain.AlertStateFiring),
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("latestPerEvent = %+v, want %+v", got, want)
+	}
+}
+
+func TestGroupTitle(t *testing.T) {
+	firing := webhook.Payload{Status: domain.AlertStateFiring}
+	resolved := webhook.Payload{Status: domain.AlertStateResolved}
+	tests := []struct {
+		name   string
+		labels map[string]string
+		ps     []webhook.Payload
+		want   string
+		status string
+	}{
+		{name: "firing", labels: map[string]string{"service": "api-gateway"}, ps: []webhook.Payload{firing, resolved, firing}, want: "[FIRING:2] service=api-gateway", status: domain.AlertStateFiring},
+		{name: "all resolved", labels: map[string]string{"service": "api-gateway"}, ps: []webhook.Payload{resolved, resolved}, want: "[RESOLVED:2] service=api-gateway", status: domain.AlertStateResolved},
+		{name: "sorted labels", labels: map[string]string{"service": "api", "alertname": "High CPU"}, ps: []webhook.Payload{firing}, want: "[FIRING:1] alertname=High CPU, service=api", status: domain.AlertStateFiring},
+		{name: "no labels", labels: map[string]string{}, ps: []webhook.Payload{firing}, want: "[FIRING:1]", status: domain.AlertStateFiring},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := &domain.NotificationGroup{Labels: tt.labels}
+			if got := groupTitle(g, tt.ps); got != tt.want {
+				t.Fatalf("groupTitle = %q, want %q", got, tt.want)
+			}
+			if got := groupPayload(g, tt.ps).Status; got != tt.status {
+				t.Fatalf("groupPayload status = %s, want %s", got, tt.status)
+			}
+		})
+	}
+}
@@ -0,0 +1,268 @@
+package usecase
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/lightwatch/monitoring-platform/services/api-go/internal/domain"
+	"github.com/lightwatch/monitoring-platform/services/api-go/pkg/webhook"
+)
+
+// Public API endpoints, used unless a target overrides them with its URL.
+const (
+	pagerDutyEventsURL = "https://events.pagerduty.com/v2/enqueue"
+	opsgenieAPIURL     = "https://api.opsgenie.com"
+)
+
+// maxDedupKey is PagerDuty's dedup_key limit; Opsgenie aliases allow more.
+const maxDedupKey = 255
+
+// Incident actions, named after the PagerDuty event_action values.
+const (
+	incidentTrigger     = "trigger"
+	incidentAcknowledge = "acknowledge"
+	incidentResolve     = "resolve"
+)
+
+// incidentEndpoint returns where notifications for an incident target are
+// sent: the target's URL override, or the service's public API.
+func incidentEndpoint(t domain.NotificationTarget) string {
+	if t.URL != "" {
+		return t.URL
+	}
+	if t.Type == domain.ChannelOpsgenie {
+		return opsgenieAPIURL
+	}
+	return pagerDutyEventsURL
+}
+
+// incidentAction maps an event transition onto an incident action.
+func incidentAction(status string) (string, error) {
+	switch status {
+	case domain.AlertStateFiring:
+		return incidentTrigger, nil
+	case domain.AlertEventAcknowledged:
+		return incidentAcknowledge, nil
+	case domain.AlertStateResolved:
+		return incidentResolve, nil
+	default:
+		return "", fmt.Errorf("no incident action for status %q", status)
+	}
+}
+
+// dedupKey identifies one upstream incident: the rule ID plus the label set
+// of the series that fired, so per-series alerts open separate incidents and
+// a resolve closes the matching one. alertname is left out so renaming a
+// rule does not orphan an open incident.
+func dedupKey(p webhook.Payload) string {
+	labels := make(map[string]string, len(p.Labels))
+	for k, v := range p.Labels {
+		if k != "alertname" {
+			labels[k] = v
+		}
+	}
+
+	key := p.AlertID
+	if s := seriesKey(labels); s != "" {
+		key += ":" + s
+	}
+	if len(key) > maxDedupKey {
+		sum := sha256.Sum256([]byte(key))
+		key = p.AlertID + ":" + hex.EncodeToString(sum[:])
+	}
+	return key
+}
+
+// incidentSummary is the one-line description of a firing alert.
+func incidentSummary(p webhook.Payload) string {
+	subject := p.AlertName
+	if p.Service != "" {
+		subject += " on " + p.Service
+	}
+	return fmt.Sprintf("%s: value %s, threshold %s",
+		subject, formatValue(p.Value), formatValue(p.Threshold))
+}
+
+// incidentDetails are the custom fields attached to a new incident.
+func incidentDetails(p webhook.Payload) map[string]string {
+	details := map[string]string{
+		"alert_id":  p.AlertID,
+		"event_id":  p.EventID,
+		"rule_type": p.Rule.Type,
+		"metric":    p.Rule.Metric,
+		"value":     formatValue(p.Value),
+		"threshold": formatValue(p.Threshold),
+	}
+	for k, v := range p.Labels {
+		details["label."+k] = v
+	}
+	if p.Link != "" {
+		details["link"] = p.Link
+	}
+	return details
+}
+
+// incidentSeverity defaults notifications queued before rules had a
+// severity to critical.
+func incidentSeverity(p webhook.Payload) string {
+	if p.Rule.Severity == "" {
+		return domain.SeverityCritical
+	}
+	return p.Rule.Severity
+}
+
+// incidentSource names what the incident is about. PagerDuty requires a
+// source; rules without a service fall back to the alert name.
+func incidentSource(p webhook.Payload) string {
+	switch {
+	case p.Service != "":
+		return p.Service
+	case p.AlertName != "":
+		return p.AlertName
+	default:
+		return "Lightwatch"
+	}
+}
+
+func incidentKey(n *domain.Notification) (string, error) {
+	if len(n.Secrets) == 0 || n.Secrets[0] == "" {
+		return "", errors.New("notification has no integration key")
+	}
+	return n.Secrets[0], nil
+}
+
+// ── PagerDuty ──
+
+// PagerDutyNotifier triggers, acknowledges and resolves PagerDuty
+// incidents through the Events API v2.
+type PagerDutyNotifier struct {
+	client *http.Client
+}
+
+// NewPagerDutyNotifier creates the PagerDuty channel.
+func NewPagerDutyNotifier(client *http.Client) *PagerDutyNotifier {
+	return &PagerDutyNotifier{client: client}
+}
+
+// Notify implements Notifier.
+func (pn *PagerDutyNotifier) Notify(ctx context.Context, n *domain.Notification, p webhook.Payload) error {
+	routingKey, err := incidentKey(n)
+	if err != nil {
+		return err
+	}
+	action, err := incidentAction(p.Status)
+	if err != nil {
+		return err
+	}
+
+	event := map[string]interface{}{
+		"routing_key":  routingKey,
+		"event_action": action,
+		"dedup_key":    dedupKey(p),
+	}
+	// Acknowledge and resolve only need the dedup key
+	if action == incidentTrigger {
+		payload := map[string]interface{}{
+			"summary":        incidentSummary(p),
+			"source":         incidentSource(p),
+			"severity":       incidentSeverity(p),
+			"timestamp":      p.TriggeredAt.UTC().Format(time.RFC3339),
+			"component":      p.Rule.Metric,
+			"class":          p.Rule.Type,
+			"custom_details": incidentDetails(p),
+		}
+		if p.Service != "" {
+			payload["group"] = p.Service
+		}
+		event["payload"] = payload
+		event["client"] = "Lightwatch"
+		if p.Link != "" {
+			event["client_url"] = p.Link
+			event["links"] = []interface{}{map[string]interface{}{"href": p.Link, "text": "View events"}}
+		}
+	}
+	return marshalAndPost(ctx, pn.client, n.Target, event)
+}
+
+// ── Opsgenie ──
+
+// opsgeniePriorities maps rule severities onto Opsgenie priorities.
+var opsgeniePriorities = map[string]string{
+	domain.SeverityCritical: "P1",
+	domain.SeverityError:    "P2",
+	domain.SeverityWarning:  "P3",
+	domain.SeverityInfo:     "P5",
+}
+
+// OpsgenieNotifier creates, acknowledges and closes Opsgenie alerts,
+// using the dedup key as the alert alias.
+type OpsgenieNotifier struct {
+	client *http.Client
+}
+
+// NewOpsgenieNotifier creates the Opsgenie channel.
+func NewOpsgenieNotifier(client *http.Client) *OpsgenieNotifier {
+	return &OpsgenieNotifier{client: client}
+}
+
+// Notify implements Notifier.
+func (on *OpsgenieNotifier) Notify(ctx context.Context, n *domain.Notification, p webhook.Payload) error {
+	apiKey, err := incidentKey(n)
+	if err != nil {
+		return err
+	}
+	action, err := incidentAction(p.Status)
+	if err != nil {
+		return err
+	}
+
+	header := http.Header{}
+	header.Set("Authorization", "GenieKey "+apiKey)
+	alias := dedupKey(p)
+
+	var (
+		endpoint string
+		body     map[string]interface{}
+	)
+	switch action {
+	case incidentTrigger:
+		severity := incidentSeverity(p)
+		message := []rune("[" + severity + "] " + p.AlertName)
+		if len(message) > 130 { // Opsgenie's limit
+			message = message[:130]
+		}
+		endpoint = n.Target + "/v2/alerts"
+		body = map[string]interface{}{
+			"message":     string(message),
+			"alias":       alias,
+			"description": incidentSummary(p),
+			"details":     incidentDetails(p),
+			"entity":      p.Service,
+			"source":      "Lightwatch",
+			"priority":    opsgeniePriorities[severity],
+			"tags":        []string{"lightwatch"},
+		}
+		if p.Service != "" {
+			body["tags"] = []string{"lightwatch", "service:" + p.Service}
+		}
+	case incidentAcknowledge:
+		endpoint = n.Target + "/v2/alerts/" + url.PathEscape(alias) + "/acknowledge?identifierType=alias"
+		body = map[string]interface{}{"source": "Lightwatch"}
+	case incidentResolve:
+		endpoint = n.Target + "/v2/alerts/" + url.PathEscape(alias) + "/close?identifierType=alias"
+		body = map[string]interface{}{"source": "Lightwatch", "note": "Resolved by Lightwatch"}
+	}
+
+	encoded, err := json.Marshal(body)
+	if err != nil {
+		return fmt.Errorf("encode message: %w", err)
+	}
+	return postJSON(ctx, on.client, endpoint, encoded, header)
+}
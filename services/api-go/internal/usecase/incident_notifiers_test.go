@@ -0,0 +1,138 @@
+package usecase
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/lightwatch/monitoring-platform/services/api-go/internal/domain"
+)
+
+func incidentNotification(channel, target string) *domain.Notification {
+	return &domain.Notification{Channel: channel, Target: target, Secrets: []string{"integration-key"}}
+}
+
+func TestPagerDutyDedupKey(t *testing.T) {
+	rc := newReceiver(t, http.StatusAccepted)
+	pn := NewPagerDutyNotifier(http.DefaultClient)
+	n := incidentNotification(domain.ChannelPagerDuty, rc.URL)
+
+	if err := pn.Notify(context.Background(), n, testPayload(domain.AlertStateFiring)); err != nil {
+		t.Fatalf("trigger: %v", err)
+	}
+	trigger := rc.last(t)
+	if err := pn.Notify(context.Background(), n, testPayload(domain.AlertStateResolved)); err != nil {
+		t.Fatalf("resolve: %v", err)
+	}
+	resolve := rc.last(t)
+
+	if got := path(t, trigger, "event_action"); got != "trigger" {
+		t.Errorf("event_action = %v, want trigger", got)
+	}
+	if got := path(t, resolve, "event_action"); got != "resolve" {
+		t.Errorf("event_action = %v, want resolve", got)
+	}
+	if got := path(t, trigger, "routing_key"); got != "integration-key" {
+		t.Errorf("routing_key = %v", got)
+	}
+	key := path(t, trigger, "dedup_key")
+	if key != "rule-1:host=srv-1,service=api-gateway" {
+		t.Errorf("dedup_key = %v, want the rule ID and series labels without alertname", key)
+	}
+	if got := path(t, resolve, "dedup_key"); got != key {
+		t.Errorf("resolve dedup_key = %v, want %v", got, key)
+	}
+	if _, ok := resolve["payload"]; ok {
+		t.Error("resolve carries a payload; only the dedup key is needed")
+	}
+
+	// A renamed rule still resolves the incident it opened.
+	renamed := testPayload(domain.AlertStateResolved)
+	renamed.AlertName = "CPU saturation"
+	renamed.Labels["alertname"] = renamed.AlertName
+	if got := dedupKey(renamed); got != key {
+		t.Errorf("dedup key after rename = %q, want %v", got, key)
+	}
+}
+
+func TestPagerDutySource(t *testing.T) {
+	tests := []struct {
+		name      string
+		alertName string
+		service   string
+		want      string
+	}{
+		{name: "service", alertName: "High CPU", service: "api-gateway", want: "api-gateway"},
+		{name: "no service", alertName: "Failed logins", want: "Failed logins"},
+		{name: "neither", want: "Lightwatch"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rc := newReceiver(t, http.StatusAccepted)
+			p := testPayload(domain.AlertStateFiring)
+			p.AlertName, p.Service = tt.alertName, tt.service
+
+			err := NewPagerDutyNotifier(http.DefaultClient).Notify(context.Background(), incidentNotification(domain.ChannelPagerDuty, rc.URL), p)
+			if err != nil {
+				t.Fatalf("Notify: %v", err)
+			}
+			if got := path(t, rc.last(t), "payload", "source"); got != tt.want {
+				t.Errorf("source = %v, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestOpsgenieLifecycle(t *testing.T) {
+	const alias = "rule-1:host=srv-1,service=api-gateway"
+	tests := []struct {
+		status string
+		path   string
+		query  string
+	}{
+		{status: domain.AlertStateFiring, path: "/v2/alerts"},
+		{status: domain.AlertEventAcknowledged, path: "/v2/alerts/" + alias + "/acknowledge", query: "identifierType=alias"},
+		{status: domain.AlertStateResolved, path: "/v2/alerts/" + alias + "/close", query: "identifierType=alias"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.status, func(t *testing.T) {
+			rc := newReceiver(t, http.StatusAccepted)
+			err := NewOpsgenieNotifier(http.DefaultClient).Notify(context.Background(), incidentNotification(domain.ChannelOpsgenie, rc.URL), testPayload(tt.status))
+			if err != nil {
+				t.Fatalf("Notify: %v", err)
+			}
+			req := rc.requests[0]
+			if req.method != http.MethodPost || req.path != tt.path || req.query != tt.query {
+				t.Errorf("request = %s %s?%s, want POST %s?%s", req.method, req.path, req.query, tt.path, tt.query)
+			}
+			if got := req.header.Get("Authorization"); got != "GenieKey integration-key" {
+				t.Errorf("Authorization = %q", got)
+			}
+
+			msg := rc.last(t)
+			if got := path(t, msg, "source"); got != "Lightwatch" {
+				t.Errorf("source = %v", got)
+			}
+			switch tt.status {
+			case domain.AlertStateFiring:
+				if got := path(t, msg, "alias"); got != alias {
+					t.Errorf("alias = %v, want %s", got, alias)
+				}
+				if got := path(t, msg, "priority"); got != "P1" {
+					t.Errorf("priority = %v, want P1 for an unset severity", got)
+				}
+			case domain.AlertStateResolved:
+				if got := path(t, msg, "note"); got != "Resolved by Lightwatch" {
+					t.Errorf("note = %v", got)
+				}
+			}
+		})
+	}
+
+	t.Run("missing key", func(t *testing.T) {
+		n := &domain.Notification{Channel: domain.ChannelOpsgenie, Target: "http://127.0.0.1:1"}
+		if err := NewOpsgenieNotifier(http.DefaultClient).Notify(context.Background(), n, testPayload(domain.AlertStateResolved)); err == nil {
+			t.Fatal("Notify without an API key succeeded")
+		}
+	})
+}
@@ -0,0 +1,145 @@
+package usecase
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/lightwatch/monitoring-platform/services/api-go/internal/domain"
+	"github.com/lightwatch/monitoring-platform/services/api-go/internal/observability"
+)
+
+// ManageAlertEvents encapsulates how responders work an alert event:
+// acknowledging it, assigning it and commenting on it. Each action is
+// recorded on the event's timeline.
+type ManageAlertEvents struct {
+	repo          domain.AlertEventsRepository
+	alerts        domain.AlertsRepository
+	states        domain.AlertStatesRepository
+	notifications domain.NotificationsRepository
+	publisher     domain.AlertPublisher // optional
+	logger        *observability.Logger
+}
+
+// NewManageAlertEvents creates a new ManageAlertEvents use case. publisher
+// may be nil to disable realtime fan-out of acknowledgements.
+func NewManageAlertEvents(
+	repo domain.AlertEventsRepository,
+	alerts domain.AlertsRepository,
+	states domain.AlertStatesRepository,
+	notifications domain.NotificationsRepository,
+	publisher domain.AlertPublisher,
+	logger *observability.Logger,
+) *ManageAlertEvents {
+	return &ManageAlertEvents{
+		repo:          repo,
+		alerts:        alerts,
+		states:        states,
+		notifications: notifications,
+		publisher:     publisher,
+		logger:        logger,
+	}
+}
+
+// Acknowledge records that by is handling a firing event. The alert engine
+// stops escalating and re-notifying it, and incidents opened for it in
+// PagerDuty or Opsgenie are acknowledged. It returns domain.ErrConflict if
+// the event is already acknowledged or resolved.
+func (uc *ManageAlertEvents) Acknowledge(ctx context.Context, id, by string) (*domain.AlertEvent, error) {
+	by = strings.TrimSpace(by)
+	if by == "" {
+		return nil, invalid("by", "is required")
+	}
+	evt, err := uc.repo.Acknowledge(ctx, id, timelineEntry(domain.TimelineAcknowledged, by, ""))
+	if err != nil {
+		return nil, err
+	}
+
+	uc.logger.Info("alert acknowledged", map[string]interface{}{
+		"alert_event_id": evt.ID,
+		"alert_name":     evt.AlertName,
+		"acked_by":       by,
+	})
+	publishAlert(ctx, uc.publisher, uc.logger, evt)
+	uc.notifyIncidents(ctx, evt)
+	return evt, nil
+}
+
+// Assign makes assignee the owner of an event; an empty assignee clears
+// it. by defaults to the assignee, for taking an event yourself.
+func (uc *ManageAlertEvents) Assign(ctx context.Context, id, assignee, by string) (*domain.AlertEvent, error) {
+	assignee = strings.TrimSpace(assignee)
+	by = strings.TrimSpace(by)
+	if by == "" {
+		by = assignee
+	}
+	if by == "" {
+		return nil, invalid("by", "is required to unassign")
+	}
+	return uc.repo.Assign(ctx, id, timelineEntry(domain.TimelineAssigned, by, assignee))
+}
+
+// Comment adds a comment to an event's timeline.
+func (uc *ManageAlertEvents) Comment(ctx context.Context, id, author, text string) (*domain.AlertEvent, error) {
+	author = strings.TrimSpace(author)
+	if author == "" {
+		return nil, invalid("author", "is required")
+	}
+	if strings.TrimSpace(text) == "" {
+		return nil, invalid("text", "is required")
+	}
+	return uc.repo.AddComment(ctx, id, timelineEntry(domain.TimelineComment, author, text))
+}
+
+func timelineEntry(kind, author, text string) domain.AlertEventEntry {
+	return domain.AlertEventEntry{
+		Kind:      kind,
+		Author:    author,
+		Text:      text,
+		CreatedAt: time.Now().UTC(),
+	}
+}
+
+// notifyIncidents queues the acknowledgement for the PagerDuty and Opsgenie
+// targets that were paged for the event. Other channels are not told: the
+// alert is still open. Failures are logged, as the ack itself succeeded.
+func (uc *ManageAlertEvents) notifyIncidents(ctx context.Context, evt *domain.AlertEvent) {
+	warn := func(msg string, err error) {
+		uc.logger.Warn(msg, map[string]interface{}{
+			"alert_event_id": evt.ID,
+			"error":          err.Error(),
+		})
+	}
+
+	rule, err := uc.alerts.FindByID(ctx, evt.AlertID)
+	if err != nil {
+		warn("alert rule lookup failed", err)
+		return
+	}
+	states, err := uc.states.FindByAlert(ctx, evt.AlertID)
+	if err != nil {
+		warn("alert state lookup failed", err)
+		return
+	}
+	var st *domain.AlertState
+	for i := range states {
+		if states[i].EventID == evt.ID {
+			st = &states[i]
+		}
+	}
+	if st == nil {
+		return
+	}
+
+	var paged []domain.NotificationTarget
+	if st.LastNotifiedAt != nil {
+		paged = ruleTargets(*rule)
+	}
+	var targets []domain.NotificationTarget
+	for _, t := range addTargets(paged, st.Escalated) {
+		if t.Type == domain.ChannelPagerDuty || t.Type == domain.ChannelOpsgenie {
+			targets = append(targets, t)
+		}
+	}
+	enqueueNotifications(ctx, uc.notifications, uc.logger, *rule, evt, targets)
+}
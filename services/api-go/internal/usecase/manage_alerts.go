@@ -2,18 +2,25 @@ package usecase
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"net/mail"
+	"net/url"
+	"regexp"
+	"strings"
 
 	"github.com/lightwatch/monitoring-platform/services/api-go/internal/domain"
 )
 
 // ManageAlerts encapsulates alert rule CRUD operations.
 type ManageAlerts struct {
-	repo domain.AlertsRepository
+	repo     domain.AlertsRepository
+	policies domain.EscalationPoliciesRepository
 }
 
 // NewManageAlerts creates a new ManageAlerts use case.
-func NewManageAlerts(repo domain.AlertsRepository) *ManageAlerts {
-	return &ManageAlerts{repo: repo}
+func NewManageAlerts(repo domain.AlertsRepository, policies domain.EscalationPoliciesRepository) *ManageAlerts {
+	return &ManageAlerts{repo: repo, policies: policies}
 }
 
 // List returns all alert rules.
@@ -21,7 +28,484 @@ func (uc *ManageAlerts) List(ctx context.Context) ([]domain.Alert, error) {
 	return uc.repo.FindAll(ctx)
 }
 
-// Create inserts a new alert rule.
+// Get returns a single alert rule.
+func (uc *ManageAlerts) Get(ctx context.Context, id string) (*domain.Alert, error) {
+	return uc.repo.FindByID(ctx, id)
+}
+
+// Create validates and inserts a new alert rule.
 func (uc *ManageAlerts) Create(ctx context.Context, alert *domain.Alert) (string, error) {
+	if alert.Type == "" {
+		alert.Type = domain.AlertTypeThreshold
+	}
+	if err := validateAlert(alert); err != nil {
+		return "", err
+	}
+	if err := uc.checkPolicy(ctx, alert); err != nil {
+		return "", err
+	}
 	return uc.repo.Create(ctx, alert)
 }
+
+// Update validates and replaces an existing alert rule. alert.Version must
+// match the stored version, otherwise domain.ErrConflict is returned.
+// Responses never carry the webhook secrets or target keys, so a rule
+// without secrets keeps the stored ones (an empty list removes them), and
+// targets without a key keep theirs as keepTargetKeys describes.
+func (uc *ManageAlerts) Update(ctx context.Context, id string, alert *domain.Alert) error {
+	existing, err := uc.repo.FindByID(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	alert.ID = id
+	alert.CreatedAt = existing.CreatedAt
+	if alert.WebhookSecrets == nil {
+		alert.WebhookSecrets = existing.WebhookSecrets
+	}
+	keepTargetKeys(alert.Targets, existing.Targets)
+	if alert.Type == "" {
+		alert.Type = domain.AlertTypeThreshold
+	}
+	if err := validateAlert(alert); err != nil {
+		return err
+	}
+	if err := uc.checkPolicy(ctx, alert); err != nil {
+		return err
+	}
+	return uc.repo.Update(ctx, alert)
+}
+
+// SetEnabled enables or disables an alert rule.
+func (uc *ManageAlerts) SetEnabled(ctx context.Context, id string, enabled bool) (*domain.Alert, error) {
+	return uc.repo.SetEnabled(ctx, id, enabled)
+}
+
+// Delete removes an alert rule.
+func (uc *ManageAlerts) Delete(ctx context.Context, id string) error {
+	return uc.repo.Delete(ctx, id)
+}
+
+// defaultHeartbeatRules are provisioned by EnsureHeartbeatRules, one per
+// heartbeat status. Without a service they cover every registered
+// service, each firing as its own alert.
+var defaultHeartbeatRules = []domain.Alert{
+	{
+		Name:      "Service heartbeat overdue",
+		Severity:  domain.SeverityWarning,
+		Condition: domain.AlertCondition{Heartbeat: &domain.HeartbeatCheck{Status: domain.ServiceDegraded}},
+	},
+	{
+		Name:      "Service heartbeat lost",
+		Severity:  domain.SeverityCritical,
+		Condition: domain.AlertCondition{Heartbeat: &domain.HeartbeatCheck{Status: domain.ServiceUnhealthy}},
+	},
+}
+
+// EnsureHeartbeatRules creates the default heartbeat rules whose status is
+// not yet covered by a heartbeat rule for every service, and returns the
+// names of those it created. Disabled rules count, so disabling a
+// provisioned rule sticks; a deleted one is provisioned again.
+func (uc *ManageAlerts) EnsureHeartbeatRules(ctx context.Context) ([]string, error) {
+	rules, err := uc.repo.FindAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+	covered := make(map[string]bool)
+	for _, r := range rules {
+		if r.Type == domain.AlertTypeHeartbeat && r.Service == "" {
+			covered[heartbeatLevel(r)] = true
+		}
+	}
+
+	var created []string
+	for _, r := range defaultHeartbeatRules {
+		if covered[heartbeatLevel(r)] {
+			continue
+		}
+		r.Type = domain.AlertTypeHeartbeat
+		r.Enabled = true
+		if _, err := uc.repo.Create(ctx, &r); err != nil {
+			return created, fmt.Errorf("create %q: %w", r.Name, err)
+		}
+		created = append(created, r.Name)
+	}
+	return created, nil
+}
+
+// checkPolicy verifies that the rule's escalation policy exists.
+func (uc *ManageAlerts) checkPolicy(ctx context.Context, a *domain.Alert) error {
+	if a.EscalationPolicy == "" {
+		return nil
+	}
+	_, err := uc.policies.FindByID(ctx, a.EscalationPolicy)
+	if errors.Is(err, domain.ErrNotFound) {
+		return invalid("escalation_policy", "does not exist")
+	}
+	return err
+}
+
+var (
+	validAlertTypes = []string{
+		domain.AlertTypeThreshold, domain.AlertTypeRateChange, domain.AlertTypeAnomaly,
+		domain.AlertTypeLog, domain.AlertTypeSecurity, domain.AlertTypeHeartbeat,
+		domain.AlertTypeComposite,
+	}
+	validOperators  = []string{"gt", "gte", "lt", "lte", "eq"}
+	validAggregates = []string{
+		domain.AggregateLast, domain.AggregateAll, domain.AggregateAvg,
+		domain.AggregateMin, domain.AggregateMax, domain.AggregateP95,
+	}
+	validChanges    = []string{domain.ChangePercent, domain.ChangeAbsolute}
+	validDirections = []string{domain.DirectionUp, domain.DirectionDown, domain.DirectionEither}
+	validBaselines  = []string{domain.BaselineZScore, domain.BaselineEWMA, domain.BaselineMAD}
+	validChannels   = []string{
+		domain.ChannelWebhook, domain.ChannelSlack, domain.ChannelTeams, domain.ChannelMattermost,
+		domain.ChannelEmail, domain.ChannelPagerDuty, domain.ChannelOpsgenie,
+	}
+	// Channels whose request body can be replaced with a target's template
+	templatedChannels = []string{domain.ChannelWebhook, domain.ChannelSlack, domain.ChannelTeams, domain.ChannelMattermost}
+	validSeverities   = []string{domain.SeverityCritical, domain.SeverityError, domain.SeverityWarning, domain.SeverityInfo}
+
+	// Values of the ingestion schemas for logs and security events
+	validLogLevels     = []string{"debug", "info", "warn", "error", "fatal"}
+	validSecurityTypes = []string{
+		"brute_force", "port_scan", "auth_failure", "malware", "injection",
+		"xss", "ddos", "privilege_escalation", "other",
+	}
+	validSecuritySeverities = []string{"low", "medium", "high", "critical"}
+	validSecurityKeys       = []string{domain.SecurityKeySourceIP, domain.SecurityKeyService, domain.SecurityKeyType}
+	validHeartbeatStatuses  = []string{domain.ServiceDegraded, domain.ServiceUnhealthy}
+
+	// Rule types that read metrics, and can select series
+	validSelectorTypes = []string{domain.AlertTypeThreshold, domain.AlertTypeRateChange, domain.AlertTypeAnomaly}
+
+	// Rule types a composite rule's conditions can use
+	validLeafTypes = []string{
+		domain.AlertTypeThreshold, domain.AlertTypeRateChange, domain.AlertTypeAnomaly,
+		domain.AlertTypeLog, domain.AlertTypeSecurity,
+	}
+)
+
+// validateAlert checks a rule definition before it is stored.
+func validateAlert(a *domain.Alert) error {
+	if a.Name == "" {
+		return invalid("name", "is required")
+	}
+	// Security and heartbeat rules may watch every service, and rules with
+	// a selector the services it matches
+	if a.Service == "" && len(a.Selector) == 0 && a.Type != domain.AlertTypeSecurity && a.Type != domain.AlertTypeHeartbeat {
+		if oneOf(a.Type, validSelectorTypes) {
+			return invalid("service", "is required unless a selector is set")
+		}
+		return invalid("service", "is required")
+	}
+	if !oneOf(a.Type, validAlertTypes) {
+		return invalid("type", "must be one of "+strings.Join(validAlertTypes, ", "))
+	}
+	if len(a.Selector) > 0 {
+		if !oneOf(a.Type, validSelectorTypes) {
+			return invalid("selector", "is only supported by "+strings.Join(validSelectorTypes, ", ")+" rules")
+		}
+		if err := validateMatchers("selector", a.Selector); err != nil {
+			return err
+		}
+		for i, m := range a.Selector {
+			if strings.ContainsAny(m.Label, ".$") {
+				return invalid(fmt.Sprintf("selector[%d].label", i), "must not contain '.' or '$'")
+			}
+		}
+	}
+
+	if err := validateCondition("condition", a.Type, a.Condition); err != nil {
+		return err
+	}
+	if err := validDuration("pending_for", a.PendingFor); err != nil {
+		return err
+	}
+	if err := validDuration("evaluation_interval", a.EvaluationInterval); err != nil {
+		return err
+	}
+
+	if len(a.WebhookSecrets) > 2 {
+		return invalid("webhook_secrets", "accepts at most two active secrets (current and next during rotation)")
+	}
+	for _, secret := range a.WebhookSecrets {
+		if len(secret) < 16 {
+			return invalid("webhook_secrets", "entries must be at least 16 characters")
+		}
+	}
+	if a.Severity != "" && !oneOf(a.Severity, validSeverities) {
+		return invalid("severity", "must be one of "+strings.Join(validSeverities, ", "))
+	}
+	if a.Webhook != "" && !validURL(a.Webhook) {
+		return invalid("webhook", "must be an http or https URL")
+	}
+	for i, t := range a.Targets {
+		field := fmt.Sprintf("targets[%d]", i)
+		if err := validateTarget(field, t); err != nil {
+			return err
+		}
+		// Grouped rules render the body against the group envelope
+		if t.Body != "" {
+			if err := checkBodyTemplate(t.Body, a.Grouping != nil); err != nil {
+				return invalid(field+".body", err.Error())
+			}
+		}
+	}
+	if g := a.Grouping; g != nil {
+		for i, label := range g.GroupBy {
+			if label == "" {
+				return invalid(fmt.Sprintf("grouping.group_by[%d]", i), "must not be empty")
+			}
+		}
+		for _, d := range []struct{ field, value string }{
+			{"grouping.group_wait", g.GroupWait},
+			{"grouping.group_interval", g.GroupInterval},
+			{"grouping.repeat_interval", g.RepeatInterval},
+		} {
+			if err := validDuration(d.field, d.value); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Bounds on a composite rule's condition tree, which is evaluated in full
+// on every tick.
+const (
+	maxConditionDepth  = 4
+	maxConditionLeaves = 10
+)
+
+// validateCondition checks the condition of a rule of type typ. field
+// prefixes error fields, e.g. "condition" or "condition.all[0].condition"
+// for a leaf of a composite rule.
+func validateCondition(field, typ string, c domain.AlertCondition) error {
+	// Log and security rules count events, heartbeat rules check services
+	// and composite rules combine conditions, rather than reading a metric
+	switch typ {
+	case domain.AlertTypeLog:
+		if c.Logs == nil {
+			return invalid(field+".logs", "is required for log rules")
+		}
+	case domain.AlertTypeSecurity:
+		if c.Security == nil {
+			return invalid(field+".security", "is required for security rules")
+		}
+	case domain.AlertTypeHeartbeat:
+		if hb := c.Heartbeat; hb != nil && hb.Status != "" && !oneOf(hb.Status, validHeartbeatStatuses) {
+			return invalid(field+".heartbeat.status", "must be one of "+strings.Join(validHeartbeatStatuses, ", "))
+		}
+	case domain.AlertTypeComposite:
+		if (len(c.All) == 0) == (len(c.Any) == 0) {
+			return invalid(field, "composite rules need exactly one of all or any")
+		}
+		leaves := 0
+		return validateConditionNode(field, domain.ConditionNode{All: c.All, Any: c.Any}, 0, &leaves)
+	default:
+		if c.Metric == "" {
+			return invalid(field+".metric", "is required")
+		}
+	}
+	if len(c.All) > 0 || len(c.Any) > 0 {
+		return invalid(field, "all and any are only supported by composite rules")
+	}
+	// Anomaly rules compare against a baseline and heartbeat rules against
+	// grace periods, not an operator + threshold
+	if typ != domain.AlertTypeAnomaly && typ != domain.AlertTypeHeartbeat && !oneOf(c.Operator, validOperators) {
+		return invalid(field+".operator", "must be one of "+strings.Join(validOperators, ", "))
+	}
+	if err := validDuration(field+".duration", c.Duration); err != nil {
+		return err
+	}
+	if c.Aggregate != "" && !oneOf(c.Aggregate, validAggregates) {
+		return invalid(field+".aggregate", "must be one of "+strings.Join(validAggregates, ", "))
+	}
+	if c.Change != "" && !oneOf(c.Change, validChanges) {
+		return invalid(field+".change", "must be one of "+strings.Join(validChanges, ", "))
+	}
+	if c.Direction != "" && !oneOf(c.Direction, validDirections) {
+		return invalid(field+".direction", "must be one of "+strings.Join(validDirections, ", "))
+	}
+
+	if an := c.Anomaly; an != nil {
+		if an.Method != "" && !oneOf(an.Method, validBaselines) {
+			return invalid(field+".anomaly.method", "must be one of "+strings.Join(validBaselines, ", "))
+		}
+		if an.Sensitivity < 0 {
+			return invalid(field+".anomaly.sensitivity", "must not be negative")
+		}
+		if an.Alpha < 0 || an.Alpha > 1 {
+			return invalid(field+".anomaly.alpha", "must be between 0 and 1")
+		}
+		if err := validDuration(field+".anomaly.history", an.History); err != nil {
+			return err
+		}
+	}
+	if l := c.Logs; l != nil {
+		if l.Level != "" && !oneOf(l.Level, validLogLevels) {
+			return invalid(field+".logs.level", "must be one of "+strings.Join(validLogLevels, ", "))
+		}
+		if _, err := regexp.Compile(l.Pattern); err != nil {
+			return invalid(field+".logs.pattern", "is not a valid regular expression")
+		}
+		for k := range l.Tags {
+			if k == "" || strings.ContainsAny(k, ".$") {
+				return invalid(field+".logs.tags", "names must be non-empty and must not contain '.' or '$'")
+			}
+		}
+	}
+	if sq := c.Security; sq != nil {
+		if sq.Type != "" && !oneOf(sq.Type, validSecurityTypes) {
+			return invalid(field+".security.type", "must be one of "+strings.Join(validSecurityTypes, ", "))
+		}
+		if sq.Severity != "" && !oneOf(sq.Severity, validSecuritySeverities) {
+			return invalid(field+".security.severity", "must be one of "+strings.Join(validSecuritySeverities, ", "))
+		}
+		for i, key := range sq.GroupBy {
+			keyField := fmt.Sprintf("%s.security.group_by[%d]", field, i)
+			if !oneOf(key, validSecurityKeys) {
+				return invalid(keyField, "must be one of "+strings.Join(validSecurityKeys, ", "))
+			}
+			if oneOf(key, sq.GroupBy[:i]) {
+				return invalid(keyField, "is listed twice")
+			}
+		}
+	}
+	return nil
+}
+
+// validateConditionNode checks one node of a composite rule's condition
+// tree and, for groups, its children. leaves counts the leaves seen so far.
+func validateConditionNode(field string, n domain.ConditionNode, depth int, leaves *int) error {
+	if depth > maxConditionDepth {
+		return invalid(field, fmt.Sprintf("nests more than %d levels deep", maxConditionDepth))
+	}
+	kinds := 0
+	for _, set := range []bool{len(n.All) > 0, len(n.Any) > 0, n.Condition != nil} {
+		if set {
+			kinds++
+		}
+	}
+	if kinds != 1 {
+		return invalid(field, "needs exactly one of all, any or condition")
+	}
+
+	if n.Condition == nil {
+		group, op := n.All, "all"
+		if len(n.Any) > 0 {
+			group, op = n.Any, "any"
+		}
+		for i, child := range group {
+			if err := validateConditionNode(fmt.Sprintf("%s.%s[%d]", field, op, i), child, depth+1, leaves); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	if *leaves++; *leaves > maxConditionLeaves {
+		return invalid(field, fmt.Sprintf("composite rules support at most %d conditions", maxConditionLeaves))
+	}
+	typ := n.Type
+	if typ == "" {
+		typ = domain.AlertTypeThreshold
+	}
+	if !oneOf(typ, validLeafTypes) {
+		return invalid(field+".type", "must be one of "+strings.Join(validLeafTypes, ", "))
+	}
+	return validateCondition(field+".condition", typ, *n.Condition)
+}
+
+// validateTarget checks one notification destination. field prefixes error
+// fields, e.g. "targets[0]".
+func validateTarget(field string, t domain.NotificationTarget) error {
+	if !oneOf(t.Type, validChannels) {
+		return invalid(field+".type", "must be one of "+strings.Join(validChannels, ", "))
+	}
+	switch t.Type {
+	case domain.ChannelPagerDuty, domain.ChannelOpsgenie:
+		if t.Key == "" {
+			return invalid(field+".key", "is required for "+t.Type+" targets")
+		}
+		// url optionally overrides the public API endpoint (e.g. EU region)
+		if t.URL != "" && !validURL(t.URL) {
+			return invalid(field+".url", "must be an http or https URL")
+		}
+	case domain.ChannelEmail:
+		if len(t.To) == 0 {
+			return invalid(field+".to", "is required for email targets")
+		}
+		for _, addr := range t.To {
+			if !validEmail(addr) {
+				return invalid(field+".to", fmt.Sprintf("must be plain email addresses, got %q", addr))
+			}
+		}
+	default:
+		if !validURL(t.URL) {
+			return invalid(field+".url", "must be an http or https URL")
+		}
+	}
+	if t.Body != "" {
+		if !oneOf(t.Type, templatedChannels) {
+			return invalid(field+".body", "is only supported for "+strings.Join(templatedChannels, ", ")+" targets")
+		}
+		// Executed against a sample where the rule is known, as escalation
+		// targets may notify both grouped and ungrouped rules
+		if _, err := parseBodyTemplate(t.Body); err != nil {
+			return invalid(field+".body", err.Error())
+		}
+	}
+	return nil
+}
+
+// keepTargetKeys gives each target without a key the key of the stored
+// target at the same position, if that has the same type. API responses
+// omit keys, so a definition read back and written unchanged keeps them.
+func keepTargetKeys(targets, stored []domain.NotificationTarget) {
+	for i := range targets {
+		if targets[i].Key == "" && i < len(stored) && stored[i].Type == targets[i].Type {
+			targets[i].Key = stored[i].Key
+		}
+	}
+}
+
+// validDuration accepts an empty value or a positive Go duration string.
+func validDuration(field, value string) error {
+	if value == "" {
+		return nil
+	}
+	d, err := parseDuration(value)
+	if err != nil || d <= 0 {
+		return invalid(field, fmt.Sprintf("must be a positive duration such as \"5m\", got %q", value))
+	}
+	return nil
+}
+
+func invalid(field, message string) error {
+	return &domain.ValidationError{Field: field, Message: message}
+}
+
+func oneOf(v string, allowed []string) bool {
+	for _, a := range allowed {
+		if v == a {
+			return true
+		}
+	}
+	return false
+}
+
+// validURL reports whether s is an absolute http(s) URL.
+func validURL(s string) bool {
+	u, err := url.Parse(s)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
+
+// validEmail accepts a bare address such as "oncall@example.com", without a
+// display name, so recipient lists can be stored comma-separated.
+func validEmail(s string) bool {
+	addr, err := mail.ParseAddress(s)
+	return err == nil && addr.Address == s
+}
@@ -0,0 +1,121 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/lightwatch/monitoring-platform/services/api-go/internal/domain"
+)
+
+// ManageEscalationPolicies encapsulates escalation policy CRUD operations.
+type ManageEscalationPolicies struct {
+	repo      domain.EscalationPoliciesRepository
+	schedules domain.SchedulesRepository
+	alerts    domain.AlertsRepository
+}
+
+// NewManageEscalationPolicies creates a new ManageEscalationPolicies use case.
+func NewManageEscalationPolicies(
+	repo domain.EscalationPoliciesRepository,
+	schedules domain.SchedulesRepository,
+	alerts domain.AlertsRepository,
+) *ManageEscalationPolicies {
+	return &ManageEscalationPolicies{repo: repo, schedules: schedules, alerts: alerts}
+}
+
+// List returns all escalation policies.
+func (uc *ManageEscalationPolicies) List(ctx context.Context) ([]domain.EscalationPolicy, error) {
+	return uc.repo.FindAll(ctx)
+}
+
+// Get returns a single escalation policy.
+func (uc *ManageEscalationPolicies) Get(ctx context.Context, id string) (*domain.EscalationPolicy, error) {
+	return uc.repo.FindByID(ctx, id)
+}
+
+// Create validates and inserts a new escalation policy.
+func (uc *ManageEscalationPolicies) Create(ctx context.Context, p *domain.EscalationPolicy) (string, error) {
+	if err := uc.validate(ctx, p); err != nil {
+		return "", err
+	}
+	return uc.repo.Create(ctx, p)
+}
+
+// Update validates and replaces an existing escalation policy. Alerts
+// already escalating continue from their next step under the new steps.
+// Step targets without a key keep the stored one, see keepTargetKeys.
+func (uc *ManageEscalationPolicies) Update(ctx context.Context, id string, p *domain.EscalationPolicy) error {
+	existing, err := uc.repo.FindByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	p.ID = id
+	p.CreatedAt = existing.CreatedAt
+	for i := range p.Steps {
+		if i < len(existing.Steps) {
+			keepTargetKeys(p.Steps[i].Targets, existing.Steps[i].Targets)
+		}
+	}
+	if err := uc.validate(ctx, p); err != nil {
+		return err
+	}
+	return uc.repo.Update(ctx, p)
+}
+
+// Delete removes an escalation policy that no alert rule references.
+func (uc *ManageEscalationPolicies) Delete(ctx context.Context, id string) error {
+	rules, err := uc.alerts.FindAll(ctx)
+	if err != nil {
+		return err
+	}
+	for _, rule := range rules {
+		if rule.EscalationPolicy == id {
+			return fmt.Errorf("%w: policy is used by alert rule %q", domain.ErrConflict, rule.Name)
+		}
+	}
+	return uc.repo.Delete(ctx, id)
+}
+
+// validate checks a policy definition, including that the schedules it
+// references exist.
+func (uc *ManageEscalationPolicies) validate(ctx context.Context, p *domain.EscalationPolicy) error {
+	if p.Name == "" {
+		return invalid("name", "is required")
+	}
+	if len(p.Steps) == 0 {
+		return invalid("steps", "at least one step is required")
+	}
+
+	var prev time.Duration
+	for i, step := range p.Steps {
+		field := fmt.Sprintf("steps[%d]", i)
+		if err := validDuration(field+".after", step.After); err != nil {
+			return err
+		}
+		after := stepDelay(step)
+		if after < prev {
+			return invalid(field+".after", "must not be earlier than the previous step")
+		}
+		prev = after
+
+		if len(step.Schedules) == 0 && len(step.Targets) == 0 {
+			return invalid(field, "needs at least one schedule or target")
+		}
+		for j, t := range step.Targets {
+			if err := validateTarget(fmt.Sprintf("%s.targets[%d]", field, j), t); err != nil {
+				return err
+			}
+		}
+		for _, id := range step.Schedules {
+			if _, err := uc.schedules.FindByID(ctx, id); err != nil {
+				if errors.Is(err, domain.ErrNotFound) {
+					return invalid(field+".schedules", fmt.Sprintf("schedule %q does not exist", id))
+				}
+				return err
+			}
+		}
+	}
+	return nil
+}
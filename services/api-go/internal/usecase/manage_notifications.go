@@ -0,0 +1,30 @@
+package usecase
+
+import (
+	"context"
+	"time"
+
+	"github.com/lightwatch/monitoring-platform/services/api-go/internal/domain"
+)
+
+// ManageNotifications encapsulates inspection and manual redelivery of
+// outbox notifications.
+type ManageNotifications struct {
+	repo domain.NotificationsRepository
+}
+
+// NewManageNotifications creates a new ManageNotifications use case.
+func NewManageNotifications(repo domain.NotificationsRepository) *ManageNotifications {
+	return &ManageNotifications{repo: repo}
+}
+
+// List returns notifications matching the given filter.
+func (uc *ManageNotifications) List(ctx context.Context, f domain.NotificationsFilter) ([]domain.Notification, int64, error) {
+	return uc.repo.Find(ctx, f)
+}
+
+// Retry schedules a notification for immediate redelivery with a fresh
+// attempt budget, e.g. after fixing a receiver that caused dead-lettering.
+func (uc *ManageNotifications) Retry(ctx context.Context, id string) (*domain.Notification, error) {
+	return uc.repo.Retry(ctx, id, time.Now())
+}
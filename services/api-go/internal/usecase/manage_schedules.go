@@ -0,0 +1,206 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/lightwatch/monitoring-platform/services/api-go/internal/domain"
+)
+
+// rotationShift is the length of one on-call shift.
+const rotationShift = 7 * 24 * time.Hour
+
+// ManageSchedules encapsulates on-call schedule CRUD and on-call lookups.
+type ManageSchedules struct {
+	repo     domain.SchedulesRepository
+	policies domain.EscalationPoliciesRepository
+}
+
+// NewManageSchedules creates a new ManageSchedules use case.
+func NewManageSchedules(repo domain.SchedulesRepository, policies domain.EscalationPoliciesRepository) *ManageSchedules {
+	return &ManageSchedules{repo: repo, policies: policies}
+}
+
+// List returns all on-call schedules.
+func (uc *ManageSchedules) List(ctx context.Context) ([]domain.OnCallSchedule, error) {
+	return uc.repo.FindAll(ctx)
+}
+
+// Get returns a single on-call schedule.
+func (uc *ManageSchedules) Get(ctx context.Context, id string) (*domain.OnCallSchedule, error) {
+	return uc.repo.FindByID(ctx, id)
+}
+
+// Create validates and inserts a new on-call schedule.
+func (uc *ManageSchedules) Create(ctx context.Context, s *domain.OnCallSchedule) (string, error) {
+	if err := validateSchedule(s); err != nil {
+		return "", err
+	}
+	return uc.repo.Create(ctx, s)
+}
+
+// Update validates and replaces an existing on-call schedule. Member
+// targets without a key keep the stored one, see keepTargetKeys.
+func (uc *ManageSchedules) Update(ctx context.Context, id string, s *domain.OnCallSchedule) error {
+	existing, err := uc.repo.FindByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	s.ID = id
+	s.CreatedAt = existing.CreatedAt
+	for i := range s.Members {
+		if i < len(existing.Members) {
+			keepTargetKeys(s.Members[i].Targets, existing.Members[i].Targets)
+		}
+	}
+	for i := range s.Overrides {
+		if i < len(existing.Overrides) {
+			keepTargetKeys(s.Overrides[i].Member.Targets, existing.Overrides[i].Member.Targets)
+		}
+	}
+	if err := validateSchedule(s); err != nil {
+		return err
+	}
+	return uc.repo.Update(ctx, s)
+}
+
+// Delete removes an on-call schedule that no escalation policy references.
+func (uc *ManageSchedules) Delete(ctx context.Context, id string) error {
+	policies, err := uc.policies.FindAll(ctx)
+	if err != nil {
+		return err
+	}
+	for _, p := range policies {
+		for _, step := range p.Steps {
+			if oneOf(id, step.Schedules) {
+				return fmt.Errorf("%w: schedule is used by escalation policy %q", domain.ErrConflict, p.Name)
+			}
+		}
+	}
+	return uc.repo.Delete(ctx, id)
+}
+
+// OnCall returns who is on call at t for every schedule that has someone
+// on call.
+func (uc *ManageSchedules) OnCall(ctx context.Context, t time.Time) ([]domain.OnCallShift, error) {
+	schedules, err := uc.repo.FindAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+	shifts := make([]domain.OnCallShift, 0, len(schedules))
+	for _, s := range schedules {
+		if shift := onCallAt(s, t); shift != nil {
+			shifts = append(shifts, *shift)
+		}
+	}
+	return shifts, nil
+}
+
+// OnCallFor returns who is on call at t for one schedule, or
+// domain.ErrNotFound if its rotation has not started.
+func (uc *ManageSchedules) OnCallFor(ctx context.Context, id string, t time.Time) (*domain.OnCallShift, error) {
+	s, err := uc.repo.FindByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	shift := onCallAt(*s, t)
+	if shift == nil {
+		return nil, fmt.Errorf("%w: nobody is on call at %s", domain.ErrNotFound, t.UTC().Format(time.RFC3339))
+	}
+	return shift, nil
+}
+
+// onCallAt resolves the shift covering t: the last matching override, or
+// the rotation member whose week it is. It returns nil before the first
+// handoff, unless an override applies.
+func onCallAt(s domain.OnCallSchedule, t time.Time) *domain.OnCallShift {
+	for i := len(s.Overrides) - 1; i >= 0; i-- {
+		o := s.Overrides[i]
+		if !t.Before(o.StartsAt) && t.Before(o.EndsAt) {
+			return &domain.OnCallShift{
+				ScheduleID:   s.ID,
+				ScheduleName: s.Name,
+				Member:       o.Member,
+				StartsAt:     o.StartsAt,
+				EndsAt:       o.EndsAt,
+				Override:     true,
+			}
+		}
+	}
+
+	if len(s.Members) == 0 || t.Before(s.Handoff) {
+		return nil
+	}
+	loc, err := time.LoadLocation(s.Timezone) // "" is UTC
+	if err != nil {
+		loc = time.UTC
+	}
+	// Shifts are counted in calendar weeks of the schedule's time zone, so
+	// the handoff stays at the same wall-clock time across DST changes.
+	first := s.Handoff.In(loc)
+	week := int(t.Sub(first) / rotationShift)
+	for week > 0 && first.AddDate(0, 0, 7*week).After(t) {
+		week--
+	}
+	for !first.AddDate(0, 0, 7*(week+1)).After(t) {
+		week++
+	}
+
+	return &domain.OnCallShift{
+		ScheduleID:   s.ID,
+		ScheduleName: s.Name,
+		Member:       s.Members[week%len(s.Members)],
+		StartsAt:     first.AddDate(0, 0, 7*week),
+		EndsAt:       first.AddDate(0, 0, 7*(week+1)),
+	}
+}
+
+// validateSchedule checks a schedule definition before it is stored.
+func validateSchedule(s *domain.OnCallSchedule) error {
+	if s.Name == "" {
+		return invalid("name", "is required")
+	}
+	if s.Handoff.IsZero() {
+		return invalid("handoff", "is required")
+	}
+	if _, err := time.LoadLocation(s.Timezone); err != nil {
+		return invalid("timezone", "unknown time zone")
+	}
+	if len(s.Members) == 0 {
+		return invalid("members", "at least one member is required")
+	}
+	for i, m := range s.Members {
+		if err := validateMember(fmt.Sprintf("members[%d]", i), m); err != nil {
+			return err
+		}
+	}
+	for i, o := range s.Overrides {
+		field := fmt.Sprintf("overrides[%d]", i)
+		if err := validateMember(field+".member", o.Member); err != nil {
+			return err
+		}
+		if o.StartsAt.IsZero() {
+			return invalid(field+".starts_at", "is required")
+		}
+		if !o.EndsAt.After(o.StartsAt) {
+			return invalid(field+".ends_at", "must be after starts_at")
+		}
+	}
+	return nil
+}
+
+func validateMember(field string, m domain.OnCallMember) error {
+	if m.Name == "" {
+		return invalid(field+".name", "is required")
+	}
+	if len(m.Targets) == 0 {
+		return invalid(field+".targets", "at least one target is required")
+	}
+	for i, t := range m.Targets {
+		if err := validateTarget(fmt.Sprintf("%s.targets[%d]", field, i), t); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,153 @@
+package usecase
+
+import (
+	"testing"
+	"time"
+
+	"github.com/lightwatch/monitoring-platform/services/api-go/internal/domain"
+)
+
+func TestOnCallAt(t *testing.T) {
+	utc := func(s string) time.Time {
+		t, err := time.Parse(time.RFC3339, s)
+		if err != nil {
+			panic(err)
+		}
+		return t
+	}
+	member := func(name string) domain.OnCallMember { return domain.OnCallMember{Name: name} }
+	rotation := domain.OnCallSchedule{
+		ID:      "sched-1",
+		Name:    "Primary",
+		Handoff: utc("2024-03-04T09:00:00Z"),
+		Members: []domain.OnCallMember{member("ana"), member("ben"), member("cai")},
+	}
+	with := func(mod func(*domain.OnCallSchedule)) domain.OnCallSchedule {
+		s := rotation
+		mod(&s)
+		return s
+	}
+	override := func(name, from, to string) domain.OnCallOverride {
+		return domain.OnCallOverride{Member: member(name), StartsAt: utc(from), EndsAt: utc(to)}
+	}
+
+	tests := []struct {
+		name       string
+		schedule   domain.OnCallSchedule
+		at         string
+		wantMember string // "" means nobody is on call
+		wantStart  string
+		wantEnd    string
+		override   bool
+	}{
+		{
+			name:     "before handoff",
+			schedule: rotation,
+			at:       "2024-03-04T08:59:59Z",
+		},
+		{
+			name:     "no members",
+			schedule: with(func(s *domain.OnCallSchedule) { s.Members = nil }),
+			at:       "2024-03-05T00:00:00Z",
+		},
+		{
+			name:       "at handoff",
+			schedule:   rotation,
+			at:         "2024-03-04T09:00:00Z",
+			wantMember: "ana", wantStart: "2024-03-04T09:00:00Z", wantEnd: "2024-03-11T09:00:00Z",
+		},
+		{
+			name:       "last second of a shift",
+			schedule:   rotation,
+			at:         "2024-03-11T08:59:59Z",
+			wantMember: "ana", wantStart: "2024-03-04T09:00:00Z", wantEnd: "2024-03-11T09:00:00Z",
+		},
+		{
+			name:       "second shift",
+			schedule:   rotation,
+			at:         "2024-03-11T09:00:00Z",
+			wantMember: "ben", wantStart: "2024-03-11T09:00:00Z", wantEnd: "2024-03-18T09:00:00Z",
+		},
+		{
+			name:       "rotation wraps",
+			schedule:   rotation,
+			at:         "2024-03-26T12:00:00Z",
+			wantMember: "ana", wantStart: "2024-03-25T09:00:00Z", wantEnd: "2024-04-01T09:00:00Z",
+		},
+		{
+			name:       "unknown time zone falls back to UTC",
+			schedule:   with(func(s *domain.OnCallSchedule) { s.Timezone = "Mars/Olympus" }),
+			at:         "2024-03-11T09:00:00Z",
+			wantMember: "ben", wantStart: "2024-03-11T09:00:00Z", wantEnd: "2024-03-18T09:00:00Z",
+		},
+		{
+			// Berlin moves to CEST on 2024-03-31, so the 09:00 handoff on
+			// 2024-04-01 is at 07:00 UTC rather than 08:00.
+			name: "handoff keeps wall-clock time across DST",
+			schedule: with(func(s *domain.OnCallSchedule) {
+				s.Timezone = "Europe/Berlin"
+				s.Handoff = utc("2024-03-18T08:00:00Z")
+			}),
+			at:         "2024-04-01T07:30:00Z",
+			wantMember: "cai", wantStart: "2024-04-01T07:00:00Z", wantEnd: "2024-04-08T07:00:00Z",
+		},
+		{
+			name: "override replaces the rotation",
+			schedule: with(func(s *domain.OnCallSchedule) {
+				s.Overrides = []domain.OnCallOverride{override("dee", "2024-03-05T00:00:00Z", "2024-03-06T00:00:00Z")}
+			}),
+			at:         "2024-03-05T12:00:00Z",
+			wantMember: "dee", wantStart: "2024-03-05T00:00:00Z", wantEnd: "2024-03-06T00:00:00Z", override: true,
+		},
+		{
+			name: "override end is exclusive",
+			schedule: with(func(s *domain.OnCallSchedule) {
+				s.Overrides = []domain.OnCallOverride{override("dee", "2024-03-05T00:00:00Z", "2024-03-06T00:00:00Z")}
+			}),
+			at:         "2024-03-06T00:00:00Z",
+			wantMember: "ana", wantStart: "2024-03-04T09:00:00Z", wantEnd: "2024-03-11T09:00:00Z",
+		},
+		{
+			name: "last overlapping override wins",
+			schedule: with(func(s *domain.OnCallSchedule) {
+				s.Overrides = []domain.OnCallOverride{
+					override("dee", "2024-03-05T00:00:00Z", "2024-03-07T00:00:00Z"),
+					override("eli", "2024-03-06T00:00:00Z", "2024-03-06T12:00:00Z"),
+				}
+			}),
+			at:         "2024-03-06T06:00:00Z",
+			wantMember: "eli", wantStart: "2024-03-06T00:00:00Z", wantEnd: "2024-03-06T12:00:00Z", override: true,
+		},
+		{
+			name: "override before handoff",
+			schedule: with(func(s *domain.OnCallSchedule) {
+				s.Overrides = []domain.OnCallOverride{override("dee", "2024-03-01T00:00:00Z", "2024-03-02T00:00:00Z")}
+			}),
+			at:         "2024-03-01T12:00:00Z",
+			wantMember: "dee", wantStart: "2024-03-01T00:00:00Z", wantEnd: "2024-03-02T00:00:00Z", override: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := onCallAt(tt.schedule, utc(tt.at))
+			if tt.wantMember == "" {
+				if got != nil {
+					t.Fatalf("onCallAt = %+v, want nobody", got)
+				}
+				return
+			}
+			if got == nil {
+				t.Fatalf("onCallAt = nil, want %s", tt.wantMember)
+			}
+			if got.Member.Name != tt.wantMember || got.Override != tt.override {
+				t.Errorf("member = %s (override %v), want %s (override %v)", got.Member.Name, got.Override, tt.wantMember, tt.override)
+			}
+			if !got.StartsAt.Equal(utc(tt.wantStart)) || !got.EndsAt.Equal(utc(tt.wantEnd)) {
+				t.Errorf("shift = %s..%s, want %s..%s", got.StartsAt.UTC().Format(time.RFC3339), got.EndsAt.UTC().Format(time.RFC3339), tt.wantStart, tt.wantEnd)
+			}
+			if got.ScheduleID != "sched-1" || got.ScheduleName != "Primary" {
+				t.Errorf("schedule = %s/%s", got.ScheduleID, got.ScheduleName)
+			}
+		})
+	}
+}
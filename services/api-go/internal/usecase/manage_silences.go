@@ -0,0 +1,167 @@
+package usecase
+
+import (
+	"context"
+	"time"
+
+	"github.com/lightwatch/monitoring-platform/services/api-go/internal/domain"
+)
+
+// maxMaintenanceWindow caps Schedule.Duration so a window cannot silence a
+// service permanently by accident.
+const maxMaintenanceWindow = 7 * 24 * time.Hour
+
+// ManageSilences encapsulates silence and maintenance window CRUD.
+type ManageSilences struct {
+	repo domain.SilencesRepository
+}
+
+// NewManageSilences creates a new ManageSilences use case.
+func NewManageSilences(repo domain.SilencesRepository) *ManageSilences {
+	return &ManageSilences{repo: repo}
+}
+
+// List returns silences with their current status, optionally only those
+// in the given status.
+func (uc *ManageSilences) List(ctx context.Context, status string) ([]domain.Silence, error) {
+	all, err := uc.repo.FindAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	results := make([]domain.Silence, 0, len(all))
+	for _, s := range all {
+		s.Status = silenceStatus(s, now)
+		if status == "" || s.Status == status {
+			results = append(results, s)
+		}
+	}
+	return results, nil
+}
+
+// Get returns a single silence.
+func (uc *ManageSilences) Get(ctx context.Context, id string) (*domain.Silence, error) {
+	s, err := uc.repo.FindByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	s.Status = silenceStatus(*s, time.Now())
+	return s, nil
+}
+
+// Create validates and inserts a new silence.
+func (uc *ManageSilences) Create(ctx context.Context, s *domain.Silence) (string, error) {
+	if s.StartsAt.IsZero() {
+		s.StartsAt = time.Now()
+	}
+	if err := validateSilence(s); err != nil {
+		return "", err
+	}
+	id, err := uc.repo.Create(ctx, s)
+	if err != nil {
+		return "", err
+	}
+	s.Status = silenceStatus(*s, time.Now())
+	return id, nil
+}
+
+// Update validates and replaces an existing silence. Setting ends_at to
+// now expires it while keeping it on record.
+func (uc *ManageSilences) Update(ctx context.Context, id string, s *domain.Silence) error {
+	existing, err := uc.repo.FindByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	s.ID = id
+	s.CreatedAt = existing.CreatedAt
+	if s.StartsAt.IsZero() {
+		s.StartsAt = existing.StartsAt
+	}
+	if err := validateSilence(s); err != nil {
+		return err
+	}
+	if err := uc.repo.Update(ctx, s); err != nil {
+		return err
+	}
+	s.Status = silenceStatus(*s, time.Now())
+	return nil
+}
+
+// Delete removes a silence.
+func (uc *ManageSilences) Delete(ctx context.Context, id string) error {
+	return uc.repo.Delete(ctx, id)
+}
+
+// validateSilence checks a silence definition before it is stored.
+func validateSilence(s *domain.Silence) error {
+	if len(s.Matchers) == 0 {
+		return invalid("matchers", "at least one matcher is required")
+	}
+	if err := validateMatchers("matchers", s.Matchers); err != nil {
+		return err
+	}
+	if s.CreatedBy == "" {
+		return invalid("created_by", "is required")
+	}
+	if s.EndsAt != nil && !s.EndsAt.After(s.StartsAt) {
+		return invalid("ends_at", "must be after starts_at")
+	}
+
+	sch := s.Schedule
+	if sch == nil {
+		if s.EndsAt == nil {
+			return invalid("ends_at", "is required unless schedule is set")
+		}
+		return nil
+	}
+	if _, err := parseCron(sch.Cron); err != nil {
+		return invalid("schedule.cron", err.Error())
+	}
+	d, err := parseDuration(sch.Duration)
+	if err != nil || d <= 0 || d > maxMaintenanceWindow {
+		return invalid("schedule.duration", "must be a positive duration of at most 168h, such as \"2h\"")
+	}
+	if _, err := time.LoadLocation(sch.Timezone); err != nil {
+		return invalid("schedule.timezone", "unknown time zone")
+	}
+	return nil
+}
+
+// silenceStatus reports whether s suppresses notifications at now.
+func silenceStatus(s domain.Silence, now time.Time) string {
+	if now.Before(s.StartsAt) {
+		return domain.SilenceScheduled
+	}
+	if s.EndsAt != nil && !now.Before(*s.EndsAt) {
+		return domain.SilenceExpired
+	}
+	if s.Schedule == nil {
+		return domain.SilenceActive
+	}
+	if inMaintenanceWindow(*s.Schedule, now) {
+		return domain.SilenceActive
+	}
+	return domain.SilenceScheduled
+}
+
+// inMaintenanceWindow reports whether now falls within Duration of the
+// schedule's latest activation. Invalid schedules never match; they are
+// rejected on save.
+func inMaintenanceWindow(sch domain.MaintenanceSchedule, now time.Time) bool {
+	cron, err := parseCron(sch.Cron)
+	if err != nil {
+		return false
+	}
+	d, err := parseDuration(sch.Duration)
+	if err != nil || d <= 0 {
+		return false
+	}
+	loc, err := time.LoadLocation(sch.Timezone) // "" is UTC
+	if err != nil {
+		return false
+	}
+
+	start, ok := cron.lastActivation(now.In(loc), d)
+	return ok && now.Before(start.Add(d))
+}
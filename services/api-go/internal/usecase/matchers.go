@@ -0,0 +1,80 @@
+package usecase
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/lightwatch/monitoring-platform/services/api-go/internal/domain"
+)
+
+var validMatchOps = []string{domain.MatchEqual, domain.MatchNotEqual, domain.MatchRegexp, domain.MatchNotRegexp}
+
+// labelMatcher is a domain.Matcher with its regular expression compiled.
+type labelMatcher struct {
+	domain.Matcher
+	re *regexp.Regexp
+}
+
+// compileMatchers prepares matchers for evaluation. Regular expressions
+// are anchored at both ends, as in Prometheus.
+func compileMatchers(ms []domain.Matcher) ([]labelMatcher, error) {
+	out := make([]labelMatcher, len(ms))
+	for i, m := range ms {
+		if m.Op == "" {
+			m.Op = domain.MatchEqual
+		}
+		out[i] = labelMatcher{Matcher: m}
+		if m.Op == domain.MatchRegexp || m.Op == domain.MatchNotRegexp {
+			re, err := regexp.Compile("^(?:" + m.Value + ")$")
+			if err != nil {
+				return nil, fmt.Errorf("invalid regular expression %q: %w", m.Value, err)
+			}
+			out[i].re = re
+		}
+	}
+	return out, nil
+}
+
+// matches tests one label; a missing label has the empty value.
+func (m labelMatcher) matches(labels map[string]string) bool {
+	v := labels[m.Label]
+	switch m.Op {
+	case domain.MatchNotEqual:
+		return v != m.Value
+	case domain.MatchRegexp:
+		return m.re.MatchString(v)
+	case domain.MatchNotRegexp:
+		return !m.re.MatchString(v)
+	default:
+		return v == m.Value
+	}
+}
+
+// matchAll reports whether labels satisfy every matcher.
+func matchAll(ms []labelMatcher, labels map[string]string) bool {
+	for _, m := range ms {
+		if !m.matches(labels) {
+			return false
+		}
+	}
+	return true
+}
+
+// validateMatchers checks matchers before they are stored. field prefixes
+// error fields, e.g. "matchers".
+func validateMatchers(field string, ms []domain.Matcher) error {
+	for i, m := range ms {
+		f := fmt.Sprintf("%s[%d]", field, i)
+		if m.Label == "" {
+			return invalid(f+".label", "is required")
+		}
+		if m.Op != "" && !oneOf(m.Op, validMatchOps) {
+			return invalid(f+".op", "must be one of "+strings.Join(validMatchOps, ", "))
+		}
+	}
+	if _, err := compileMatchers(ms); err != nil {
+		return invalid(field, err.Error())
+	}
+	return nil
+}
@@ -0,0 +1,186 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/lightwatch/monitoring-platform/services/api-go/internal/domain"
+	"github.com/lightwatch/monitoring-platform/services/api-go/internal/observability"
+)
+
+// MonitorHeartbeats is the dead man's switch for registered services. A
+// service whose heartbeats stop is moved to degraded once its grace period
+// passes, then to unhealthy. The next heartbeat sets the status the
+// service reports again, so recovery needs no action here. Services that
+// never sent a heartbeat keep the status they registered with.
+//
+// Alerting on missing heartbeats is done by heartbeat rules, which the
+// alert engine evaluates with the same grace periods; see
+// ManageAlerts.EnsureHeartbeatRules for the rules provisioned by default.
+type MonitorHeartbeats struct {
+	services       domain.ServicesRepository
+	degradedAfter  time.Duration // defaults for services without their own grace
+	unhealthyAfter time.Duration
+	logger         *observability.Logger
+}
+
+// NewMonitorHeartbeats creates the heartbeat monitor. degradedAfter and
+// unhealthyAfter are the grace periods of services that do not set their
+// own.
+func NewMonitorHeartbeats(services domain.ServicesRepository, degradedAfter, unhealthyAfter time.Duration, logger *observability.Logger) *MonitorHeartbeats {
+	return &MonitorHeartbeats{
+		services:       services,
+		degradedAfter:  degradedAfter,
+		unhealthyAfter: unhealthyAfter,
+		logger:         logger,
+	}
+}
+
+// Start runs the monitor in a background goroutine, checking every
+// interval until ctx is cancelled.
+func (m *MonitorHeartbeats) Start(ctx context.Context, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		m.logger.Info("heartbeat monitor started", map[string]interface{}{
+			"interval":        interval.String(),
+			"degraded_after":  m.degradedAfter.String(),
+			"unhealthy_after": m.unhealthyAfter.String(),
+		})
+
+		for {
+			select {
+			case <-ctx.Done():
+				m.logger.Info("heartbeat monitor stopped")
+				return
+			case <-ticker.C:
+				if err := m.Tick(ctx); err != nil {
+					m.logger.Error("heartbeat check failed", map[string]interface{}{
+						"error": err.Error(),
+					})
+				}
+			}
+		}
+	}()
+}
+
+// Tick checks every service once, downgrading the status of those whose
+// heartbeats are overdue.
+func (m *MonitorHeartbeats) Tick(ctx context.Context) error {
+	services, err := m.findServices(ctx, "")
+	if err != nil {
+		return fmt.Errorf("fetch services: %w", err)
+	}
+
+	now := time.Now()
+	for _, svc := range services {
+		if svc.LastHeartbeat.IsZero() {
+			continue // never reported; nothing is overdue yet
+		}
+		status := m.heartbeatStatus(svc, now)
+		if statusRank(status) <= statusRank(svc.Status) {
+			continue // fresh, or already at least as bad
+		}
+		updated, err := m.services.UpdateStatus(ctx, svc.Name, svc.LastHeartbeat, status)
+		if err != nil {
+			m.logger.Warn("service status update failed", map[string]interface{}{
+				"service": svc.Name,
+				"error":   err.Error(),
+			})
+			continue
+		}
+		if updated {
+			m.logger.Warn("service heartbeat overdue", map[string]interface{}{
+				"service":        svc.Name,
+				"from":           svc.Status,
+				"to":             status,
+				"last_heartbeat": svc.LastHeartbeat,
+			})
+		}
+	}
+	return nil
+}
+
+// SetGrace overrides the grace periods of one service; nil restores the
+// defaults.
+func (m *MonitorHeartbeats) SetGrace(ctx context.Context, name string, g *domain.HeartbeatGrace) (*domain.Service, error) {
+	if g != nil {
+		if err := validDuration("degraded_after", g.DegradedAfter); err != nil {
+			return nil, err
+		}
+		if err := validDuration("unhealthy_after", g.UnhealthyAfter); err != nil {
+			return nil, err
+		}
+		degraded, unhealthy := m.grace(domain.Service{HeartbeatGrace: g})
+		if unhealthy <= degraded {
+			return nil, invalid("unhealthy_after", "must be longer than degraded_after")
+		}
+		if *g == (domain.HeartbeatGrace{}) {
+			g = nil
+		}
+	}
+	return m.services.SetHeartbeatGrace(ctx, name, g)
+}
+
+// heartbeatStatus is the status a service's heartbeat age alone warrants.
+func (m *MonitorHeartbeats) heartbeatStatus(svc domain.Service, now time.Time) string {
+	degraded, unhealthy := m.grace(svc)
+	age := now.Sub(svc.LastHeartbeat)
+	switch {
+	case age >= unhealthy:
+		return domain.ServiceUnhealthy
+	case age >= degraded:
+		return domain.ServiceDegraded
+	default:
+		return domain.ServiceHealthy
+	}
+}
+
+// grace returns the service's grace periods, falling back to the defaults
+// for those it does not set.
+func (m *MonitorHeartbeats) grace(svc domain.Service) (degraded, unhealthy time.Duration) {
+	degraded, unhealthy = m.degradedAfter, m.unhealthyAfter
+	if g := svc.HeartbeatGrace; g != nil {
+		if d, err := parseDuration(g.DegradedAfter); err == nil && d > 0 {
+			degraded = d
+		}
+		if d, err := parseDuration(g.UnhealthyAfter); err == nil && d > 0 {
+			unhealthy = d
+		}
+	}
+	return degraded, unhealthy
+}
+
+// findServices pages through the registry in name order, optionally for
+// one service. Each page starts after the last name of the one before, so
+// heartbeats arriving during the scan cannot skip or repeat a service.
+func (m *MonitorHeartbeats) findServices(ctx context.Context, name string) ([]domain.Service, error) {
+	f := domain.ServicesFilter{Name: name, Sort: domain.ServicesByName, Limit: 500}
+	var all []domain.Service
+	for {
+		services, _, err := m.services.FindAll(ctx, f)
+		if err != nil {
+			return nil, err
+		}
+		all = append(all, services...)
+		if len(services) < f.Limit {
+			return all, nil
+		}
+		f.After = services[len(services)-1].Name
+	}
+}
+
+// statusRank orders service statuses from healthy to unhealthy. Unknown
+// statuses count as healthy.
+func statusRank(status string) int {
+	switch status {
+	case domain.ServiceDegraded:
+		return 1
+	case domain.ServiceUnhealthy:
+		return 2
+	default:
+		return 0
+	}
+}
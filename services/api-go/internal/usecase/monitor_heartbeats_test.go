@@ -0,0 +1,123 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/lightwatch/monitoring-platform/services/api-go/internal/domain"
+	"github.com/lightwatch/monitoring-platform/services/api-go/internal/observability"
+)
+
+// memServices is an in-memory domain.ServicesRepository. Its beat hook
+// runs before every page, as heartbeats would arrive during a scan.
+type memServices struct {
+	mu       sync.Mutex
+	services map[string]domain.Service
+	updates  map[string]int
+	beat     func(map[string]domain.Service)
+}
+
+func newMemServices(services ...domain.Service) *memServices {
+	m := &memServices{services: make(map[string]domain.Service), updates: make(map[string]int)}
+	for _, svc := range services {
+		m.services[svc.Name] = svc
+	}
+	return m
+}
+
+func (m *memServices) FindAll(_ context.Context, f domain.ServicesFilter) ([]domain.Service, int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.beat != nil {
+		m.beat(m.services)
+	}
+	var out []domain.Service
+	for _, svc := range m.services {
+		if f.Name != "" && svc.Name != f.Name || f.Status != "" && svc.Status != f.Status {
+			continue
+		}
+		if f.Sort == domain.ServicesByName && svc.Name <= f.After {
+			continue
+		}
+		out = append(out, svc)
+	}
+	total := int64(len(out))
+	sort.Slice(out, func(i, j int) bool {
+		if f.Sort == domain.ServicesByName {
+			return out[i].Name < out[j].Name
+		}
+		return out[i].LastHeartbeat.After(out[j].LastHeartbeat)
+	})
+	skip := (max(f.Page, 1) - 1) * f.Limit
+	out = out[min(skip, len(out)):]
+	return out[:min(f.Limit, len(out))], total, nil
+}
+
+func (m *memServices) UpdateStatus(_ context.Context, name string, lastHeartbeat time.Time, status string) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.updates[name]++
+	svc, ok := m.services[name]
+	if !ok || svc.LastHeartbeat.After(lastHeartbeat) {
+		return false, nil
+	}
+	svc.Status = status
+	m.services[name] = svc
+	return true, nil
+}
+
+func (m *memServices) SetHeartbeatGrace(_ context.Context, name string, g *domain.HeartbeatGrace) (*domain.Service, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	svc, ok := m.services[name]
+	if !ok {
+		return nil, domain.ErrNotFound
+	}
+	svc.HeartbeatGrace = g
+	m.services[name] = svc
+	return &svc, nil
+}
+
+func TestHeartbeatScanSeesEveryServiceOnce(t *testing.T) {
+	start := time.Now().Add(-time.Hour)
+	var services []domain.Service
+	for i := 0; i < 1234; i++ {
+		services = append(services, domain.Service{
+			Name:          fmt.Sprintf("svc-%04d", i),
+			Status:        domain.ServiceHealthy,
+			LastHeartbeat: start.Add(time.Duration(i) * time.Millisecond),
+		})
+	}
+	repo := newMemServices(services...)
+	// Between pages, the service with the oldest heartbeat reports again
+	// without changing its status, which reorders a heartbeat sort
+	repo.beat = func(services map[string]domain.Service) {
+		var oldest domain.Service
+		for _, svc := range services {
+			if oldest.Name == "" || svc.LastHeartbeat.Before(oldest.LastHeartbeat) {
+				oldest = svc
+			}
+		}
+		oldest.LastHeartbeat = oldest.LastHeartbeat.Add(time.Minute)
+		services[oldest.Name] = oldest
+	}
+
+	m := NewMonitorHeartbeats(repo, 5*time.Minute, 30*time.Minute, observability.Discard())
+	if err := m.Tick(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	var wrong []string
+	for _, svc := range services {
+		if n := repo.updates[svc.Name]; n != 1 {
+			wrong = append(wrong, fmt.Sprintf("%s checked %d times", svc.Name, n))
+		}
+	}
+	if len(wrong) > 0 {
+		t.Fatalf("%d services not checked once: %s", len(wrong), strings.Join(wrong[:min(len(wrong), 5)], ", "))
+	}
+}
@@ -0,0 +1,129 @@
+package usecase
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/lightwatch/monitoring-platform/services/api-go/internal/domain"
+	"github.com/lightwatch/monitoring-platform/services/api-go/pkg/webhook"
+)
+
+// Notifier delivers one outbox notification over a single channel. p is the
+// rendered alert transition; n carries the target and channel settings.
+// A returned error marks the attempt as failed and schedules a retry.
+type Notifier interface {
+	Notify(ctx context.Context, n *domain.Notification, p webhook.Payload) error
+}
+
+// GroupNotifier is implemented by notifiers that can send several
+// notifications for one target as a single message. Notifications of
+// grouped rules are delivered through NotifyBatch, one call per alert
+// group; other notifiers receive them one at a time. ns and ps are
+// parallel slices ordered by creation.
+type GroupNotifier interface {
+	Notifier
+	NotifyBatch(ctx context.Context, ns []*domain.Notification, ps []webhook.Payload) error
+}
+
+// BatchNotifier is a GroupNotifier that also combines ungrouped
+// notifications queued for one target, such as email digests. The first
+// notification for a target is held until the current window of
+// BatchWindow length closes; everything due for that target is then sent
+// through one NotifyBatch call.
+type BatchNotifier interface {
+	GroupNotifier
+	BatchWindow() time.Duration
+}
+
+// WebhookNotifier posts the generic JSON payload, or the target's body
+// template, and signs it when the rule has webhook secrets.
+type WebhookNotifier struct {
+	client *http.Client
+}
+
+// NewWebhookNotifier creates the generic webhook channel.
+func NewWebhookNotifier(client *http.Client) *WebhookNotifier {
+	return &WebhookNotifier{client: client}
+}
+
+// Notify implements Notifier.
+func (wn *WebhookNotifier) Notify(ctx context.Context, n *domain.Notification, p webhook.Payload) error {
+	body, err := renderWebhookBody(n, p)
+	if err != nil {
+		return err
+	}
+
+	return wn.post(ctx, n, body)
+}
+
+// NotifyBatch implements GroupNotifier. The group is sent as one
+// webhook.GroupPayload; its notifications share their template and
+// secrets, as ClaimBatch only batches alike ones.
+func (wn *WebhookNotifier) NotifyBatch(ctx context.Context, ns []*domain.Notification, ps []webhook.Payload) error {
+	n := ns[0]
+	if n.Group == nil {
+		return errors.New("webhook batch without an alert group")
+	}
+	body, err := renderWebhookBody(n, groupPayload(n.Group, latestPerEvent(ns, ps)))
+	if err != nil {
+		return err
+	}
+	return wn.post(ctx, n, body)
+}
+
+func (wn *WebhookNotifier) post(ctx context.Context, n *domain.Notification, body []byte) error {
+	header := http.Header{}
+	// Signed per attempt so the timestamp reflects this delivery, not the
+	// original enqueue, and retries stay inside the receiver's tolerance.
+	if len(n.Secrets) > 0 {
+		header.Set(webhook.SignatureHeader, webhook.Header(n.Secrets, time.Now(), body))
+	}
+	return postJSON(ctx, wn.client, n.Target, body, header)
+}
+
+// postJSON sends body to url and treats any non-2xx response as a failure.
+func postJSON(ctx context.Context, client *http.Client, url string, body []byte, header http.Header) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("build request: %w", err)
+	}
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("receiver responded %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// postTemplate posts a target's body template rendered against payload, a
+// webhook.Payload or a webhook.GroupPayload, in place of a chat message.
+func postTemplate(ctx context.Context, client *http.Client, n *domain.Notification, payload interface{}) error {
+	body, err := renderWebhookBody(n, payload)
+	if err != nil {
+		return err
+	}
+	return postJSON(ctx, client, n.Target, body, nil)
+}
+
+// marshalAndPost encodes a chat message and posts it to the target.
+func marshalAndPost(ctx context.Context, client *http.Client, url string, msg interface{}) error {
+	body, err := json.Marshal(msg)
+	if err != nil {
+		return fmt.Errorf("encode message: %w", err)
+	}
+	return postJSON(ctx, client, url, body, nil)
+}
@@ -0,0 +1,46 @@
+package usecase
+
+import (
+	"context"
+
+	"github.com/lightwatch/monitoring-platform/services/api-go/internal/domain"
+)
+
+// QueryAlertEvents encapsulates the use case of querying alert event history.
+type QueryAlertEvents struct {
+	repo   domain.AlertEventsRepository
+	alerts domain.AlertsRepository
+}
+
+// NewQueryAlertEvents creates a new QueryAlertEvents use case.
+func NewQueryAlertEvents(repo domain.AlertEventsRepository, alerts domain.AlertsRepository) *QueryAlertEvents {
+	return &QueryAlertEvents{repo: repo, alerts: alerts}
+}
+
+// Execute runs the alert events query with the given filter.
+func (uc *QueryAlertEvents) Execute(ctx context.Context, f domain.AlertEventsFilter) ([]domain.AlertEvent, int64, error) {
+	return uc.repo.Find(ctx, f)
+}
+
+// ForAlert returns the event history of one alert rule, or
+// domain.ErrNotFound if the rule does not exist.
+func (uc *QueryAlertEvents) ForAlert(ctx context.Context, alertID string, f domain.AlertEventsFilter) ([]domain.AlertEvent, int64, error) {
+	if _, err := uc.alerts.FindByID(ctx, alertID); err != nil {
+		return nil, 0, err
+	}
+	f.AlertID = alertID
+	return uc.repo.Find(ctx, f)
+}
+
+// Get returns one event of an alert rule, or domain.ErrNotFound if the
+// rule has no such event.
+func (uc *QueryAlertEvents) Get(ctx context.Context, alertID, eventID string) (*domain.AlertEvent, error) {
+	evt, err := uc.repo.FindByID(ctx, eventID)
+	if err != nil {
+		return nil, err
+	}
+	if evt.AlertID != alertID {
+		return nil, domain.ErrNotFound
+	}
+	return evt, nil
+}
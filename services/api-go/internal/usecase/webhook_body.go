@@ -0,0 +1,129 @@
+package usecase
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/lightwatch/monitoring-platform/services/api-go/internal/domain"
+	"github.com/lightwatch/monitoring-platform/services/api-go/pkg/webhook"
+)
+
+// bodyTemplateFuncs are available to user-defined webhook body templates.
+// json is the one to reach for: it encodes any value — strings included —
+// as a JSON literal, so rule names or labels cannot break the document.
+var bodyTemplateFuncs = template.FuncMap{
+	"json": func(v interface{}) (string, error) {
+		b, err := json.Marshal(v)
+		return string(b), err
+	},
+	"upper": strings.ToUpper,
+	"lower": strings.ToLower,
+	"rfc3339": func(t time.Time) string {
+		return t.UTC().Format(time.RFC3339)
+	},
+}
+
+// webhookPayload builds the delivery document for a notification. link
+// points back to its alert event.
+func webhookPayload(n *domain.Notification, link string) webhook.Payload {
+	evt := n.Event
+	rule := n.Rule
+	if rule.ID == "" {
+		// Queued before rule snapshots were stored.
+		rule = domain.RuleInfo{ID: evt.AlertID, Name: evt.AlertName}
+	}
+
+	return webhook.Payload{
+		Version:   webhook.PayloadVersion,
+		EventID:   evt.ID,
+		Status:    evt.Status,
+		AlertID:   evt.AlertID,
+		AlertName: evt.AlertName,
+		Service:   evt.Service,
+		Value:     evt.Value,
+		Threshold: evt.Threshold,
+		Labels:    evt.Labels,
+		Rule: webhook.Rule{
+			ID:        rule.ID,
+			Name:      rule.Name,
+			Type:      rule.Type,
+			Severity:  rule.Severity,
+			Metric:    rule.Condition.Metric,
+			Operator:  rule.Condition.Operator,
+			Threshold: rule.Condition.Threshold,
+			Duration:  rule.Condition.Duration,
+		},
+		Meta:        evt.Meta,
+		TriggeredAt: evt.TriggeredAt,
+		ResolvedAt:  evt.ResolvedAt,
+		Link:        link,
+	}
+}
+
+// renderWebhookBody returns the request body for a webhook notification:
+// the target's body template executed against the payload if one is set,
+// otherwise the payload marshalled as JSON. payload is a webhook.Payload,
+// or a webhook.GroupPayload for grouped rules.
+func renderWebhookBody(n *domain.Notification, payload interface{}) ([]byte, error) {
+	if n.Template == "" {
+		return json.Marshal(payload)
+	}
+
+	tmpl, err := parseBodyTemplate(n.Template)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, payload); err != nil {
+		return nil, fmt.Errorf("render body template: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+func parseBodyTemplate(text string) (*template.Template, error) {
+	tmpl, err := template.New("body").Funcs(bodyTemplateFuncs).Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("parse body template: %w", err)
+	}
+	return tmpl, nil
+}
+
+// checkBodyTemplate validates a body template when a rule is saved. Parsing
+// alone accepts references to fields that do not exist, so the template is
+// also executed against a sample payload — a group envelope if the rule
+// groups its notifications.
+func checkBodyTemplate(text string, grouped bool) error {
+	tmpl, err := parseBodyTemplate(text)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	var sample interface{} = webhook.Payload{
+		Version:     webhook.PayloadVersion,
+		EventID:     "sample",
+		Status:      domain.AlertStateFiring,
+		AlertName:   "sample",
+		Labels:      map[string]string{},
+		Meta:        map[string]interface{}{},
+		TriggeredAt: now,
+		ResolvedAt:  &now,
+	}
+	if grouped {
+		sample = webhook.GroupPayload{
+			Version:     webhook.PayloadVersion,
+			GroupKey:    "{}",
+			GroupLabels: map[string]string{},
+			Status:      domain.AlertStateFiring,
+			Alerts:      []webhook.Payload{sample.(webhook.Payload)},
+		}
+	}
+	if err := tmpl.Execute(io.Discard, sample); err != nil {
+		return fmt.Errorf("render body template: %w", err)
+	}
+	return nil
+}
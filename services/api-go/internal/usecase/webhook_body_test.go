@@ -0,0 +1,109 @@
+package usecase
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/lightwatch/monitoring-platform/services/api-go/internal/domain"
+	"github.com/lightwatch/monitoring-platform/services/api-go/pkg/webhook"
+)
+
+func TestWebhookNotify(t *testing.T) {
+	const secret = "whsec_0123456789abcdef"
+	n := domain.Notification{
+		ID: "n-1", AlertID: "rule-1", EventID: "evt-1", Channel: domain.ChannelWebhook,
+		Event: domain.AlertEvent{
+			ID: "evt-1", AlertID: "rule-1", AlertName: `Disk "/" full`, Service: "db", Status: domain.AlertStateFiring,
+			Value: 97, Threshold: 90, Labels: map[string]string{"mount": `"/"`},
+			TriggeredAt: time.Date(2026, 10, 17, 9, 0, 0, 0, time.UTC),
+		},
+		Rule: domain.RuleInfo{ID: "rule-1", Name: `Disk "/" full`, Type: domain.AlertTypeThreshold, Severity: "critical"},
+	}
+	link := "http://localhost:3003/api/alerts/rule-1/events/evt-1"
+
+	tests := []struct {
+		name     string
+		template string
+		secrets  []string
+		want     string // exact body; empty for the default payload
+	}{
+		{name: "default payload"},
+		{name: "signed", secrets: []string{secret}},
+		{name: "template", template: `{"summary": {{json .AlertName}}, "state": "{{upper .Status}}", "at": "{{rfc3339 .TriggeredAt}}"}`,
+			want: `{"summary": "Disk \"/\" full", "state": "FIRING", "at": "2026-10-17T09:00:00Z"}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rc := newReceiver(t, http.StatusNoContent)
+			n := n
+			n.Target, n.Template, n.Secrets = rc.URL, tt.template, tt.secrets
+
+			if err := NewWebhookNotifier(http.DefaultClient).Notify(context.Background(), &n, webhookPayload(&n, link)); err != nil {
+				t.Fatal(err)
+			}
+			rc.mu.Lock()
+			defer rc.mu.Unlock()
+			if len(rc.requests) != 1 {
+				t.Fatalf("receiver got %d requests, want 1", len(rc.requests))
+			}
+			req := rc.requests[0]
+			if ct := req.header.Get("Content-Type"); ct != "application/json" {
+				t.Errorf("Content-Type = %q", ct)
+			}
+			sig := req.header.Get(webhook.SignatureHeader)
+			if len(tt.secrets) == 0 && sig != "" {
+				t.Errorf("unsigned rule sent %s", sig)
+			}
+			if len(tt.secrets) > 0 {
+				if err := webhook.Verify(sig, req.body, tt.secrets, webhook.DefaultTolerance, time.Now()); err != nil {
+					t.Errorf("signature: %v", err)
+				}
+			}
+			if tt.want != "" {
+				if string(req.body) != tt.want {
+					t.Fatalf("body = %s, want %s", req.body, tt.want)
+				}
+				return
+			}
+
+			var p webhook.Payload
+			if err := json.Unmarshal(req.body, &p); err != nil {
+				t.Fatalf("body is not JSON: %v: %s", err, req.body)
+			}
+			if p.AlertName != n.Event.AlertName || p.Labels["mount"] != `"/"` || p.EventID != "evt-1" || p.Link != link {
+				t.Errorf("payload = %+v", p)
+			}
+			if p.Rule.ID != "rule-1" || p.Rule.Severity != "critical" || p.Version != webhook.PayloadVersion {
+				t.Errorf("payload rule = %+v, version %q", p.Rule, p.Version)
+			}
+		})
+	}
+}
+
+func TestCheckBodyTemplate(t *testing.T) {
+	tests := []struct {
+		name    string
+		text    string
+		grouped bool
+		wantErr bool
+	}{
+		{name: "payload fields", text: `{"text": {{json .AlertName}}, "labels": {{json .Labels}}}`},
+		{name: "unknown field", text: `{"text": {{json .Title}}}`, wantErr: true},
+		{name: "syntax error", text: `{"text": {{json .AlertName}`, wantErr: true},
+		{name: "unknown function", text: `{{title .AlertName}}`, wantErr: true},
+		{name: "group envelope", text: `{"count": {{len .Alerts}}, "key": {{json .GroupKey}}}`, grouped: true},
+		{name: "group envelope, ungrouped rule", text: `{"count": {{len .Alerts}}}`, wantErr: true},
+		{name: "payload fields, grouped rule", text: `{"text": {{json .AlertName}}}`, grouped: true, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkBodyTemplate(tt.text, tt.grouped)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("checkBodyTemplate error = %v, want error %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,50 @@
+package webhook
+
+import "time"
+
+// PayloadVersion is the schema version of Payload.
+const PayloadVersion = "1"
+
+// Payload is the default JSON body of a Lightwatch webhook delivery.
+// Rules with a custom body template receive the same fields as template
+// data instead.
+type Payload struct {
+	Version     string                 `json:"version"`
+	EventID     string                 `json:"event_id"`
+	Status      string                 `json:"status"` // firing, resolved
+	AlertID     string                 `json:"alert_id"`
+	AlertName   string                 `json:"alert_name"`
+	Service     string                 `json:"service"`
+	Value       float64                `json:"value"`
+	Threshold   float64                `json:"threshold"`
+	Labels      map[string]string      `json:"labels,omitempty"`
+	Rule        Rule                   `json:"rule"`
+	Meta        map[string]interface{} `json:"meta,omitempty"`
+	TriggeredAt time.Time              `json:"triggered_at"`
+	ResolvedAt  *time.Time             `json:"resolved_at,omitempty"`
+	Link        string                 `json:"link,omitempty"`
+}
+
+// Rule describes the alert rule that produced a delivery.
+type Rule struct {
+	ID        string  `json:"id"`
+	Name      string  `json:"name"`
+	Type      string  `json:"type"`
+	Severity  string  `json:"severity,omitempty"`
+	Metric    string  `json:"metric,omitempty"`
+	Operator  string  `json:"operator,omitempty"`
+	Threshold float64 `json:"threshold"`
+	Duration  string  `json:"duration,omitempty"`
+}
+
+// GroupPayload is the JSON body of a webhook delivery for a rule that
+// groups its notifications: every alert of the group that changed since
+// the group's previous message, or is being repeated. Templated rules
+// receive it as template data.
+type GroupPayload struct {
+	Version     string            `json:"version"`
+	GroupKey    string            `json:"group_key"`
+	GroupLabels map[string]string `json:"group_labels"`
+	Status      string            `json:"status"` // firing while any alert in Alerts fires, else resolved
+	Alerts      []Payload         `json:"alerts"`
+}
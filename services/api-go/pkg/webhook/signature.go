@@ -0,0 +1,139 @@
+// Package webhook signs and verifies Lightwatch webhook deliveries.
+//
+// Every delivery from a rule with signing secrets carries a header
+//
+//	X-Lightwatch-Signature: t=1700000000,v1=5257a869...,v1=9f1c0e2b...
+//
+// where t is the Unix time of the attempt and each v1 is the hex-encoded
+// HMAC-SHA256 of "<t>.<raw body>" under one active secret. During a secret
+// rotation a rule has two active secrets and the header carries one v1 per
+// secret, so receivers holding either secret accept the call.
+//
+// Receivers should verify against the raw request body and reject
+// timestamps outside a small tolerance to prevent replays:
+//
+//	body, err := webhook.VerifyRequest(r, []string{secret}, webhook.DefaultTolerance)
+//	if err != nil {
+//		http.Error(w, err.Error(), http.StatusUnauthorized)
+//		return
+//	}
+package webhook
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SignatureHeader is the HTTP header carrying the delivery signature.
+const SignatureHeader = "X-Lightwatch-Signature"
+
+// DefaultTolerance is the recommended maximum age of a delivery.
+const DefaultTolerance = 5 * time.Minute
+
+// Verification errors.
+var (
+	ErrMissingSignature = errors.New("webhook: missing signature header")
+	ErrInvalidHeader    = errors.New("webhook: malformed signature header")
+	ErrTimestampExpired = errors.New("webhook: timestamp outside tolerance")
+	ErrNoValidSignature = errors.New("webhook: no signature matches")
+)
+
+// Sign returns the hex-encoded HMAC-SHA256 of "<timestamp>.<body>".
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// Header builds the signature header value for body signed at t with every
+// given secret.
+func Header(secrets []string, t time.Time, body []byte) string {
+	ts := t.Unix()
+	parts := make([]string, 0, len(secrets)+1)
+	parts = append(parts, "t="+strconv.FormatInt(ts, 10))
+	for _, s := range secrets {
+		parts = append(parts, "v1="+Sign(s, ts, body))
+	}
+	return strings.Join(parts, ",")
+}
+
+// Verify checks a signature header against body. It succeeds if the
+// timestamp is within tolerance of now and any v1 signature matches any of
+// the receiver's secrets. A tolerance of zero disables the age check.
+func Verify(header string, body []byte, secrets []string, tolerance time.Duration, now time.Time) error {
+	if header == "" {
+		return ErrMissingSignature
+	}
+
+	var (
+		ts     int64
+		hasTS  bool
+		hashes [][]byte
+	)
+	for _, part := range strings.Split(header, ",") {
+		k, v, ok := strings.Cut(strings.TrimSpace(part), "=")
+		if !ok {
+			return ErrInvalidHeader
+		}
+		switch k {
+		case "t":
+			n, err := strconv.ParseInt(v, 10, 64)
+			if err != nil {
+				return ErrInvalidHeader
+			}
+			ts, hasTS = n, true
+		case "v1":
+			h, err := hex.DecodeString(v)
+			if err != nil {
+				return ErrInvalidHeader
+			}
+			hashes = append(hashes, h)
+		}
+	}
+	if !hasTS || len(hashes) == 0 {
+		return ErrInvalidHeader
+	}
+
+	if tolerance > 0 {
+		age := now.Sub(time.Unix(ts, 0))
+		if age > tolerance || age < -tolerance {
+			return ErrTimestampExpired
+		}
+	}
+
+	for _, secret := range secrets {
+		expected, _ := hex.DecodeString(Sign(secret, ts, body))
+		for _, h := range hashes {
+			if hmac.Equal(expected, h) {
+				return nil
+			}
+		}
+	}
+	return ErrNoValidSignature
+}
+
+// VerifyRequest reads r's body, verifies its signature header and returns
+// the body. r.Body is replaced so it can be read again by the caller.
+func VerifyRequest(r *http.Request, secrets []string, tolerance time.Duration) ([]byte, error) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		return nil, err
+	}
+	r.Body.Close()
+	r.Body = io.NopCloser(bytes.NewReader(body))
+
+	if err := Verify(r.Header.Get(SignatureHeader), body, secrets, tolerance, time.Now()); err != nil {
+		return nil, err
+	}
+	return body, nil
+}
@@ -0,0 +1,118 @@
+package webhook
+
+import (
+	"errors"
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+const (
+	sigA = "a4b39d8bb2e6dfa1ea9f2ea037550b767cb1f592e0a81565c3a565232799943d"
+	sigB = "e875e8398471960a6dca4815ee984f2af3c20c4bde8009a508484589173e5116"
+)
+
+var (
+	signedAt = time.Unix(1700000000, 0)
+	body     = []byte(`{"a":1}`)
+)
+
+func TestSign(t *testing.T) {
+	if got := Sign("whsec_a", signedAt.Unix(), body); got != sigA {
+		t.Fatalf("Sign = %s, want %s", got, sigA)
+	}
+}
+
+func TestHeader(t *testing.T) {
+	tests := []struct {
+		name    string
+		secrets []string
+		want    string
+	}{
+		{name: "no secrets", want: "t=1700000000"},
+		{name: "one secret", secrets: []string{"whsec_a"}, want: "t=1700000000,v1=" + sigA},
+		{name: "rotation", secrets: []string{"whsec_a", "whsec_b"}, want: "t=1700000000,v1=" + sigA + ",v1=" + sigB},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Header(tt.secrets, signedAt, body); got != tt.want {
+				t.Fatalf("Header = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestVerify(t *testing.T) {
+	rotated := Header([]string{"whsec_a", "whsec_b"}, signedAt, body)
+	tests := []struct {
+		name      string
+		header    string
+		body      []byte
+		secrets   []string
+		tolerance time.Duration
+		now       time.Time
+		want      error
+	}{
+		{name: "valid", header: "t=1700000000,v1=" + sigA, secrets: []string{"whsec_a"}},
+		{name: "spaces around parts", header: "t=1700000000, v1=" + sigA, secrets: []string{"whsec_a"}},
+		{name: "old secret during rotation", header: rotated, secrets: []string{"whsec_a"}},
+		{name: "new secret during rotation", header: rotated, secrets: []string{"whsec_b"}},
+		{name: "receiver holds both", header: "t=1700000000,v1=" + sigB, secrets: []string{"whsec_a", "whsec_b"}},
+		{name: "unknown scheme ignored", header: "t=1700000000,v0=abc,v1=" + sigA, secrets: []string{"whsec_a"}},
+		{name: "within tolerance", header: "t=1700000000,v1=" + sigA, secrets: []string{"whsec_a"}, tolerance: DefaultTolerance, now: signedAt.Add(4 * time.Minute)},
+		{name: "clock skew within tolerance", header: "t=1700000000,v1=" + sigA, secrets: []string{"whsec_a"}, tolerance: DefaultTolerance, now: signedAt.Add(-4 * time.Minute)},
+		{name: "zero tolerance skips age", header: "t=1700000000,v1=" + sigA, secrets: []string{"whsec_a"}, now: signedAt.Add(24 * time.Hour)},
+		{name: "missing", secrets: []string{"whsec_a"}, want: ErrMissingSignature},
+		{name: "no timestamp", header: "v1=" + sigA, secrets: []string{"whsec_a"}, want: ErrInvalidHeader},
+		{name: "no signature", header: "t=1700000000", secrets: []string{"whsec_a"}, want: ErrInvalidHeader},
+		{name: "bad timestamp", header: "t=soon,v1=" + sigA, secrets: []string{"whsec_a"}, want: ErrInvalidHeader},
+		{name: "bad hex", header: "t=1700000000,v1=zz", secrets: []string{"whsec_a"}, want: ErrInvalidHeader},
+		{name: "part without equals", header: "t=1700000000,v1", secrets: []string{"whsec_a"}, want: ErrInvalidHeader},
+		{name: "expired", header: "t=1700000000,v1=" + sigA, secrets: []string{"whsec_a"}, tolerance: DefaultTolerance, now: signedAt.Add(6 * time.Minute), want: ErrTimestampExpired},
+		{name: "from the future", header: "t=1700000000,v1=" + sigA, secrets: []string{"whsec_a"}, tolerance: DefaultTolerance, now: signedAt.Add(-6 * time.Minute), want: ErrTimestampExpired},
+		{name: "wrong secret", header: "t=1700000000,v1=" + sigA, secrets: []string{"whsec_b"}, want: ErrNoValidSignature},
+		{name: "tampered body", header: "t=1700000000,v1=" + sigA, body: []byte(`{"a":2}`), secrets: []string{"whsec_a"}, want: ErrNoValidSignature},
+		{name: "replayed timestamp", header: "t=1700000001,v1=" + sigA, secrets: []string{"whsec_a"}, want: ErrNoValidSignature},
+		{name: "no receiver secrets", header: "t=1700000000,v1=" + sigA, want: ErrNoValidSignature},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := tt.body
+			if b == nil {
+				b = body
+			}
+			now := tt.now
+			if now.IsZero() {
+				now = signedAt
+			}
+			if err := Verify(tt.header, b, tt.secrets, tt.tolerance, now); !errors.Is(err, tt.want) {
+				t.Fatalf("Verify = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestVerifyRequest(t *testing.T) {
+	r := httptest.NewRequest("POST", "/hook", strings.NewReader(string(body)))
+	r.Header.Set(SignatureHeader, Header([]string{"whsec_a"}, time.Now(), body))
+
+	got, err := VerifyRequest(r, []string{"whsec_a"}, DefaultTolerance)
+	if err != nil {
+		t.Fatalf("VerifyRequest: %v", err)
+	}
+	if string(got) != string(body) {
+		t.Fatalf("body = %s, want %s", got, body)
+	}
+	again, _ := io.ReadAll(r.Body)
+	if string(again) != string(body) {
+		t.Fatalf("body not restored: %s", again)
+	}
+
+	r = httptest.NewRequest("POST", "/hook", strings.NewReader(string(body)))
+	r.Header.Set(SignatureHeader, Header([]string{"whsec_a"}, time.Now().Add(-time.Hour), body))
+	if _, err := VerifyRequest(r, []string{"whsec_a"}, DefaultTolerance); !errors.Is(err, ErrTimestampExpired) {
+		t.Fatalf("VerifyRequest = %v, want %v", err, ErrTimestampExpired)
+	}
+}